@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tasker/internal/apitoken"
+	"tasker/internal/auth"
+	"tasker/internal/migrate"
+	"tasker/internal/repository"
+)
+
+const migrateUsage = "usage: server migrate up|down|status|to <version>"
+
+// runMigrateCommand handles `server migrate up|down|status|to N`
+func runMigrateCommand(m *migrate.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		return m.Up()
+	case "down":
+		return m.Down()
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], err)
+		}
+		return m.To(version)
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%06d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+const tokenUsage = "usage: server token create --email <email> --name <name> --scopes <scope,...> [--expires-days N]"
+
+// runTokenCommand handles `server token create`, which mints an API token for
+// an existing user so the first script can be set up without a browser session
+func runTokenCommand(args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return errors.New(tokenUsage)
+	}
+
+	fs := flag.NewFlagSet("token create", flag.ContinueOnError)
+	email := fs.String("email", "", "email of the user who will own the token")
+	name := fs.String("name", "", "name to identify the token by")
+	scopeList := fs.String("scopes", "", "comma-separated scopes: "+strings.Join(apitoken.AllScopes, ","))
+	expiresDays := fs.Int("expires-days", 0, "days until the token expires; 0 never expires")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *email == "" || strings.TrimSpace(*name) == "" || *scopeList == "" || *expiresDays < 0 {
+		return errors.New(tokenUsage)
+	}
+
+	scopes := strings.Split(*scopeList, ",")
+	for i, s := range scopes {
+		scopes[i] = strings.TrimSpace(s)
+		if !slices.Contains(apitoken.AllScopes, scopes[i]) {
+			return fmt.Errorf("unknown scope %q", scopes[i])
+		}
+	}
+
+	u, err := repository.Users.GetUserByEmail(*email)
+	if err != nil {
+		return err
+	}
+
+	token, hash := auth.GenerateAPIToken()
+	newToken := apitoken.APIToken{
+		Name:   strings.TrimSpace(*name),
+		Prefix: apitoken.DisplayPrefix(token),
+		Scopes: scopes,
+	}
+	if *expiresDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, *expiresDays)
+		newToken.ExpiresAt = &expiresAt
+	}
+
+	if _, err := repository.APITokens.CreateToken(u.ID, newToken, hash); err != nil {
+		return err
+	}
+
+	fmt.Println(token)
+	fmt.Fprintln(os.Stderr, "Store this token now; it cannot be shown again.")
+	return nil
+}
@@ -1,13 +1,132 @@
 package task
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultKeyPrefix is used for tasks created without an explicit key prefix
+const DefaultKeyPrefix = "TASK"
+
+// Priorities lists the allowed priorities from lowest to highest.
+// Statuses depend on the board's workflow.
+var Priorities = []string{"Low", "Medium", "High"}
 
 type Task struct {
-	ID          string    `json:"id" db:"id"`
-	Title       string    `json:"title" db:"title"`
-	Description string    `json:"description" db:"description"`
-	Status      string    `json:"status" db:"status"`
-	Priority    string    `json:"priority" db:"priority"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          string     `json:"id" db:"id"`
+	UserID      int64      `json:"-" db:"user_id"`
+	Title       string     `json:"title" db:"title"`
+	Description string     `json:"description" db:"description"`
+	Status      string     `json:"status" db:"status"`
+	Priority    string     `json:"priority" db:"priority"`
+	ProjectID   *int64     `json:"project_id" db:"project_id"`
+	StartAt     *time.Time `json:"start_at" db:"start_at"`
+	DueAt       *time.Time `json:"due_at" db:"due_at"`
+	// StatusCategory is the workflow category of Status: todo, active or done
+	StatusCategory string `json:"status_category" db:"status_category"`
+	// Rank orders tasks within their status column, see package rank
+	Rank string `json:"rank" db:"rank"`
+	// EstimateHours is the expected effort, used to plan dependency chains
+	EstimateHours *float64 `json:"estimate_hours" db:"estimate_hours"`
+	// ParentID is the key of the task this one is a subtask of
+	ParentID *string `json:"parent_id" db:"parent_id"`
+	// Progress rolls up the direct subtasks and is nil for tasks without any
+	Progress *Progress `json:"progress,omitempty" db:"progress"`
+	// Tags holds the names of the task's tags, sorted
+	Tags pq.StringArray `json:"tags" db:"tags"`
+	// BlockedBy lists the tasks that must be done before this one can be; Blocks is the reverse
+	BlockedBy pq.StringArray `json:"blocked_by" db:"blocked_by"`
+	Blocks    pq.StringArray `json:"blocks" db:"blocks"`
+	// Version increases on every write and is served as the task's ETag
+	Version   int64     `json:"version" db:"version"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// DeletedAt is set while the task is in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// ArchivedAt is set while a done task is archived and off the board
+	ArchivedAt *time.Time `json:"archived_at" db:"archived_at"`
+	// Recurrence is set for occurrences of a recurring task
+	Recurrence *Recurrence `json:"recurrence,omitempty" db:"recurrence"`
+}
+
+// Progress counts how many of a task's direct subtasks are done
+type Progress struct {
+	Done    int `json:"done"`
+	Total   int `json:"total"`
+	Percent int `json:"percent"`
+}
+
+// NewProgress works out the percentage for done out of total subtasks
+func NewProgress(done, total int) *Progress {
+	if total == 0 {
+		return nil
+	}
+	return &Progress{Done: done, Total: total, Percent: done * 100 / total}
+}
+
+// Scan reads the {"done": n, "total": n} object built by the task query
+func (p *Progress) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Progress", src)
+	}
+	if err := json.Unmarshal(raw, p); err != nil {
+		return err
+	}
+	if p.Total > 0 {
+		p.Percent = p.Done * 100 / p.Total
+	}
+	return nil
+}
+
+// Recurrence links an occurrence of a recurring task to its series
+type Recurrence struct {
+	SeriesID int64 `json:"series_id"`
+	// Rule is the series' schedule, see package recurrence
+	Rule string `json:"rule"`
+	// AfterCompletion counts the schedule from when the last occurrence was
+	// done rather than from the first one's due date
+	AfterCompletion bool `json:"after_completion"`
+	// OccursAt is the date this occurrence was scheduled for. Moving its due
+	// date leaves the series alone.
+	OccursAt *time.Time `json:"occurs_at"`
+	// NextAt is when the series' next occurrence comes due; nil when the
+	// series counts from completion
+	NextAt *time.Time `json:"next_at"`
+	// Timezone is the IANA zone the rule's days and time of day are kept in
+	Timezone string `json:"timezone"`
+}
+
+// Scan reads the recurrence object built by the task query
+func (r *Recurrence) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return fmt.Errorf("cannot scan %T into Recurrence", src)
+	}
+}
+
+// KeyPrefix returns the prefix part of a task key, e.g. HOME for HOME-001
+func KeyPrefix(key string) string {
+	if i := strings.LastIndex(key, "-"); i > 0 {
+		return key[:i]
+	}
+	return DefaultKeyPrefix
+}
+
+// FormatKey builds a task key such as TASK-001 from a prefix and its sequence number
+func FormatKey(prefix string, n int) string {
+	return fmt.Sprintf("%s-%03d", prefix, n)
 }
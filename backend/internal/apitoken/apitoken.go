@@ -0,0 +1,49 @@
+package apitoken
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Scopes an API token can carry
+const (
+	ScopeTasksRead     = "tasks:read"
+	ScopeTasksWrite    = "tasks:write"
+	ScopeProjectsRead  = "projects:read"
+	ScopeProjectsWrite = "projects:write"
+)
+
+// AllScopes lists every scope a token may be granted
+var AllScopes = []string{ScopeTasksRead, ScopeTasksWrite, ScopeProjectsRead, ScopeProjectsWrite}
+
+// APIToken is a long-lived credential for scripts. Only a hash of the secret is stored;
+// Prefix keeps the first few characters so users can tell their tokens apart.
+type APIToken struct {
+	ID         int64          `json:"id" db:"id"`
+	UserID     int64          `json:"-" db:"user_id"`
+	Name       string         `json:"name" db:"name"`
+	Prefix     string         `json:"prefix" db:"token_prefix"`
+	Scopes     pq.StringArray `json:"scopes" db:"scopes"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time     `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt  *time.Time     `json:"expires_at" db:"expires_at"`
+}
+
+// HasScope reports whether the token was granted scope
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// DisplayPrefix returns the leading characters of a token kept for display
+func DisplayPrefix(token string) string {
+	if len(token) < 12 {
+		return token
+	}
+	return token[:12]
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	secret   []byte
+	tokenTTL = 24 * time.Hour
+)
+
+// Claims are the JWT claims issued at login. The subject holds the user id.
+type Claims struct {
+	jwt.RegisteredClaims
+}
+
+// UserID returns the user id stored in the token subject
+func (c *Claims) UserID() (int64, error) {
+	return strconv.ParseInt(c.Subject, 10, 64)
+}
+
+// Init sets the key used to sign tokens and how long issued tokens stay valid
+func Init(signingKey []byte, ttl time.Duration) {
+	secret = signingKey
+	tokenTTL = ttl
+}
+
+// RandomSecret returns a fresh signing key for when none is configured
+func RandomSecret() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+func CheckPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// IssueToken signs a token for userID and returns it with its claims
+func IssueToken(userID int64) (string, *Claims, error) {
+	jti := make([]byte, 16)
+	rand.Read(jti)
+
+	now := time.Now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        hex.EncodeToString(jti),
+			Subject:   strconv.FormatInt(userID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenTTL)),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return token, claims, nil
+}
+
+// ParseToken verifies a token's signature and expiry and returns its claims
+func ParseToken(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	if _, err := claims.UserID(); err != nil || claims.ID == "" {
+		return nil, errors.New("invalid token: malformed claims")
+	}
+
+	return claims, nil
+}
+
+// APITokenPrefix marks personal API tokens so they can be told apart from session JWTs
+const APITokenPrefix = "tkr_"
+
+// IsAPIToken reports whether token looks like a personal API token rather than a JWT
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// GenerateAPIToken returns a new random API token and the hash to store for it
+func GenerateAPIToken() (token string, hash string) {
+	raw := make([]byte, 32)
+	rand.Read(raw)
+
+	token = APITokenPrefix + hex.EncodeToString(raw)
+	return token, HashAPIToken(token)
+}
+
+// HashAPIToken returns the SHA-256 hex digest stored for an API token.
+// Tokens are high-entropy random values, so a fast hash is sufficient.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
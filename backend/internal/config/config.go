@@ -12,15 +12,27 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+	// JWTSecret signs session tokens; a random one is used per boot when empty
+	JWTSecret   string
+	JWTTTLHours int
+	// TrashRetentionDays is how long deleted tasks stay in the trash; 0 keeps them
+	TrashRetentionDays int
+	// AutoArchiveDays is how long done tasks stay on the board; 0 keeps them
+	AutoArchiveDays int
 }
 
 func Load() *Config {
 	return &Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		DBUser:     getEnv("DB_USER", "tasker"),
-		DBPassword: getEnv("DB_PASSWORD", ""),
-		DBName:     getEnv("DB_NAME", "tasker_db"),
+		DBHost:      getEnv("DB_HOST", "localhost"),
+		DBPort:      getEnv("DB_PORT", "5432"),
+		DBUser:      getEnv("DB_USER", "tasker"),
+		DBPassword:  getEnv("DB_PASSWORD", ""),
+		DBName:      getEnv("DB_NAME", "tasker_db"),
+		JWTSecret:   getEnv("JWT_SECRET", ""),
+		JWTTTLHours: getEnvAsInt("JWT_TTL_HOURS", 24),
+
+		TrashRetentionDays: getEnvAsInt("TRASH_RETENTION_DAYS", 30),
+		AutoArchiveDays:    getEnvAsInt("AUTO_ARCHIVE_DAYS", 14),
 	}
 }
 
@@ -0,0 +1,236 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/workflow"
+)
+
+// Node is a task in the dependency graph together with its earliest schedule
+type Node struct {
+	ID             string     `json:"id"`
+	Title          string     `json:"title"`
+	Status         string     `json:"status"`
+	StatusCategory string     `json:"status_category"`
+	EstimateHours  *float64   `json:"estimate_hours"`
+	StartAt        *time.Time `json:"start_at"`
+	DueAt          *time.Time `json:"due_at"`
+	// EarliestStart and EarliestFinish assume work starts now and every blocker
+	// finishes as early as it can
+	EarliestStart  time.Time `json:"earliest_start"`
+	EarliestFinish time.Time `json:"earliest_finish"`
+	// Late is set when the earliest finish is after the due date
+	Late     bool `json:"late"`
+	Critical bool `json:"critical"`
+}
+
+// Edge points from a blocker to the task it blocks
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is the dependency DAG with the critical path leading to its target
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+	// CriticalPath lists the chain of tasks that decides when the target can finish
+	CriticalPath []string `json:"critical_path"`
+	// Target is the task the critical path ends at: the requested one, or else
+	// the one that finishes last
+	Target *Node `json:"target"`
+}
+
+// Build lays out the dependency graph of tasks and schedules it from now.
+// Without a target it covers every task with a dependency; with one, only the
+// target and the tasks it transitively waits on.
+//
+// A task takes its estimate once all blockers are finished and not before its
+// start_at. Done tasks take no time, whatever their column is called. A task
+// without an estimate is assumed to be finished by its due date, or
+// immediately when it has none.
+func Build(tasks []task.Task, target string, now time.Time) (*Graph, error) {
+	byID := make(map[string]task.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+
+	include := make(map[string]bool)
+	if target != "" {
+		if _, ok := byID[target]; !ok {
+			return nil, fmt.Errorf("task not found: %s", target)
+		}
+		pending := []string{target}
+		for len(pending) > 0 {
+			id := pending[0]
+			pending = pending[1:]
+			if include[id] {
+				continue
+			}
+			include[id] = true
+			pending = append(pending, byID[id].BlockedBy...)
+		}
+	} else {
+		for _, t := range tasks {
+			if len(t.BlockedBy) > 0 || len(t.Blocks) > 0 {
+				include[t.ID] = true
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(include))
+	for id := range include {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	g := &Graph{Nodes: []Node{}, Edges: []Edge{}, CriticalPath: []string{}}
+	waiting := make(map[string]int, len(ids))
+	for _, id := range ids {
+		for _, blocker := range byID[id].BlockedBy {
+			if include[blocker] {
+				g.Edges = append(g.Edges, Edge{From: blocker, To: id})
+				waiting[id]++
+			}
+		}
+	}
+
+	// Schedule in topological order; ready holds tasks whose blockers are all scheduled
+	nodes := make(map[string]*Node, len(ids))
+	driver := make(map[string]string, len(ids))
+	var ready []string
+	for _, id := range ids {
+		if waiting[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	var order []string
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		nodes[id], driver[id] = schedule(byID[id], nodes, include, now)
+
+		for _, blocked := range byID[id].Blocks {
+			if !include[blocked] {
+				continue
+			}
+			if waiting[blocked]--; waiting[blocked] == 0 {
+				ready = append(ready, blocked)
+			}
+		}
+	}
+	if len(order) != len(ids) {
+		return nil, errors.New("dependency cycle")
+	}
+
+	end := target
+	for _, id := range order {
+		if end == "" || nodes[id].EarliestFinish.After(nodes[end].EarliestFinish) {
+			end = id
+		}
+	}
+	for id := end; id != ""; id = driver[id] {
+		nodes[id].Critical = true
+		g.CriticalPath = append(g.CriticalPath, id)
+	}
+	slices.Reverse(g.CriticalPath)
+
+	for _, id := range ids {
+		g.Nodes = append(g.Nodes, *nodes[id])
+	}
+	if end != "" {
+		targetNode := *nodes[end]
+		g.Target = &targetNode
+	}
+
+	return g, nil
+}
+
+// schedule works out when t can start and finish given its already scheduled
+// blockers. It also returns the blocker that held it up the longest, if any.
+func schedule(t task.Task, scheduled map[string]*Node, include map[string]bool, now time.Time) (*Node, string) {
+	start := now
+	if t.StartAt != nil && t.StartAt.After(start) {
+		start = *t.StartAt
+	}
+
+	var driver string
+	for _, blocker := range t.BlockedBy {
+		if b, ok := scheduled[blocker]; ok && include[blocker] && b.EarliestFinish.After(start) {
+			start = b.EarliestFinish
+			driver = blocker
+		}
+	}
+
+	finish := start
+	switch {
+	case t.StatusCategory == workflow.CategoryDone:
+	case t.EstimateHours != nil:
+		finish = start.Add(time.Duration(*t.EstimateHours * float64(time.Hour)))
+	case t.DueAt != nil && t.DueAt.After(start):
+		finish = *t.DueAt
+	}
+
+	return &Node{
+		ID:             t.ID,
+		Title:          t.Title,
+		Status:         t.Status,
+		StatusCategory: t.StatusCategory,
+		EstimateHours:  t.EstimateHours,
+		StartAt:        t.StartAt,
+		DueAt:          t.DueAt,
+		EarliestStart:  start,
+		EarliestFinish: finish,
+		Late:           t.StatusCategory != workflow.CategoryDone && t.DueAt != nil && finish.After(*t.DueAt),
+	}, driver
+}
+
+// DOT renders the graph in Graphviz DOT. The critical path is drawn in red,
+// late tasks in orange and Done tasks greyed out.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph tasks {\n\trankdir=LR;\n\tnode [shape=box];\n")
+
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + quote(n.ID+"\n"+n.Title)}
+		if n.StatusCategory == workflow.CategoryDone {
+			attrs = append(attrs, `style=filled`, `fillcolor="lightgrey"`)
+		}
+		switch {
+		case n.Critical:
+			attrs = append(attrs, `color="red"`, `penwidth=2`)
+		case n.Late:
+			attrs = append(attrs, `color="orange"`)
+		}
+		fmt.Fprintf(&b, "\t%s [%s];\n", quote(n.ID), strings.Join(attrs, ", "))
+	}
+
+	onPath := make(map[Edge]bool)
+	for i := 1; i < len(g.CriticalPath); i++ {
+		onPath[Edge{From: g.CriticalPath[i-1], To: g.CriticalPath[i]}] = true
+	}
+	for _, e := range g.Edges {
+		if onPath[e] {
+			fmt.Fprintf(&b, "\t%s -> %s [color=\"red\", penwidth=2];\n", quote(e.From), quote(e.To))
+		} else {
+			fmt.Fprintf(&b, "\t%s -> %s;\n", quote(e.From), quote(e.To))
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// quote makes s a DOT string literal
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
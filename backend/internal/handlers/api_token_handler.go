@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"tasker/internal/apitoken"
+	"tasker/internal/auth"
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type createAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// validScopes reports whether every scope is one a token may be granted
+func validScopes(scopes []string) bool {
+	for _, s := range scopes {
+		if !slices.Contains(apitoken.AllScopes, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateAPIToken(req createAPITokenRequest) map[string]string {
+	errors := make(map[string]string)
+
+	if strings.TrimSpace(req.Name) == "" {
+		errors["name"] = "name is required"
+	}
+
+	if len(req.Scopes) == 0 || !validScopes(req.Scopes) {
+		errors["scopes"] = "scopes must be one or more of: " + strings.Join(apitoken.AllScopes, ", ")
+	}
+
+	if req.ExpiresInDays < 0 {
+		errors["expires_in_days"] = "expires_in_days cannot be negative"
+	}
+
+	return errors
+}
+
+// GetAPITokensHandler lists the caller's active API tokens
+func GetAPITokensHandler(c *gin.Context) {
+	tokens, err := repository.APITokens.ListTokens(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// PostAPITokenHandler creates an API token. The secret is only ever shown in this response.
+func PostAPITokenHandler(c *gin.Context) {
+	var req createAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if validationErrors := validateAPIToken(req); len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	token, hash := auth.GenerateAPIToken()
+	newToken := apitoken.APIToken{
+		Name:   strings.TrimSpace(req.Name),
+		Prefix: apitoken.DisplayPrefix(token),
+		Scopes: req.Scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		newToken.ExpiresAt = &expiresAt
+	}
+
+	created, err := repository.APITokens.CreateToken(currentUserID(c), newToken, hash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"token": token, "api_token": created})
+}
+
+// DeleteAPITokenHandler handles DELETE /api/tokens/:id requests by revoking the token
+func DeleteAPITokenHandler(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+		return
+	}
+
+	if err := repository.APITokens.RevokeToken(currentUserID(c), id); err != nil {
+		if strings.Contains(err.Error(), "api token not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke token"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"tasker/internal/apitoken"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupAPITokenTestRouter wires the token and task routes the way setupRouter does
+func setupAPITokenTestRouter() *gin.Engine {
+	r := gin.Default()
+
+	r.POST("/api/auth/register", RegisterHandler)
+
+	api := r.Group("/api", RequireAuth())
+	api.POST("/auth/logout", RequireSession(), LogoutHandler)
+
+	tokens := api.Group("/tokens", RequireSession())
+	tokens.GET("", GetAPITokensHandler)
+	tokens.POST("", PostAPITokenHandler)
+	tokens.DELETE("/:id", DeleteAPITokenHandler)
+
+	api.GET("/task", RequireScope(apitoken.ScopeTasksRead), GetTaskHandler)
+	api.POST("/task", RequireScope(apitoken.ScopeTasksWrite), PostTaskHandler)
+
+	return r
+}
+
+// createTestAPIToken mints a token through the API and returns its plaintext and id
+func createTestAPIToken(t *testing.T, r *gin.Engine, session string, scopes ...string) (string, int64) {
+	body, _ := json.Marshal(map[string]any{"name": "cron", "scopes": scopes})
+	w := makeAuthedRequest(r, "POST", "/api/tokens", session, body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response struct {
+		Token    string            `json:"token"`
+		APIToken apitoken.APIToken `json:"api_token"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NotEmpty(t, response.Token)
+	return response.Token, response.APIToken.ID
+}
+
+func TestPostAPITokenHandler_Success(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAPITokenTestRouter()
+	session := registerTestUser(t, r, "sam@example.com")
+
+	body, _ := json.Marshal(map[string]any{"name": "backup script", "scopes": []string{"tasks:read"}, "expires_in_days": 30})
+	w := makeAuthedRequest(r, "POST", "/api/tokens", session, body)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]any
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	token, _ := response["token"].(string)
+	assert.Contains(t, token, "tkr_")
+
+	created, ok := response["api_token"].(map[string]any)
+	assert.True(t, ok)
+	assert.Equal(t, "backup script", created["name"])
+	assert.Equal(t, token[:12], created["prefix"])
+	assert.NotNil(t, created["expires_at"])
+	assert.NotContains(t, w.Body.String(), "hash")
+}
+
+func TestPostAPITokenHandler_ValidationErrors(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAPITokenTestRouter()
+	session := registerTestUser(t, r, "sam@example.com")
+
+	tests := []struct {
+		name  string
+		body  map[string]any
+		field string
+	}{
+		{"missing name", map[string]any{"scopes": []string{"tasks:read"}}, "name"},
+		{"no scopes", map[string]any{"name": "cron"}, "scopes"},
+		{"unknown scope", map[string]any{"name": "cron", "scopes": []string{"admin"}}, "scopes"},
+		{"negative expiry", map[string]any{"name": "cron", "scopes": []string{"tasks:read"}, "expires_in_days": -1}, "expires_in_days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.body)
+			w := makeAuthedRequest(r, "POST", "/api/tokens", session, body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response map[string]any
+			json.Unmarshal(w.Body.Bytes(), &response)
+			details, _ := response["details"].(map[string]any)
+			assert.Contains(t, details, tt.field)
+		})
+	}
+}
+
+func TestGetAPITokensHandler_ListsOnlyOwnActiveTokens(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAPITokenTestRouter()
+	sam := registerTestUser(t, r, "sam@example.com")
+	alex := registerTestUser(t, r, "alex@example.com")
+
+	createTestAPIToken(t, r, sam, "tasks:read")
+	_, revokedID := createTestAPIToken(t, r, sam, "tasks:write")
+	createTestAPIToken(t, r, alex, "tasks:read")
+
+	w := makeAuthedRequest(r, "DELETE", fmt.Sprintf("/api/tokens/%d", revokedID), sam, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = makeAuthedRequest(r, "GET", "/api/tokens", sam, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var tokens []apitoken.APIToken
+	json.Unmarshal(w.Body.Bytes(), &tokens)
+	assert.Len(t, tokens, 1)
+	assert.Equal(t, []string{"tasks:read"}, []string(tokens[0].Scopes))
+}
+
+func TestDeleteAPITokenHandler_NotFound(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAPITokenTestRouter()
+	sam := registerTestUser(t, r, "sam@example.com")
+	alex := registerTestUser(t, r, "alex@example.com")
+	_, id := createTestAPIToken(t, r, sam, "tasks:read")
+
+	w := makeAuthedRequest(r, "DELETE", fmt.Sprintf("/api/tokens/%d", id), alex, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = makeAuthedRequest(r, "DELETE", "/api/tokens/abc", sam, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAPIToken_ScopesAreEnforced(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAPITokenTestRouter()
+	session := registerTestUser(t, r, "sam@example.com")
+	token, _ := createTestAPIToken(t, r, session, "tasks:read")
+
+	w := makeAuthedRequest(r, "GET", "/api/task", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = makeAuthedRequest(r, "POST", "/api/task", token, marshalTaskBody("From cron", "", "", ""))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "tasks:write")
+
+	// Sessions are not limited by scopes
+	w = makeAuthedRequest(r, "POST", "/api/task", session, marshalTaskBody("From browser", "", "", ""))
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestAPIToken_ActsAsItsOwner(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAPITokenTestRouter()
+	session := registerTestUser(t, r, "sam@example.com")
+	token, _ := createTestAPIToken(t, r, session, "tasks:read", "tasks:write")
+
+	w := makeAuthedRequest(r, "POST", "/api/task", token, marshalTaskBody("From cron", "", "", ""))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = makeAuthedRequest(r, "GET", "/api/task", session, nil)
+	assert.Contains(t, w.Body.String(), "From cron")
+}
+
+func TestAPIToken_RevokedAndUnknownTokensAreRejected(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAPITokenTestRouter()
+	session := registerTestUser(t, r, "sam@example.com")
+	token, id := createTestAPIToken(t, r, session, "tasks:read")
+
+	w := makeAuthedRequest(r, "DELETE", fmt.Sprintf("/api/tokens/%d", id), session, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = makeAuthedRequest(r, "GET", "/api/task", token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = makeAuthedRequest(r, "GET", "/api/task", "tkr_not-a-real-token", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAPIToken_CannotManageTokens(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAPITokenTestRouter()
+	session := registerTestUser(t, r, "sam@example.com")
+	token, _ := createTestAPIToken(t, r, session, apitoken.AllScopes...)
+
+	body, _ := json.Marshal(map[string]any{"name": "escalate", "scopes": apitoken.AllScopes})
+	w := makeAuthedRequest(r, "POST", "/api/tokens", token, body)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = makeAuthedRequest(r, "GET", "/api/tokens", token, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = makeAuthedRequest(r, "POST", "/api/auth/logout", token, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAPIToken_RecordsLastUse(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAPITokenTestRouter()
+	session := registerTestUser(t, r, "sam@example.com")
+	token, _ := createTestAPIToken(t, r, session, "tasks:read")
+
+	makeAuthedRequest(r, "GET", "/api/task", token, nil)
+
+	tokens, _ := mockAPITokenRepo.ListTokens(1)
+	assert.Len(t, tokens, 1)
+	assert.NotNil(t, tokens[0].LastUsedAt)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"tasker/internal/repository"
+	"tasker/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ArchiveTaskHandler handles POST /api/task/:id/archive requests, taking a
+// done task off the board. Archived tasks keep showing up everywhere else.
+func ArchiveTaskHandler(c *gin.Context) {
+	setArchived(c, true)
+}
+
+// UnarchiveTaskHandler handles POST /api/task/:id/unarchive requests, putting
+// an archived task back at the top of its column
+func UnarchiveTaskHandler(c *gin.Context) {
+	setArchived(c, false)
+}
+
+// setArchived archives or unarchives the task in the URL and responds. Asking
+// for the state a task is already in writes nothing.
+func setArchived(c *gin.Context, archived bool) {
+	taskID := c.Param("id")
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+
+	current, err := repository.Tasks.GetTaskByID(currentUserID(c), taskID)
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+		return
+	}
+
+	if version != 0 && version != current.Version {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+	if (current.ArchivedAt != nil) == archived {
+		c.Header("ETag", taskETag(current))
+		c.JSON(http.StatusOK, current)
+		return
+	}
+
+	if archived && current.StatusCategory != workflow.CategoryDone {
+		c.JSON(http.StatusConflict, gin.H{"error": "only done tasks can be archived"})
+		return
+	}
+	var limit *repository.WIPLimit
+	if !archived {
+		wf, ok := loadWorkflow(c, current.ProjectID)
+		if ok {
+			limit, ok = wipLimit(c, wf, current.ID, current.Status)
+		}
+		if !ok {
+			return
+		}
+	}
+
+	updated, err := repository.Tasks.ArchiveTask(currentUserID(c), current.ID, current.Version, archived, limit)
+	if err != nil {
+		if respondIfOverLimit(c, err) {
+			return
+		}
+		switch msg := err.Error(); {
+		case strings.Contains(msg, "task not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		case strings.Contains(msg, "task version conflict"):
+			respondPreconditionFailed(c, taskID)
+		case strings.Contains(msg, "task not done"):
+			c.JSON(http.StatusConflict, gin.H{"error": "only done tasks can be archived"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to archive task"})
+		}
+		return
+	}
+
+	c.Header("ETag", taskETag(updated))
+	c.JSON(http.StatusOK, updated)
+}
+
+// GetArchiveHandler handles GET /api/archive requests, listing archived tasks
+// most recently archived first. It takes the same filters, search, sort and
+// paging as GET /api/task, archived_after and archived_before included.
+func GetArchiveHandler(c *gin.Context) {
+	opts, validationErrors := parseListOptions(c, "-archived_at")
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+	opts.Archived = true
+
+	respondTaskList(c, opts)
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func archiveTask(r *gin.Engine, id string) *httptest.ResponseRecorder {
+	return makeJSONRequest(r, "POST", "/api/task/"+id+"/archive", nil)
+}
+
+func unarchiveTask(r *gin.Engine, id string) *httptest.ResponseRecorder {
+	return makeJSONRequest(r, "POST", "/api/task/"+id+"/unarchive", nil)
+}
+
+func listArchive(t *testing.T, r *gin.Engine, query string) []task.Task {
+	w := makeJSONRequest(r, "GET", "/api/archive"+query, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var tasks []task.Task
+	json.Unmarshal(w.Body.Bytes(), &tasks)
+	return tasks
+}
+
+func TestArchiveTaskHandler_TakesTaskOffBoard(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	shipped := createTestTask(t, r, map[string]any{"title": "Shipped", "status": "Done"})
+	createTestTask(t, r, map[string]any{"title": "Released", "status": "Done"})
+
+	w := archiveTask(r, shipped.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var archived task.Task
+	json.Unmarshal(w.Body.Bytes(), &archived)
+	assert.NotNil(t, archived.ArchivedAt)
+	assert.Equal(t, shipped.Version+1, archived.Version)
+	assert.Equal(t, taskETag(&archived), w.Header().Get("ETag"))
+
+	assert.Equal(t, []string{"Released"}, columnTitles(t, r, "Done"))
+	assert.Equal(t, 1, getBoard(t, r, "").Columns[2].Count)
+
+	// Still there for everything but the board
+	assert.NotNil(t, getTask(t, r, shipped.ID).ArchivedAt)
+	tasks, _ := listTasks(t, r, "")
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, history.ActionArchive, getHistory(t, r, shipped.ID)[0].Action)
+
+	// Archiving again writes nothing
+	assert.Equal(t, http.StatusOK, archiveTask(r, shipped.ID).Code)
+	assert.Equal(t, archived.Version, getTask(t, r, shipped.ID).Version)
+}
+
+func TestArchiveTaskHandler_Errors(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	open := createTestTask(t, r, map[string]any{"title": "Open", "status": "TODO"})
+	done := createTestTask(t, r, map[string]any{"title": "Done", "status": "Done"})
+
+	w := archiveTask(r, open.ID)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Nil(t, getTask(t, r, open.ID).ArchivedAt)
+
+	assert.Equal(t, http.StatusNotFound, archiveTask(r, "TASK-999").Code)
+	assert.Equal(t, http.StatusNotFound, unarchiveTask(r, "TASK-999").Code)
+
+	w = makeConditionalRequest(r, "POST", "/api/task/"+done.ID+"/archive", `"7"`, nil)
+	assertPreconditionFailed(t, w, done.Version)
+}
+
+func TestUnarchiveTaskHandler_PutsCardBackOnTop(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	shipped := createTestTask(t, r, map[string]any{"title": "Shipped", "status": "Done"})
+	createTestTask(t, r, map[string]any{"title": "Released", "status": "Done"})
+	assert.Equal(t, http.StatusOK, archiveTask(r, shipped.ID).Code)
+	createTestTask(t, r, map[string]any{"title": "Deployed", "status": "Done"})
+
+	w := unarchiveTask(r, shipped.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var unarchived task.Task
+	json.Unmarshal(w.Body.Bytes(), &unarchived)
+	assert.Nil(t, unarchived.ArchivedAt)
+
+	assert.Equal(t, []string{"Shipped", "Deployed", "Released"}, columnTitles(t, r, "Done"))
+	assert.Equal(t, history.ActionUnarchive, getHistory(t, r, shipped.ID)[0].Action)
+	assert.Empty(t, listArchive(t, r, ""))
+}
+
+func TestUnarchiveTaskHandler_RespectsWIPLimit(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	assert.Equal(t, http.StatusOK, putWorkflow(r, "", `{"columns":[
+		{"name":"TODO","category":"todo"},
+		{"name":"Done","category":"done","wip_limit":1}
+	]}`).Code)
+	first := createTestTask(t, r, map[string]any{"title": "First", "status": "Done"})
+	assert.Equal(t, http.StatusOK, archiveTask(r, first.ID).Code)
+	createTestTask(t, r, map[string]any{"title": "Second", "status": "Done"})
+
+	assert.Equal(t, http.StatusConflict, unarchiveTask(r, first.ID).Code)
+	assert.NotNil(t, getTask(t, r, first.ID).ArchivedAt)
+
+	w := makeJSONRequest(r, "POST", "/api/task/"+first.ID+"/unarchive?force=true", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestArchive_LeavingDoneUnarchives(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	reopened := createTestTask(t, r, map[string]any{"title": "Reopened", "status": "Done"})
+	moved := createTestTask(t, r, map[string]any{"title": "Moved", "status": "Done"})
+	renamed := createTestTask(t, r, map[string]any{"title": "Renamed", "status": "Done"})
+	for _, id := range []string{reopened.ID, moved.ID, renamed.ID} {
+		assert.Equal(t, http.StatusOK, archiveTask(r, id).Code)
+	}
+
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, reopened.ID, `{"status":"TODO"}`).Code)
+	assert.Nil(t, getTask(t, r, reopened.ID).ArchivedAt)
+
+	// Dragging a card puts it on the board, even within Done
+	assert.Equal(t, http.StatusOK, moveTask(r, moved.ID, `{"status":"Done"}`).Code)
+	assert.Equal(t, []string{"Moved"}, columnTitles(t, r, "Done"))
+
+	// Edits that stay done leave the task archived
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, renamed.ID, `{"title":"Renamed again"}`).Code)
+	assert.NotNil(t, getTask(t, r, renamed.ID).ArchivedAt)
+}
+
+func TestGetArchiveHandler_SearchAndDates(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	for _, title := range []string{"Fix login bug", "Write release notes", "Fix signup bug"} {
+		done := createTestTask(t, r, map[string]any{"title": title, "status": "Done"})
+		assert.Equal(t, http.StatusOK, archiveTask(r, done.ID).Code)
+	}
+	createTestTask(t, r, map[string]any{"title": "Fix logout bug", "status": "Done"})
+
+	// Most recently archived first
+	assert.Equal(t, []string{"Fix signup bug", "Write release notes", "Fix login bug"}, taskTitles(listArchive(t, r, "")))
+	assert.Equal(t, []string{"Fix signup bug", "Fix login bug"}, taskTitles(listArchive(t, r, "?q=fix")))
+
+	// Backdate one to check the date range
+	for id, tk := range mockRepo.tasks {
+		if tk.Title == "Fix login bug" {
+			lastMonth := time.Now().AddDate(0, -1, 0)
+			tk.ArchivedAt = &lastMonth
+			mockRepo.tasks[id] = tk
+		}
+	}
+	since := time.Now().AddDate(0, 0, -7).Format(time.DateOnly)
+	assert.Len(t, listArchive(t, r, "?archived_after="+since), 2)
+	assert.Equal(t, []string{"Fix login bug"}, taskTitles(listArchive(t, r, "?archived_before="+since)))
+
+	w := makeJSONRequest(r, "GET", "/api/archive?archived_after=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestArchiveDoneTasks_ArchivesTasksDoneLongAgo(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	stale := createTestTask(t, r, map[string]any{"title": "Stale", "status": "Done"})
+	fresh := createTestTask(t, r, map[string]any{"title": "Fresh", "status": "Done"})
+	open := createTestTask(t, r, map[string]any{"title": "Open", "status": "TODO"})
+
+	longAgo := time.Now().AddDate(0, 0, -15)
+	mockRepo.doneAt[stale.ID] = longAgo
+	for _, id := range []string{fresh.ID, open.ID} {
+		tk := mockRepo.tasks[id]
+		tk.UpdatedAt = longAgo
+		mockRepo.tasks[id] = tk
+	}
+
+	// Editing a done task doesn't restart its time on the board
+	w := makePatchRequest(r, stale.ID, `{"description": "Follow-up notes"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	archived, err := mockRepo.ArchiveDoneTasks(14 * 24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), archived)
+	assert.Equal(t, []string{"Stale"}, taskTitles(listArchive(t, r, "")))
+	assert.Equal(t, []string{"Fresh"}, columnTitles(t, r, "Done"))
+}
+
+func TestArchiveDoneTasks_CountsFromLastTimeDone(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	reopened := createTestTask(t, r, map[string]any{"title": "Reopened", "status": "Done"})
+	unarchived := createTestTask(t, r, map[string]any{"title": "Unarchived", "status": "Done"})
+
+	longAgo := time.Now().AddDate(0, 0, -15)
+	mockRepo.doneAt[reopened.ID] = longAgo
+	mockRepo.doneAt[unarchived.ID] = longAgo
+
+	// Leaving a done column and coming back starts the count again
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, reopened.ID, `{"status": "TODO"}`).Code)
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, reopened.ID, `{"status": "Done"}`).Code)
+
+	// So does putting an archived task back on the board
+	assert.Equal(t, http.StatusOK, makeJSONRequest(r, "POST", "/api/task/"+unarchived.ID+"/archive", nil).Code)
+	assert.Equal(t, http.StatusOK, makeJSONRequest(r, "POST", "/api/task/"+unarchived.ID+"/unarchive", nil).Code)
+
+	archived, err := mockRepo.ArchiveDoneTasks(14 * 24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), archived)
+	assert.ElementsMatch(t, []string{"Reopened", "Unarchived"}, columnTitles(t, r, "Done"))
+}
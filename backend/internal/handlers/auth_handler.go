@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"tasker/internal/auth"
+	"tasker/internal/repository"
+	"tasker/internal/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+const minPasswordLength = 8
+
+type credentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	Timezone string `json:"timezone"`
+}
+
+// updateProfileRequest is the PATCH /api/auth/me body; omitted fields are left alone
+type updateProfileRequest struct {
+	Name     *string `json:"name"`
+	Timezone *string `json:"timezone"`
+}
+
+func validateRegistration(req credentialsRequest) map[string]string {
+	errors := make(map[string]string)
+
+	if addr, err := mail.ParseAddress(req.Email); err != nil || addr.Address != strings.TrimSpace(req.Email) {
+		errors["email"] = "a valid email address is required"
+	}
+
+	if len(req.Password) < minPasswordLength {
+		errors["password"] = "password must be at least 8 characters"
+	}
+
+	if req.Timezone != "" && !validateTimezone(req.Timezone) {
+		errors["timezone"] = "timezone must be an IANA time zone such as Europe/Berlin"
+	}
+
+	return errors
+}
+
+// startSession issues a token for u, sets it as an httpOnly cookie and writes it to the response
+func startSession(c *gin.Context, status int, u *user.User) {
+	token, claims, err := auth.IssueToken(u.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue token"})
+		return
+	}
+
+	maxAge := int(time.Until(claims.ExpiresAt.Time).Seconds())
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(authCookieName, token, maxAge, "/", "", c.Request.TLS != nil, true)
+
+	c.JSON(status, gin.H{
+		"token":      token,
+		"expires_at": claims.ExpiresAt.Time,
+		"user":       u,
+	})
+}
+
+// RegisterHandler handles POST /api/auth/register requests
+func RegisterHandler(c *gin.Context) {
+	var req credentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if validationErrors := validateRegistration(req); len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register user"})
+		return
+	}
+
+	createdUser, err := repository.Users.CreateUser(user.User{
+		Email:        strings.TrimSpace(req.Email),
+		PasswordHash: hash,
+		Name:         strings.TrimSpace(req.Name),
+		Timezone:     req.Timezone,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "email already registered") {
+			c.JSON(http.StatusConflict, gin.H{"error": "email already registered"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register user"})
+		return
+	}
+
+	startSession(c, http.StatusCreated, createdUser)
+}
+
+// LoginHandler handles POST /api/auth/login requests
+func LoginHandler(c *gin.Context) {
+	var req credentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	u, err := repository.Users.GetUserByEmail(req.Email)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log in"})
+		return
+	}
+
+	if !auth.CheckPassword(u.PasswordHash, req.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
+		return
+	}
+
+	startSession(c, http.StatusOK, u)
+}
+
+// LogoutHandler handles POST /api/auth/logout requests by revoking the caller's token
+func LogoutHandler(c *gin.Context) {
+	claims := c.MustGet(claimsKey).(*auth.Claims)
+
+	if err := repository.Users.RevokeToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(authCookieName, "", -1, "/", "", c.Request.TLS != nil, true)
+	c.Status(http.StatusNoContent)
+}
+
+// MeHandler handles GET /api/auth/me requests
+func MeHandler(c *gin.Context) {
+	u, err := repository.Users.GetUserByID(currentUserID(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, u)
+}
+
+// UpdateMeHandler handles PATCH /api/auth/me requests for the caller's name and time zone
+func UpdateMeHandler(c *gin.Context) {
+	var req updateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if req.Timezone != nil && !validateTimezone(*req.Timezone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"timezone": "timezone must be an IANA time zone such as Europe/Berlin"}})
+		return
+	}
+
+	u, err := repository.Users.GetUserByID(currentUserID(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		return
+	}
+
+	if req.Name != nil {
+		u.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Timezone != nil {
+		u.Timezone = *req.Timezone
+	}
+
+	updated, err := repository.Users.UpdateUser(*u)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
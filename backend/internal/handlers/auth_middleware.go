@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"tasker/internal/apitoken"
+	"tasker/internal/auth"
+	"tasker/internal/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// authCookieName carries the session token for browser clients
+	authCookieName = "tasker_token"
+	userIDKey      = "userID"
+	claimsKey      = "authClaims"
+	apiTokenKey    = "apiToken"
+)
+
+// lastUsedResolution limits how often a token's last_used_at is written
+const lastUsedResolution = time.Minute
+
+// requestToken reads the token from an "Authorization: Bearer" header, falling back to the session cookie
+func requestToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+
+	token, _ := c.Cookie(authCookieName)
+	return token
+}
+
+// RequireAuth rejects requests without a valid, unrevoked session token or
+// personal API token and stores the caller's user id for the handlers behind it
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := requestToken(c)
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+
+		if auth.IsAPIToken(token) {
+			authenticateAPIToken(c, token)
+			return
+		}
+
+		claims, err := auth.ParseToken(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			return
+		}
+
+		revoked, err := repository.Users.IsTokenRevoked(claims.ID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify token"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			return
+		}
+
+		userID, _ := claims.UserID()
+		c.Set(userIDKey, userID)
+		c.Set(claimsKey, claims)
+		c.Next()
+	}
+}
+
+func authenticateAPIToken(c *gin.Context, token string) {
+	t, err := repository.APITokens.GetActiveTokenByHash(auth.HashAPIToken(token))
+	if err != nil {
+		if strings.Contains(err.Error(), "api token not found") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify token"})
+		return
+	}
+
+	if t.LastUsedAt == nil || time.Since(*t.LastUsedAt) > lastUsedResolution {
+		if err := repository.APITokens.MarkTokenUsed(t.ID); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	c.Set(userIDKey, t.UserID)
+	c.Set(apiTokenKey, t)
+	c.Next()
+}
+
+// RequireScope rejects API tokens that were not granted scope.
+// Logged-in sessions are not limited by scopes.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if t, ok := c.Get(apiTokenKey); ok && !t.(*apitoken.APIToken).HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient scope", "required_scope": scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession only lets logged-in sessions through, so an API token can
+// never be used to mint or revoke other tokens
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get(claimsKey); !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this endpoint requires a logged-in session"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentUserID returns the id of the authenticated caller
+func currentUserID(c *gin.Context) int64 {
+	return c.GetInt64(userIDKey)
+}
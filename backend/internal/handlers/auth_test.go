@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	task "tasker/internal/Task"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupAuthTestRouter mounts the task routes behind the real RequireAuth middleware
+func setupAuthTestRouter() *gin.Engine {
+	r := gin.Default()
+
+	r.POST("/api/auth/register", RegisterHandler)
+	r.POST("/api/auth/login", LoginHandler)
+
+	api := r.Group("/api", RequireAuth())
+	api.POST("/auth/logout", LogoutHandler)
+	api.GET("/auth/me", MeHandler)
+	api.PATCH("/auth/me", RequireSession(), UpdateMeHandler)
+	api.GET("/task", GetTaskHandler)
+	api.GET("/task/:id", GetTaskByIDHandler)
+	api.POST("/task", PostTaskHandler)
+
+	return r
+}
+
+func makeAuthedRequest(r *gin.Engine, method string, path string, token string, body []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func registerTestUser(t *testing.T, r *gin.Engine, email string) string {
+	body, _ := json.Marshal(map[string]string{"email": email, "password": "correct horse", "name": "Test"})
+	w := makeJSONRequest(r, "POST", "/api/auth/register", body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]any
+	json.Unmarshal(w.Body.Bytes(), &response)
+	token, _ := response["token"].(string)
+	assert.NotEmpty(t, token)
+	return token
+}
+
+func TestRegisterHandler_Success(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAuthTestRouter()
+
+	body, _ := json.Marshal(map[string]string{"email": "sam@example.com", "password": "correct horse", "name": "Sam"})
+	w := makeJSONRequest(r, "POST", "/api/auth/register", body)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotContains(t, w.Body.String(), "password")
+	assert.Contains(t, w.Header().Get("Set-Cookie"), authCookieName+"=")
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "HttpOnly")
+
+	var response map[string]any
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.NotEmpty(t, response["token"])
+	userBody, ok := response["user"].(map[string]any)
+	assert.True(t, ok)
+	assert.Equal(t, "sam@example.com", userBody["email"])
+}
+
+func TestRegisterHandler_ValidationErrors(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAuthTestRouter()
+
+	body, _ := json.Marshal(map[string]string{"email": "not-an-email", "password": "short"})
+	w := makeJSONRequest(r, "POST", "/api/auth/register", body)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response map[string]any
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	details, ok := response["details"].(map[string]any)
+	assert.True(t, ok)
+	assert.NotNil(t, details["email"])
+	assert.NotNil(t, details["password"])
+}
+
+func TestRegisterHandler_DuplicateEmail(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAuthTestRouter()
+
+	registerTestUser(t, r, "sam@example.com")
+
+	body, _ := json.Marshal(map[string]string{"email": "SAM@example.com", "password": "another password"})
+	w := makeJSONRequest(r, "POST", "/api/auth/register", body)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestLoginHandler(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAuthTestRouter()
+
+	registerTestUser(t, r, "sam@example.com")
+
+	body, _ := json.Marshal(map[string]string{"email": "sam@example.com", "password": "correct horse"})
+	w := makeJSONRequest(r, "POST", "/api/auth/login", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	body, _ = json.Marshal(map[string]string{"email": "sam@example.com", "password": "wrong password"})
+	w = makeJSONRequest(r, "POST", "/api/auth/login", body)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	body, _ = json.Marshal(map[string]string{"email": "nobody@example.com", "password": "correct horse"})
+	w = makeJSONRequest(r, "POST", "/api/auth/login", body)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestMeHandler(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAuthTestRouter()
+
+	token := registerTestUser(t, r, "sam@example.com")
+
+	w := makeAuthedRequest(r, "GET", "/api/auth/me", token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "sam@example.com")
+}
+
+func TestRequireAuth_RejectsMissingAndInvalidTokens(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAuthTestRouter()
+
+	w := makeAuthedRequest(r, "GET", "/api/task", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = makeAuthedRequest(r, "GET", "/api/task", "not.a.token", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRequireAuth_AcceptsSessionCookie(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAuthTestRouter()
+
+	token := registerTestUser(t, r, "sam@example.com")
+
+	req, _ := http.NewRequest("GET", "/api/auth/me", nil)
+	req.AddCookie(&http.Cookie{Name: authCookieName, Value: token})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLogoutHandler_RevokesToken(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAuthTestRouter()
+
+	token := registerTestUser(t, r, "sam@example.com")
+
+	w := makeAuthedRequest(r, "POST", "/api/auth/logout", token, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = makeAuthedRequest(r, "GET", "/api/auth/me", token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestTasksAreScopedToTheirOwner(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAuthTestRouter()
+
+	alice := registerTestUser(t, r, "alice@example.com")
+	bob := registerTestUser(t, r, "bob@example.com")
+
+	w := makeAuthedRequest(r, "POST", "/api/task", alice, marshalTaskBody("Alice's task", "", "", ""))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created task.Task
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	var bobTasks []task.Task
+	w = makeAuthedRequest(r, "GET", "/api/task", bob, nil)
+	json.Unmarshal(w.Body.Bytes(), &bobTasks)
+	assert.Equal(t, 0, len(bobTasks))
+
+	w = makeAuthedRequest(r, "GET", "/api/task/"+created.ID, bob, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var aliceTasks []task.Task
+	w = makeAuthedRequest(r, "GET", "/api/task", alice, nil)
+	json.Unmarshal(w.Body.Bytes(), &aliceTasks)
+	assert.Equal(t, 1, len(aliceTasks))
+}
+
+func TestRegisterHandler_Timezone(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAuthTestRouter()
+
+	body, _ := json.Marshal(map[string]string{"email": "sam@example.com", "password": "correct horse", "timezone": "Mars/Olympus_Mons"})
+	w := makeJSONRequest(r, "POST", "/api/auth/register", body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "timezone")
+
+	token := registerTestUser(t, r, "sam@example.com")
+	w = makeAuthedRequest(r, "GET", "/api/auth/me", token, nil)
+	assert.Contains(t, w.Body.String(), `"timezone":"UTC"`)
+}
+
+func TestUpdateMeHandler(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupAuthTestRouter()
+	token := registerTestUser(t, r, "sam@example.com")
+
+	w := makeAuthedRequest(r, "PATCH", "/api/auth/me", token, []byte(`{"timezone": "Europe/Berlin"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]any
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "Europe/Berlin", response["timezone"])
+	assert.Equal(t, "Test", response["name"])
+
+	w = makeAuthedRequest(r, "PATCH", "/api/auth/me", token, []byte(`{"timezone": "Berlin"}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	task "tasker/internal/Task"
+	"tasker/internal/repository"
+	"tasker/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+)
+
+// boardColumn is a workflow column together with its cards in rank order
+type boardColumn struct {
+	workflow.Column
+	Count int         `json:"count"`
+	Tasks []task.Task `json:"tasks"`
+}
+
+// sameBoard reports whether two project ids name the same board
+func sameBoard(a, b *int64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// entersColumn reports whether moving a task from before to after puts it in a
+// column of wf that it isn't already counted in. It responds and returns false
+// in ok when the previous board can't be read.
+func entersColumn(c *gin.Context, before, after task.Task, wf *workflow.Workflow) (entering bool, ok bool) {
+	if before.Status != after.Status {
+		return true, true
+	}
+	if sameBoard(before.ProjectID, after.ProjectID) {
+		return false, true
+	}
+
+	// Projects without a workflow of their own share the default board
+	previous, ok := loadWorkflow(c, before.ProjectID)
+	if !ok {
+		return false, false
+	}
+	return !sameBoard(previous.ProjectID, wf.ProjectID), true
+}
+
+// wipLimit returns the WIP limit a write putting a task in status has to keep,
+// or nil when the column has none. ?force=true lets the task in anyway; every
+// override is logged. It responds and returns false when the column can't be
+// counted for the log.
+func wipLimit(c *gin.Context, wf *workflow.Workflow, taskID string, status string) (*repository.WIPLimit, bool) {
+	col, ok := wf.Column(status)
+	if !ok || col.WIPLimit == nil {
+		return nil, true
+	}
+	if c.Query("force") != "true" {
+		return &repository.WIPLimit{Board: wf.ProjectID, Status: status, Limit: *col.WIPLimit}, true
+	}
+
+	counts, err := repository.Workflows.CountTasksByStatus(currentUserID(c), wf.ProjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count tasks"})
+		return nil, false
+	}
+	if count := counts[status]; count >= *col.WIPLimit {
+		if taskID == "" {
+			taskID = "a new task"
+		}
+		log.Printf("WIP limit overridden: user %d put %s in %q with %d/%d tasks", currentUserID(c), taskID, status, count, *col.WIPLimit)
+	}
+	return nil, true
+}
+
+// respondIfOverLimit responds with 409 and returns true when the write failed
+// because the task's column is at its WIP limit
+func respondIfOverLimit(c *gin.Context, err error) bool {
+	var over *repository.WIPLimitError
+	if !errors.As(err, &over) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":     "column is at its WIP limit; pass force=true to override",
+		"status":    over.Status,
+		"count":     over.Count,
+		"wip_limit": over.Limit,
+	})
+	return true
+}
+
+// GetBoardHandler handles GET /api/board requests. It returns the columns of
+// the default board, or of a project's board with ?project=, each with its
+// WIP limit and its tasks in rank order.
+func GetBoardHandler(c *gin.Context) {
+	projectID, ok := parseWorkflowProject(c)
+	if !ok {
+		return
+	}
+
+	wf, ok := loadWorkflow(c, projectID)
+	if !ok {
+		return
+	}
+
+	tasks, err := repository.Workflows.GetBoardTasks(currentUserID(c), wf.ProjectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get board tasks"})
+		return
+	}
+
+	columns := make([]boardColumn, len(wf.Columns))
+	for i, col := range wf.Columns {
+		columns[i] = boardColumn{Column: col, Tasks: []task.Task{}}
+		for _, t := range tasks {
+			if t.Status == col.Name {
+				columns[i].Tasks = append(columns[i].Tasks, t)
+			}
+		}
+		columns[i].Count = len(columns[i].Tasks)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"project_id": wf.ProjectID, "columns": columns})
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// limitedWorkflow caps In Progress at two tasks
+const limitedWorkflow = `{"columns":[
+	{"name":"TODO","category":"todo"},
+	{"name":"In Progress","category":"active","wip_limit":2},
+	{"name":"Done","category":"done"}
+]}`
+
+type boardResponse struct {
+	ProjectID *int64        `json:"project_id"`
+	Columns   []boardColumn `json:"columns"`
+}
+
+func getBoard(t *testing.T, r *gin.Engine, query string) boardResponse {
+	w := makeJSONRequest(r, "GET", "/api/board"+query, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var board boardResponse
+	json.Unmarshal(w.Body.Bytes(), &board)
+	return board
+}
+
+func TestGetBoardHandler_CountsAndLimits(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	assert.Equal(t, http.StatusOK, putWorkflow(r, "", limitedWorkflow).Code)
+	createTestTask(t, r, map[string]any{"title": "Plan", "status": "TODO"})
+	createTestTask(t, r, map[string]any{"title": "Build", "status": "In Progress"})
+	createTestTask(t, r, map[string]any{"title": "Test", "status": "In Progress"})
+
+	board := getBoard(t, r, "")
+	assert.Nil(t, board.ProjectID)
+	assert.Len(t, board.Columns, 3)
+
+	assert.Equal(t, "TODO", board.Columns[0].Name)
+	assert.Equal(t, 1, board.Columns[0].Count)
+	assert.Nil(t, board.Columns[0].WIPLimit)
+
+	assert.Equal(t, 2, board.Columns[1].Count)
+	assert.Equal(t, 2, *board.Columns[1].WIPLimit)
+
+	assert.Equal(t, 0, board.Columns[2].Count)
+}
+
+func TestWIPLimit_RefusesMovesIntoFullColumn(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	assert.Equal(t, http.StatusOK, putWorkflow(r, "", limitedWorkflow).Code)
+	first := createTestTask(t, r, map[string]any{"title": "Build", "status": "In Progress"})
+	createTestTask(t, r, map[string]any{"title": "Test", "status": "In Progress"})
+	waiting := createTestTask(t, r, map[string]any{"title": "Deploy", "status": "TODO"})
+
+	// Creating straight into the column
+	w := makePostRequest(r, marshalTaskBody("Document", "", "In Progress", ""))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "In Progress", resp["status"])
+	assert.Equal(t, float64(2), resp["count"])
+	assert.Equal(t, float64(2), resp["wip_limit"])
+
+	// Moving in with PATCH or PUT
+	assert.Equal(t, http.StatusConflict, makePatchRequest(r, waiting.ID, `{"status":"In Progress"}`).Code)
+	body := []byte(`{"title":"Deploy","status":"In Progress","priority":"Medium"}`)
+	assert.Equal(t, http.StatusConflict, makePutRequest(r, waiting.ID, body).Code)
+	assert.Equal(t, "TODO", getTask(t, r, waiting.ID).Status)
+
+	// Tasks already in the column can still be edited
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, first.ID, `{"status":"In Progress","title":"Build it"}`).Code)
+
+	// Finishing one frees a slot
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, first.ID, `{"status":"Done"}`).Code)
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, waiting.ID, `{"status":"In Progress"}`).Code)
+}
+
+func TestWIPLimit_ForceOverrides(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	assert.Equal(t, http.StatusOK, putWorkflow(r, "", limitedWorkflow).Code)
+	createTestTask(t, r, map[string]any{"title": "Build", "status": "In Progress"})
+	createTestTask(t, r, map[string]any{"title": "Test", "status": "In Progress"})
+	waiting := createTestTask(t, r, map[string]any{"title": "Deploy", "status": "TODO"})
+
+	w := makeJSONRequest(r, "PATCH", "/api/task/"+waiting.ID+"?force=true", []byte(`{"status":"In Progress"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = makeJSONRequest(r, "POST", "/api/task?force=true", marshalTaskBody("Hotfix", "", "In Progress", ""))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	assert.Equal(t, 4, getBoard(t, r, "").Columns[1].Count)
+}
+
+func TestWIPLimit_ConcurrentWritesTakeTurns(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	assert.Equal(t, http.StatusOK, putWorkflow(r, "", limitedWorkflow).Code)
+
+	// The column is counted inside each write, so only two of these fit
+	const creates = 10
+	codes := make(chan int, creates)
+	var wg sync.WaitGroup
+	for i := range creates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- makePostRequest(r, marshalTaskBody(fmt.Sprintf("Task %d", i), "", "In Progress", "")).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	created := 0
+	for code := range codes {
+		if code == http.StatusCreated {
+			created++
+		} else {
+			assert.Equal(t, http.StatusConflict, code)
+		}
+	}
+	assert.Equal(t, 2, created)
+	assert.Equal(t, 2, getBoard(t, r, "").Columns[1].Count)
+}
+
+func TestWIPLimit_CountsPerBoard(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	proj := createTestProject(t, r, "Launch")
+	query := fmt.Sprintf("?project=%d", proj.ID)
+	assert.Equal(t, http.StatusOK, putWorkflow(r, query, limitedWorkflow).Code)
+
+	// Tasks on the default board don't use up the project's slots
+	createTestTask(t, r, map[string]any{"title": "Groceries", "status": "In Progress"})
+	createTestTask(t, r, map[string]any{"title": "Laundry", "status": "In Progress"})
+	createTestTask(t, r, map[string]any{"title": "Taxes", "status": "In Progress"})
+
+	body := fmt.Appendf(nil, `{"title":"Launch plan","status":"In Progress","project_id":%d}`, proj.ID)
+	assert.Equal(t, http.StatusCreated, makePostRequest(r, body).Code)
+	assert.Equal(t, http.StatusCreated, makePostRequest(r, body).Code)
+	assert.Equal(t, http.StatusConflict, makePostRequest(r, body).Code)
+
+	board := getBoard(t, r, query)
+	assert.Equal(t, proj.ID, *board.ProjectID)
+	assert.Equal(t, 2, board.Columns[1].Count)
+	assert.Equal(t, 3, getBoard(t, r, "").Columns[1].Count)
+}
+
+func TestPutWorkflowHandler_RejectsInvalidWIPLimit(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	w := putWorkflow(r, "", `{"columns":[{"name":"Done","category":"done","wip_limit":0}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	task "tasker/internal/Task"
+	"tasker/internal/repository"
+	"tasker/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBulkTasks bounds how many tasks a single bulk request can change
+const maxBulkTasks = 200
+
+// Bulk operations
+const (
+	bulkSetStatus   = "set_status"
+	bulkSetPriority = "set_priority"
+	bulkReplace     = "replace"
+	bulkDelete      = "delete"
+)
+
+// Outcomes of a bulk request for one task
+const (
+	bulkUpdated   = "updated"
+	bulkDeleted   = "deleted"
+	bulkUnchanged = "unchanged"
+	bulkFailed    = "failed"
+	// bulkSkipped tasks were fine but weren't changed because another failed
+	bulkSkipped = "skipped"
+)
+
+// bulkOperation is one change applied to every task of a bulk request
+type bulkOperation struct {
+	Op       string `json:"op"`
+	Status   string `json:"status"`
+	Priority string `json:"priority"`
+	// Field, Find and Replace replace every Find in the title or description
+	Field   string `json:"field"`
+	Find    string `json:"find"`
+	Replace string `json:"replace"`
+	// Subtasks is reject or cascade, as for DELETE /api/task/:id
+	Subtasks string `json:"subtasks"`
+}
+
+// bulkRequest is the POST /api/task/bulk body. The operations are applied in
+// order to each task.
+type bulkRequest struct {
+	IDs        []string        `json:"ids"`
+	Operations []bulkOperation `json:"operations"`
+	DryRun     bool            `json:"dry_run"`
+}
+
+// bulkResult is what happened, or would happen on a dry run, to one task
+type bulkResult struct {
+	ID      string            `json:"id"`
+	Result  string            `json:"result"`
+	Task    *task.Task        `json:"task,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// bulkItem is a task of a bulk request as read and as it is to become
+type bulkItem struct {
+	before *task.Task
+	after  task.Task
+	wf     *workflow.Workflow
+}
+
+func validateBulkRequest(req bulkRequest) map[string]string {
+	errors := make(map[string]string)
+
+	switch {
+	case len(req.IDs) == 0:
+		errors["ids"] = "ids must list at least one task"
+	case len(req.IDs) > maxBulkTasks:
+		errors["ids"] = fmt.Sprintf("a bulk change can cover at most %d tasks", maxBulkTasks)
+	case slices.ContainsFunc(req.IDs, func(id string) bool { return strings.TrimSpace(id) == "" }):
+		errors["ids"] = "ids cannot be empty"
+	}
+
+	if len(req.Operations) == 0 {
+		errors["operations"] = "operations must list at least one operation"
+	}
+	for i, op := range req.Operations {
+		var problem string
+		switch op.Op {
+		case bulkSetStatus:
+			if op.Status == "" {
+				problem = "status is required"
+			}
+		case bulkSetPriority:
+			if !slices.Contains(task.Priorities, op.Priority) {
+				problem = "priority must be one of: " + strings.Join(task.Priorities, ", ")
+			}
+		case bulkReplace:
+			if op.Field != "title" && op.Field != "description" {
+				problem = "field must be one of: title, description"
+			} else if op.Find == "" {
+				problem = "find cannot be empty"
+			}
+		case bulkDelete:
+			if len(req.Operations) > 1 {
+				problem = "delete cannot be combined with other operations"
+			} else if op.Subtasks != "" && op.Subtasks != string(repository.TaskDeleteReject) && op.Subtasks != string(repository.TaskDeleteCascade) {
+				problem = "subtasks must be one of: reject, cascade"
+			}
+		default:
+			problem = "op must be one of: set_status, set_priority, replace, delete"
+		}
+		if problem != "" {
+			errors["operations"] = fmt.Sprintf("operation %d: %s", i+1, problem)
+			break
+		}
+	}
+
+	return errors
+}
+
+// applyBulkOperations returns t with every update operation applied
+func applyBulkOperations(t task.Task, ops []bulkOperation) task.Task {
+	for _, op := range ops {
+		switch op.Op {
+		case bulkSetStatus:
+			t.Status = op.Status
+		case bulkSetPriority:
+			t.Priority = op.Priority
+		case bulkReplace:
+			if op.Field == "title" {
+				t.Title = strings.ReplaceAll(t.Title, op.Find, op.Replace)
+			} else {
+				t.Description = strings.ReplaceAll(t.Description, op.Find, op.Replace)
+			}
+		}
+	}
+	t.Title = strings.TrimSpace(t.Title)
+	return t
+}
+
+// childrenFirst orders the indexes of items so that a task comes after its
+// subtasks among them, which lets a parent be deleted together with its
+// children without cascading
+func childrenFirst(items []bulkItem, indexes []int) []int {
+	parents := make(map[string]string)
+	for _, i := range indexes {
+		if items[i].before.ParentID != nil {
+			parents[items[i].before.ID] = *items[i].before.ParentID
+		}
+	}
+	depth := func(id string) int {
+		d := 0
+		for parent, ok := parents[id]; ok && d < maxBulkTasks; parent, ok = parents[parent] {
+			d++
+		}
+		return d
+	}
+
+	ordered := slices.Clone(indexes)
+	slices.SortStableFunc(ordered, func(a, b int) int {
+		return cmp.Compare(depth(items[b].before.ID), depth(items[a].before.ID))
+	})
+	return ordered
+}
+
+// blockersFirst orders the indexes of items so that a task comes after the
+// tasks blocking it among them, which lets a task be done together with its
+// blockers
+func blockersFirst(items []bulkItem, indexes []int) []int {
+	blockedBy := make(map[string][]string)
+	for _, i := range indexes {
+		blockedBy[items[i].before.ID] = items[i].before.BlockedBy
+	}
+	var depth func(id string, d int) int
+	depth = func(id string, d int) int {
+		deepest := d
+		for _, blocker := range blockedBy[id] {
+			if _, ok := blockedBy[blocker]; ok && d < maxBulkTasks {
+				deepest = max(deepest, depth(blocker, d+1))
+			}
+		}
+		return deepest
+	}
+
+	ordered := slices.Clone(indexes)
+	slices.SortStableFunc(ordered, func(a, b int) int {
+		return cmp.Compare(depth(items[a].before.ID, 0), depth(items[b].before.ID, 0))
+	})
+	return ordered
+}
+
+// BulkTaskHandler handles POST /api/task/bulk requests, applying the same
+// operations to many tasks in one transaction: all of them change or none do.
+// Every task gets a result; when one fails the response is 409 and the others
+// are skipped. With dry_run the changes are checked and made, then rolled back.
+// ?force=true lets tasks past WIP limits like it does for single tasks.
+func BulkTaskHandler(c *gin.Context) {
+	var req bulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if validationErrors := validateBulkRequest(req); len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	userID := currentUserID(c)
+	deleting := req.Operations[0].Op == bulkDelete
+	ids := uniqueIDs(req.IDs)
+
+	results := make([]bulkResult, len(ids))
+	items := make([]bulkItem, len(ids))
+	fail := func(i int, msg string, details map[string]string) {
+		results[i].Result = bulkFailed
+		results[i].Error = msg
+		results[i].Details = details
+	}
+
+	// Workflows are read once per board, so tasks on the same board share one
+	boards := make(map[string]*workflow.Workflow)
+	boardKey := func(projectID *int64) string {
+		if projectID == nil {
+			return ""
+		}
+		return fmt.Sprint(*projectID)
+	}
+	board := func(projectID *int64) (*workflow.Workflow, bool) {
+		if wf, ok := boards[boardKey(projectID)]; ok {
+			return wf, true
+		}
+		wf, ok := loadWorkflow(c, projectID)
+		if !ok {
+			return nil, false
+		}
+		// Projects without a workflow of their own share the default board
+		if shared, ok := boards[boardKey(wf.ProjectID)]; ok {
+			wf = shared
+		}
+		boards[boardKey(projectID)], boards[boardKey(wf.ProjectID)] = wf, wf
+		return wf, true
+	}
+
+	seen := make(map[string]bool)
+	for i, id := range ids {
+		results[i].ID = id
+
+		existing, err := repository.Tasks.GetTaskByID(userID, id)
+		if err != nil {
+			if strings.Contains(err.Error(), "task not found") {
+				fail(i, "task not found", nil)
+				continue
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+			return
+		}
+		if seen[existing.ID] {
+			fail(i, "task is listed more than once", nil)
+			continue
+		}
+		seen[existing.ID] = true
+		items[i].before = existing
+		if deleting {
+			continue
+		}
+
+		after := applyBulkOperations(*existing, req.Operations)
+		validationErrors := make(map[string]string)
+		if after.Title == "" {
+			validationErrors["title"] = "title cannot be empty or whitespace only"
+		}
+		if after.Status != existing.Status {
+			wf, ok := board(existing.ProjectID)
+			if !ok {
+				return
+			}
+			validateStatus(wf, after.Status, validationErrors)
+			validateTransition(wf, existing.Status, after.Status, validationErrors)
+			after.StatusCategory = wf.Category(after.Status)
+			items[i].wf = wf
+		}
+		if len(validationErrors) > 0 {
+			fail(i, "validation failed", validationErrors)
+			continue
+		}
+		items[i].after = after
+	}
+
+	// changing lists the tasks that pass the checks so far
+	changing := func() []int {
+		var indexes []int
+		for i := range items {
+			if results[i].Result != bulkFailed {
+				indexes = append(indexes, i)
+			}
+		}
+		return indexes
+	}
+
+	if !deleting {
+		// Completing a task needs its blockers done first, or done in this same change
+		doneHere := make(map[string]bool)
+		for _, i := range changing() {
+			if items[i].after.StatusCategory == workflow.CategoryDone {
+				doneHere[items[i].before.ID] = true
+			}
+		}
+		for _, i := range changing() {
+			if items[i].after.StatusCategory != workflow.CategoryDone || items[i].before.StatusCategory == workflow.CategoryDone {
+				continue
+			}
+			blockers, err := repository.Tasks.GetOpenBlockers(userID, items[i].before.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check blockers"})
+				return
+			}
+			var open []string
+			for _, b := range blockers {
+				if !doneHere[b.ID] {
+					open = append(open, b.ID)
+				}
+			}
+			if len(open) > 0 {
+				fail(i, "task is blocked by unfinished tasks", map[string]string{"blocked_by": strings.Join(open, ", ")})
+			}
+		}
+	}
+
+	var limits []repository.WIPLimit
+	if !deleting {
+		var ok bool
+		if limits, ok = bulkWIPLimits(c, items, changing()); !ok {
+			return
+		}
+	}
+
+	// applied maps each change back to the task it came from
+	var changes []repository.TaskChange
+	var applied []int
+	order := changing()
+	if deleting {
+		order = childrenFirst(items, order)
+	} else {
+		order = blockersFirst(items, order)
+	}
+	for _, i := range order {
+		change := repository.TaskChange{ID: items[i].before.ID}
+		if deleting {
+			change.Delete = true
+			change.DeleteMode = repository.TaskDeleteMode(cmp.Or(req.Operations[0].Subtasks, string(repository.TaskDeleteReject)))
+			change.Task.Version = items[i].before.Version
+		} else {
+			after, before := items[i].after, items[i].before
+			if after.Title == before.Title && after.Description == before.Description && after.Status == before.Status && after.Priority == before.Priority {
+				results[i].Result = bulkUnchanged
+				results[i].Task = before
+				continue
+			}
+			change.Task = after
+		}
+		changes = append(changes, change)
+		applied = append(applied, i)
+	}
+
+	respondFailed := func() {
+		for i := range results {
+			if results[i].Result != bulkFailed {
+				results[i] = bulkResult{ID: results[i].ID, Result: bulkSkipped}
+			}
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "bulk change failed; no task was changed", "dry_run": req.DryRun, "results": results})
+	}
+
+	if len(changing()) < len(items) {
+		respondFailed()
+		return
+	}
+
+	changed, err := repository.Tasks.ApplyTaskChanges(userID, changes, req.DryRun, limits)
+	if err != nil {
+		var over *repository.WIPLimitError
+		if errors.As(err, &over) {
+			for _, i := range applied {
+				if items[i].after.Status == over.Status && items[i].before.Status != over.Status && sameBoard(items[i].wf.ProjectID, over.Board) {
+					fail(i, "column would go over its WIP limit; pass force=true to override", map[string]string{
+						"status":    over.Status,
+						"wip_limit": fmt.Sprint(over.Limit),
+					})
+				}
+			}
+			respondFailed()
+			return
+		}
+		var changeErr *repository.TaskChangeError
+		if !errors.As(err, &changeErr) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to apply bulk change"})
+			return
+		}
+		i := applied[changeErr.Index]
+		var blocked *repository.BlockedError
+		if errors.As(changeErr.Err, &blocked) {
+			open := make([]string, len(blocked.Blockers))
+			for j, b := range blocked.Blockers {
+				open[j] = b.ID
+			}
+			fail(i, "task is blocked by unfinished tasks", map[string]string{"blocked_by": strings.Join(open, ", ")})
+			respondFailed()
+			return
+		}
+		switch msg := changeErr.Err.Error(); {
+		case strings.Contains(msg, "task not found"):
+			fail(i, "task not found", nil)
+		case strings.Contains(msg, "task version conflict"):
+			fail(i, "task was changed by someone else; try again", nil)
+		case strings.Contains(msg, "task has subtasks"):
+			fail(i, "task has subtasks; delete them too or pass subtasks=cascade", nil)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to apply bulk change"})
+			return
+		}
+		respondFailed()
+		return
+	}
+
+	for j, i := range applied {
+		if deleting {
+			results[i].Result = bulkDeleted
+			continue
+		}
+		results[i].Result = bulkUpdated
+		results[i].Task = changed[j]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dry_run": req.DryRun, "results": results})
+}
+
+// uniqueIDs drops repeats of the same key, keeping the first
+func uniqueIDs(ids []string) []string {
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if !slices.Contains(result, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// bulkWIPLimits returns the WIP limits of the columns tasks enter in the
+// change, which the repository checks once every task has moved, so tasks
+// leaving a column make room in it. Items on the same board must share their
+// workflow. ?force=true lifts the limits and logs the columns it takes past
+// them; it responds and returns false when those can't be counted.
+func bulkWIPLimits(c *gin.Context, items []bulkItem, indexes []int) ([]repository.WIPLimit, bool) {
+	type column struct {
+		wf     *workflow.Workflow
+		status string
+	}
+	entering := make(map[column]int)
+	leaving := make(map[column]int)
+	for _, i := range indexes {
+		wf := items[i].wf
+		if wf == nil || items[i].after.Status == items[i].before.Status {
+			continue
+		}
+		entering[column{wf, items[i].after.Status}]++
+		leaving[column{wf, items[i].before.Status}]++
+	}
+
+	var limits []repository.WIPLimit
+	counts := make(map[*workflow.Workflow]map[string]int)
+	for col, entrants := range entering {
+		limit, ok := col.wf.Column(col.status)
+		if !ok || limit.WIPLimit == nil {
+			continue
+		}
+		if c.Query("force") != "true" {
+			limits = append(limits, repository.WIPLimit{Board: col.wf.ProjectID, Status: col.status, Limit: *limit.WIPLimit})
+			continue
+		}
+
+		if counts[col.wf] == nil {
+			boardCounts, err := repository.Workflows.CountTasksByStatus(currentUserID(c), col.wf.ProjectID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count tasks"})
+				return nil, false
+			}
+			counts[col.wf] = boardCounts
+		}
+		if count := counts[col.wf][col.status] - leaving[col] + entrants; count > *limit.WIPLimit {
+			log.Printf("WIP limit overridden: user %d put %d tasks in %q with %d/%d tasks", currentUserID(c), entrants, col.status, count, *limit.WIPLimit)
+		}
+	}
+	return limits, true
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type bulkResponse struct {
+	Error   string       `json:"error"`
+	DryRun  bool         `json:"dry_run"`
+	Results []bulkResult `json:"results"`
+}
+
+func postBulk(r *gin.Engine, query string, body map[string]any) (bulkResponse, int) {
+	raw, _ := json.Marshal(body)
+	w := makeJSONRequest(r, "POST", "/api/task/bulk"+query, raw)
+
+	var resp bulkResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp, w.Code
+}
+
+func TestBulkTaskHandler_UpdatesEveryTask(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	a := createTestTask(t, r, map[string]any{"title": "Q3 report", "status": "TODO"})
+	b := createTestTask(t, r, map[string]any{"title": "Q3 budget", "status": "TODO"})
+
+	resp, code := postBulk(r, "", map[string]any{
+		"ids": []string{a.ID, b.ID},
+		"operations": []map[string]any{
+			{"op": "set_status", "status": "In Progress"},
+			{"op": "set_priority", "priority": "High"},
+			{"op": "replace", "field": "title", "find": "Q3", "replace": "Q4"},
+		},
+	})
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, resp.DryRun)
+	assert.Len(t, resp.Results, 2)
+	for _, result := range resp.Results {
+		assert.Equal(t, "updated", result.Result)
+		assert.Equal(t, "In Progress", result.Task.Status)
+		assert.Equal(t, "High", result.Task.Priority)
+	}
+	assert.Equal(t, "Q4 report", resp.Results[0].Task.Title)
+
+	stored, _ := mockRepo.GetTaskByID(testUserID, b.ID)
+	assert.Equal(t, "Q4 budget", stored.Title)
+	assert.Equal(t, "active", stored.StatusCategory)
+}
+
+func TestBulkTaskHandler_FailureChangesNothing(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	a := createTestTask(t, r, map[string]any{"title": "Fine", "status": "TODO"})
+	b := createTestTask(t, r, map[string]any{"title": "Q3", "status": "TODO"})
+
+	resp, code := postBulk(r, "", map[string]any{
+		"ids":        []string{a.ID, b.ID, "TASK-999"},
+		"operations": []map[string]any{{"op": "replace", "field": "title", "find": "Q3", "replace": " "}},
+	})
+
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "skipped", resp.Results[0].Result)
+	assert.Equal(t, "failed", resp.Results[1].Result)
+	assert.Contains(t, resp.Results[1].Details, "title")
+	assert.Equal(t, "failed", resp.Results[2].Result)
+	assert.Equal(t, "task not found", resp.Results[2].Error)
+
+	stored, _ := mockRepo.GetTaskByID(testUserID, b.ID)
+	assert.Equal(t, "Q3", stored.Title)
+	assert.Equal(t, int64(1), stored.Version)
+}
+
+func TestBulkTaskHandler_RollsBackWhenAWriteFails(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, map[string]any{"title": "Parent", "status": "TODO"})
+	createSubtask(t, r, parent.ID, "Child")
+	other := createTestTask(t, r, map[string]any{"title": "Other", "status": "TODO"})
+
+	// Other goes first and is put back when Parent turns out to have subtasks
+	resp, code := postBulk(r, "", map[string]any{
+		"ids":        []string{other.ID, parent.ID},
+		"operations": []map[string]any{{"op": "delete"}},
+	})
+
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "skipped", resp.Results[0].Result)
+	assert.Equal(t, "task has subtasks; delete them too or pass subtasks=cascade", resp.Results[1].Error)
+
+	_, err := mockRepo.GetTaskByID(testUserID, other.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, mockRepo.trash)
+}
+
+func TestBulkTaskHandler_DeletesParentWithSelectedChildren(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, map[string]any{"title": "Parent", "status": "TODO"})
+	child := createSubtask(t, r, parent.ID, "Child")
+
+	resp, code := postBulk(r, "", map[string]any{
+		"ids":        []string{parent.ID, child.ID},
+		"operations": []map[string]any{{"op": "delete"}},
+	})
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "deleted", resp.Results[0].Result)
+	assert.Equal(t, "deleted", resp.Results[1].Result)
+	assert.Len(t, mockRepo.trash, 2)
+
+	// The whole bulk change is undone at once
+	w := makeJSONRequest(r, "POST", "/api/undo", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, mockRepo.trash)
+}
+
+func TestBulkTaskHandler_DryRun(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	a := createTestTask(t, r, map[string]any{"title": "Ship it", "status": "TODO"})
+
+	resp, code := postBulk(r, "", map[string]any{
+		"ids":        []string{a.ID},
+		"operations": []map[string]any{{"op": "set_status", "status": "Done"}},
+		"dry_run":    true,
+	})
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, resp.DryRun)
+	assert.Equal(t, "updated", resp.Results[0].Result)
+	assert.Equal(t, "Done", resp.Results[0].Task.Status)
+
+	stored, _ := mockRepo.GetTaskByID(testUserID, a.ID)
+	assert.Equal(t, "TODO", stored.Status)
+	history, _ := mockRepo.GetTaskHistory(testUserID, a.ID)
+	assert.Len(t, history, 1)
+}
+
+func TestBulkTaskHandler_Unchanged(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	a := createTestTask(t, r, map[string]any{"title": "Nothing to find", "status": "TODO"})
+
+	resp, code := postBulk(r, "", map[string]any{
+		"ids":        []string{a.ID, a.ID},
+		"operations": []map[string]any{{"op": "replace", "field": "description", "find": "Q3", "replace": "Q4"}},
+	})
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, resp.Results, 1)
+	assert.Equal(t, "unchanged", resp.Results[0].Result)
+	assert.Equal(t, int64(1), resp.Results[0].Task.Version)
+}
+
+func TestBulkTaskHandler_StatusChecks(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	assert.Equal(t, http.StatusOK, putWorkflow(r, "", limitedWorkflow).Code)
+	blocker := createTestTask(t, r, map[string]any{"title": "Blocker", "status": "TODO"})
+	blocked := createTestTask(t, r, map[string]any{"title": "Blocked", "status": "TODO"})
+	assert.Equal(t, http.StatusOK, addDependency(r, blocked.ID, blocker.ID).Code)
+
+	// A blocker finished in the same change doesn't block
+	_, code := postBulk(r, "", map[string]any{
+		"ids":        []string{blocked.ID, blocker.ID},
+		"operations": []map[string]any{{"op": "set_status", "status": "Done"}},
+		"dry_run":    true,
+	})
+	assert.Equal(t, http.StatusOK, code)
+
+	resp, code := postBulk(r, "", map[string]any{
+		"ids":        []string{blocked.ID},
+		"operations": []map[string]any{{"op": "set_status", "status": "Done"}},
+	})
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, map[string]string{"blocked_by": blocker.ID}, resp.Results[0].Details)
+
+	resp, code = postBulk(r, "", map[string]any{
+		"ids":        []string{blocked.ID},
+		"operations": []map[string]any{{"op": "set_status", "status": "Shipped"}},
+	})
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Contains(t, resp.Results[0].Details, "status")
+
+	// Three tasks don't fit a column limited to two, unless forced
+	third := createTestTask(t, r, map[string]any{"title": "Third", "status": "TODO"})
+	body := map[string]any{
+		"ids":        []string{blocker.ID, blocked.ID, third.ID},
+		"operations": []map[string]any{{"op": "set_status", "status": "In Progress"}},
+	}
+	resp, code = postBulk(r, "", body)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, "2", resp.Results[0].Details["wip_limit"])
+
+	_, code = postBulk(r, "?force=true", body)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestBulkTaskHandler_Validation(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	tests := []struct {
+		name  string
+		body  map[string]any
+		field string
+	}{
+		{"no ids", map[string]any{"operations": []map[string]any{{"op": "delete"}}}, "ids"},
+		{"no operations", map[string]any{"ids": []string{"TASK-001"}}, "operations"},
+		{"unknown op", map[string]any{"ids": []string{"TASK-001"}, "operations": []map[string]any{{"op": "archive"}}}, "operations"},
+		{"bad priority", map[string]any{"ids": []string{"TASK-001"}, "operations": []map[string]any{{"op": "set_priority", "priority": "Urgent"}}}, "operations"},
+		{"bad field", map[string]any{"ids": []string{"TASK-001"}, "operations": []map[string]any{{"op": "replace", "field": "status", "find": "a"}}}, "operations"},
+		{"delete and more", map[string]any{"ids": []string{"TASK-001"}, "operations": []map[string]any{{"op": "delete"}, {"op": "set_priority", "priority": "Low"}}}, "operations"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, _ := json.Marshal(tt.body)
+			w := makeJSONRequest(r, "POST", "/api/task/bulk", raw)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var resp map[string]any
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			assert.Contains(t, resp["details"], tt.field)
+		})
+	}
+}
@@ -8,15 +8,37 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// DeleteTaskHandler handles DELETE /api/task/:id requests
+// DeleteTaskHandler handles DELETE /api/task/:id requests.
+// With If-Match the task is only deleted if it hasn't changed since it was read.
+// A task with subtasks is only deleted with ?subtasks=cascade, which deletes them too.
 func DeleteTaskHandler(c *gin.Context) {
 	taskID := c.Param("id")
 
-	if err := repository.Tasks.DeleteTask(taskID); err != nil {
+	mode := repository.TaskDeleteMode(c.DefaultQuery("subtasks", string(repository.TaskDeleteReject)))
+	if mode != repository.TaskDeleteReject && mode != repository.TaskDeleteCascade {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"subtasks": "subtasks must be one of: reject, cascade"}})
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+
+	if err := repository.Tasks.DeleteTask(currentUserID(c), taskID, version, mode); err != nil {
 		if strings.Contains(err.Error(), "task not found") {
 			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
 			return
 		}
+		if strings.Contains(err.Error(), "task version conflict") {
+			respondPreconditionFailed(c, taskID)
+			return
+		}
+		if strings.Contains(err.Error(), "task has subtasks") {
+			c.JSON(http.StatusConflict, gin.H{"error": "task has subtasks; delete them first or pass subtasks=cascade"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete task"})
 		return
 	}
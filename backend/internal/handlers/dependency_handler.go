@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dependencyRequest is the POST /api/task/:id/dependencies body
+type dependencyRequest struct {
+	BlockerID string `json:"blocker_id"`
+}
+
+// respondIfBlocked answers a write refused for completing a task while any of
+// its blockers is not Done, listing those blockers. It reports whether err was
+// one.
+func respondIfBlocked(c *gin.Context, err error) bool {
+	var blocked *repository.BlockedError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+
+	open := make([]gin.H, len(blocked.Blockers))
+	for i, b := range blocked.Blockers {
+		open[i] = gin.H{"id": b.ID, "title": b.Title, "status": b.Status}
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "task is blocked by unfinished tasks", "blockers": open})
+	return true
+}
+
+// PostDependencyHandler handles POST /api/task/:id/dependencies requests. The task
+// named by blocker_id must be Done before :id can be.
+func PostDependencyHandler(c *gin.Context) {
+	var req dependencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if strings.TrimSpace(req.BlockerID) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"blocker_id": "blocker_id is required"}})
+		return
+	}
+
+	updatedTask, err := repository.Tasks.AddDependency(currentUserID(c), c.Param("id"), strings.TrimSpace(req.BlockerID))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "task not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		case strings.Contains(err.Error(), "blocker not found"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"blocker_id": "blocker task does not exist"}})
+		case strings.Contains(err.Error(), "dependency cycle"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"blocker_id": "dependency would create a cycle"}})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add dependency"})
+		}
+		return
+	}
+
+	c.Header("ETag", taskETag(updatedTask))
+	c.JSON(http.StatusOK, updatedTask)
+}
+
+// DeleteDependencyHandler handles DELETE /api/task/:id/dependencies/:blocker_id requests
+func DeleteDependencyHandler(c *gin.Context) {
+	err := repository.Tasks.RemoveDependency(currentUserID(c), c.Param("id"), c.Param("blocker_id"))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "task not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		case strings.Contains(err.Error(), "dependency not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "dependency not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove dependency"})
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
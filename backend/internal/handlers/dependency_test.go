@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	task "tasker/internal/Task"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func addDependency(r *gin.Engine, id string, blockerID string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"blocker_id":%q}`, blockerID)
+	return makeJSONRequest(r, "POST", "/api/task/"+id+"/dependencies", []byte(body))
+}
+
+func TestPostDependencyHandler_Success(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	blocker := createTestTask(t, r, originalTask)
+	blocked := createTestTask(t, r, originalTask)
+
+	w := addDependency(r, blocked.ID, blocker.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updated task.Task
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	assert.Equal(t, []string{blocker.ID}, []string(updated.BlockedBy))
+	assert.Empty(t, updated.Blocks)
+	assert.Greater(t, updated.Version, blocked.Version)
+
+	assert.Equal(t, []string{blocked.ID}, []string(getTask(t, r, blocker.ID).Blocks))
+
+	// Adding the same edge again changes nothing
+	w = addDependency(r, blocked.ID, blocker.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, getTask(t, r, blocked.ID).BlockedBy, 1)
+}
+
+func TestTaskResponses_IncludeEmptyDependencyLists(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	w := makePostRequest(r, marshalTaskBody("Alone", "", "", ""))
+
+	var body map[string]any
+	json.Unmarshal(w.Body.Bytes(), &body)
+	assert.Equal(t, []any{}, body["blocked_by"])
+	assert.Equal(t, []any{}, body["blocks"])
+}
+
+func TestPostDependencyHandler_RejectsCycles(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	a := createTestTask(t, r, originalTask)
+	b := createTestTask(t, r, originalTask)
+	c := createTestTask(t, r, originalTask)
+	assert.Equal(t, http.StatusOK, addDependency(r, b.ID, a.ID).Code)
+	assert.Equal(t, http.StatusOK, addDependency(r, c.ID, b.ID).Code)
+
+	tests := []struct {
+		name          string
+		id, blockerID string
+	}{
+		{"self", a.ID, a.ID},
+		{"direct", a.ID, b.ID},
+		{"transitive", a.ID, c.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := addDependency(r, tt.id, tt.blockerID)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), "cycle")
+		})
+	}
+}
+
+func TestPostDependencyHandler_Errors(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	existing := createTestTask(t, r, originalTask)
+
+	assert.Equal(t, http.StatusNotFound, addDependency(r, "TASK-404", existing.ID).Code)
+	assert.Equal(t, http.StatusBadRequest, addDependency(r, existing.ID, "TASK-404").Code)
+	assert.Equal(t, http.StatusBadRequest, addDependency(r, existing.ID, " ").Code)
+}
+
+func TestDeleteDependencyHandler(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	blocker := createTestTask(t, r, originalTask)
+	blocked := createTestTask(t, r, originalTask)
+	addDependency(r, blocked.ID, blocker.ID)
+
+	w := makeJSONRequest(r, "DELETE", "/api/task/"+blocked.ID+"/dependencies/"+blocker.ID, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, getTask(t, r, blocked.ID).BlockedBy)
+
+	w = makeJSONRequest(r, "DELETE", "/api/task/"+blocked.ID+"/dependencies/"+blocker.ID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDoneTransition_RefusedWhileBlocked(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	blocker := createTestTask(t, r, originalTask)
+	blocked := createTestTask(t, r, originalTask)
+	addDependency(r, blocked.ID, blocker.ID)
+
+	w := makeJSONRequest(r, "PUT", "/api/task/"+blocked.ID, []byte(`{"title":"Blocked","status":"Done","priority":"Low"}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response struct {
+		Error    string           `json:"error"`
+		Blockers []map[string]any `json:"blockers"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Len(t, response.Blockers, 1)
+	assert.Equal(t, blocker.ID, response.Blockers[0]["id"])
+	assert.Equal(t, "In Progress", response.Blockers[0]["status"])
+
+	w = makePatchRequest(r, blocked.ID, `{"status":"Done"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "In Progress", getTask(t, r, blocked.ID).Status)
+
+	// Other edits still go through
+	w = makePatchRequest(r, blocked.ID, `{"title":"Still blocked"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	makePatchRequest(r, blocker.ID, `{"status":"Done"}`)
+	w = makePatchRequest(r, blocked.ID, `{"status":"Done"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestDependencies_FollowRekeyAndDelete(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	blocker := createTestTask(t, r, originalTask)
+	blocked := createTestTask(t, r, originalTask)
+	addDependency(r, blocked.ID, blocker.ID)
+
+	w := makeJSONRequest(r, "POST", "/api/task/"+blocker.ID+"/rekey", []byte(`{"prefix":"HOME"}`))
+	var rekeyed task.Task
+	json.Unmarshal(w.Body.Bytes(), &rekeyed)
+	assert.Equal(t, []string{rekeyed.ID}, []string(getTask(t, r, blocked.ID).BlockedBy))
+
+	makeDeleteRequest(r, rekeyed.ID)
+	assert.Empty(t, getTask(t, r, blocked.ID).BlockedBy)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	task "tasker/internal/Task"
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// taskETag is the strong entity tag for a single task version
+func taskETag(t *task.Task) string {
+	return fmt.Sprintf(`"%d"`, t.Version)
+}
+
+// taskListETag changes whenever any task in the list is added, removed or written.
+// Individual tasks carry their own version for use with If-Match.
+func taskListETag(tasks []task.Task) string {
+	h := sha256.New()
+	for _, t := range tasks {
+		fmt.Fprintf(h, "%s:%d\n", t.ID, t.Version)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil))[:16] + `"`
+}
+
+// ifMatchVersion reads the If-Match header. It returns 0 when the header is absent
+// or "*", meaning any version may be written. A header that can't name a task
+// version can never match, so ok is false.
+func ifMatchVersion(c *gin.Context) (version int64, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	tag, found := strings.CutPrefix(header, `"`)
+	tag, closed := strings.CutSuffix(tag, `"`)
+	if !found || !closed {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// respondPreconditionFailed sends 412 with the server's current copy of the task
+// so the client can merge its change and retry
+func respondPreconditionFailed(c *gin.Context, taskID string) {
+	current, err := repository.Tasks.GetTaskByID(currentUserID(c), taskID)
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+		return
+	}
+
+	c.Header("ETag", taskETag(current))
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "task has been modified", "current": current})
+}
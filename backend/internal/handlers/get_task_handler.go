@@ -1,19 +1,206 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"tasker/internal/repository"
+	"tasker/internal/workflow"
+	"time"
+
+	task "tasker/internal/Task"
 
 	"github.com/gin-gonic/gin"
 )
 
-// GetTaskHandler returns all tasks
+// maxTaskPageSize caps ?limit= so one request can't pull the whole table in a single page
+const maxTaskPageSize = 500
+
+// taskPage is a listing asked for with limit or cursor. NextCursor continues it
+// and is null on the last page.
+type taskPage struct {
+	Tasks      []task.Task `json:"tasks"`
+	NextCursor *string     `json:"next_cursor"`
+}
+
+// parseTimeParam accepts an RFC 3339 timestamp or a plain YYYY-MM-DD date
+func parseTimeParam(value string) (*time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// listParam collects a filter given either repeated (?status=a&status=b) or comma-separated
+func listParam(c *gin.Context, name string) []string {
+	var values []string
+	for _, param := range c.QueryArray(name) {
+		for v := range strings.SplitSeq(param, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// parseListOptions reads the GET /api/task query string. defaultSort is the
+// sort parameter used when the request has none.
+func parseListOptions(c *gin.Context, defaultSort string) (repository.ListOptions, map[string]string) {
+	errors := make(map[string]string)
+	opts := repository.ListOptions{
+		Statuses:   listParam(c, "status"),
+		Priorities: listParam(c, "priority"),
+		Query:      strings.TrimSpace(c.Query("q")),
+	}
+
+	if projectParam := c.Query("project"); projectParam != "" {
+		projectID, err := strconv.ParseInt(projectParam, 10, 64)
+		if err != nil {
+			errors["project"] = "invalid project id"
+		}
+		opts.ProjectID = &projectID
+	}
+
+	// Statuses differ from board to board, so any name is accepted; categories are fixed
+	opts.Categories = listParam(c, "category")
+	for _, category := range opts.Categories {
+		if !slices.Contains(workflow.Categories, category) {
+			errors["category"] = "category must be one of: " + strings.Join(workflow.Categories, ", ")
+		}
+	}
+	for _, p := range opts.Priorities {
+		if !slices.Contains(task.Priorities, p) {
+			errors["priority"] = "priority must be one of: " + strings.Join(task.Priorities, ", ")
+		}
+	}
+
+	ranges := map[string]**time.Time{
+		"created_after":   &opts.CreatedAfter,
+		"created_before":  &opts.CreatedBefore,
+		"updated_after":   &opts.UpdatedAfter,
+		"updated_before":  &opts.UpdatedBefore,
+		"due_after":       &opts.DueAfter,
+		"due_before":      &opts.DueBefore,
+		"archived_after":  &opts.ArchivedAfter,
+		"archived_before": &opts.ArchivedBefore,
+	}
+	for name, dest := range ranges {
+		if value := c.Query(name); value != "" {
+			t, err := parseTimeParam(value)
+			if err != nil {
+				errors[name] = name + " must be an RFC 3339 timestamp or YYYY-MM-DD date"
+			}
+			*dest = t
+		}
+	}
+
+	if view := c.Query("due"); view != "" {
+		switch {
+		case !slices.Contains(dueViews, view):
+			errors["due"] = "due must be one of: " + strings.Join(dueViews, ", ")
+		case opts.DueAfter != nil || opts.DueBefore != nil:
+			errors["due"] = "due cannot be combined with due_after or due_before"
+		default:
+			loc, err := requestLocation(c)
+			if err != nil {
+				errors["tz"] = "tz must be an IANA time zone such as Europe/Berlin"
+				break
+			}
+			applyDueView(&opts, view, time.Now(), loc)
+		}
+	}
+
+	opts.Tags = normalizeTags(listParam(c, "tags"))
+	switch c.DefaultQuery("tags_match", "any") {
+	case "any":
+	case "all":
+		opts.AllTags = true
+	default:
+		errors["tags_match"] = "tags_match must be one of: any, all"
+	}
+
+	sort := c.DefaultQuery("sort", defaultSort)
+	opts.Sort = strings.TrimPrefix(sort, "-")
+	opts.Descending = strings.HasPrefix(sort, "-")
+	if !slices.Contains(repository.SortableTaskColumns, opts.Sort) {
+		errors["sort"] = "sort must be one of: " + strings.Join(repository.SortableTaskColumns, ", ") + " (prefix with - for descending)"
+	}
+
+	if limitParam := c.Query("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 1 || limit > maxTaskPageSize {
+			errors["limit"] = fmt.Sprintf("limit must be between 1 and %d", maxTaskPageSize)
+		}
+		opts.Limit = limit
+	}
+
+	if cursorParam := c.Query("cursor"); cursorParam != "" {
+		cursor, err := repository.DecodeTaskCursor(cursorParam)
+		if err != nil || cursor.Sort != opts.Sort || cursor.Descending != opts.Descending {
+			errors["cursor"] = "cursor is invalid or was issued for a different sort"
+		}
+		opts.After = cursor
+	}
+
+	return opts, errors
+}
+
+// GetTaskHandler lists tasks. Query parameters filter (project, status, priority,
+// tags with tags_match, created_/updated_/due_/archived_ after/before, due views in the tz zone), search (q),
+// order (sort) and page (limit, cursor) the results. Without paging the body is a plain array of tasks;
+// with it, a page whose next_cursor continues the listing.
 func GetTaskHandler(c *gin.Context) {
-	tasks, err := repository.Tasks.GetAllTasks()
+	opts, validationErrors := parseListOptions(c, "-"+repository.DefaultTaskSort)
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	respondTaskList(c, opts)
+}
+
+// respondTaskList writes the tasks matching opts with their ETag, as a taskPage
+// when opts pages through them
+func respondTaskList(c *gin.Context, opts repository.ListOptions) {
+	tasks, next, err := repository.Tasks.ListTasks(currentUserID(c), opts)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tasks"})
 		return
 	}
 
-	c.JSON(http.StatusOK, tasks)
+	c.Header("ETag", taskListETag(tasks))
+	if opts.Limit == 0 && opts.After == nil {
+		c.JSON(http.StatusOK, tasks)
+		return
+	}
+
+	page := taskPage{Tasks: tasks}
+	if next != "" {
+		page.NextCursor = &next
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetTaskByIDHandler handles GET /api/task/:id requests.
+// Old keys of re-keyed tasks resolve to the task under its current key.
+func GetTaskByIDHandler(c *gin.Context) {
+	t, err := repository.Tasks.GetTaskByID(currentUserID(c), c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+		return
+	}
+
+	c.Header("ETag", taskETag(t))
+	c.JSON(http.StatusOK, t)
 }
@@ -37,3 +37,33 @@ func TestGetTaskHandler(t *testing.T) {
 	assert.Contains(t, body, "In Progress")
 	assert.Contains(t, body, "High")
 }
+
+func TestGetTaskByIDHandler(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	postW := makePostRequest(r, marshalTaskBody("Pay rent", "", "", ""))
+	assert.Equal(t, http.StatusCreated, postW.Code)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/task/TASK-001", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Pay rent")
+}
+
+func TestGetTaskByIDHandler_NotFound(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/task/TASK-404", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"tasker/internal/graph"
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetGraphHandler handles GET /api/graph requests. It returns the dependency
+// graph with each task's earliest schedule and the critical path, as JSON or,
+// with ?format=dot, as Graphviz DOT. ?target= narrows the graph to one task and
+// everything it waits on.
+func GetGraphHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "dot" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"format": "format must be one of: json, dot"}})
+		return
+	}
+
+	var target string
+	if param := c.Query("target"); param != "" {
+		t, err := repository.Tasks.GetTaskByID(currentUserID(c), param)
+		if err != nil {
+			if strings.Contains(err.Error(), "task not found") {
+				c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+			return
+		}
+		target = t.ID
+	}
+
+	tasks, err := repository.Tasks.GetAllTasks(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get tasks"})
+		return
+	}
+
+	g, err := graph.Build(tasks, target, time.Now().UTC().Truncate(time.Second))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build graph"})
+		return
+	}
+
+	if format == "dot" {
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(g.DOT()))
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/graph"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func getGraph(t *testing.T, r *gin.Engine, query string) graph.Graph {
+	w := makeJSONRequest(r, "GET", "/api/graph"+query, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var g graph.Graph
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &g))
+	return g
+}
+
+// seedGraph builds design (4h) and build (10h), which both block ship (2h)
+func seedGraph(t *testing.T, r *gin.Engine) (design, build, ship task.Task) {
+	design = createTestTask(t, r, map[string]any{"title": "Design", "estimate_hours": 4})
+	build = createTestTask(t, r, map[string]any{"title": "Build", "estimate_hours": 10})
+	ship = createTestTask(t, r, map[string]any{"title": "Ship", "estimate_hours": 2})
+	createTestTask(t, r, map[string]any{"title": "Unrelated"})
+	addDependency(r, ship.ID, design.ID)
+	addDependency(r, ship.ID, build.ID)
+	return design, build, ship
+}
+
+func TestGetGraphHandler_CriticalPath(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	_, build, ship := seedGraph(t, r)
+
+	g := getGraph(t, r, "")
+
+	assert.Len(t, g.Nodes, 3)
+	assert.Len(t, g.Edges, 2)
+	assert.Equal(t, []string{build.ID, ship.ID}, g.CriticalPath)
+	assert.Equal(t, ship.ID, g.Target.ID)
+	assert.Equal(t, 12*time.Hour, g.Target.EarliestFinish.Sub(g.Nodes[0].EarliestStart))
+}
+
+func TestGetGraphHandler_DoneBlockersTakeNoTime(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	design, build, ship := seedGraph(t, r)
+	makePatchRequest(r, build.ID, `{"status":"Done"}`)
+
+	g := getGraph(t, r, "?target="+ship.ID)
+
+	assert.Equal(t, []string{design.ID, ship.ID}, g.CriticalPath)
+	assert.Equal(t, 4*time.Hour, g.Target.EarliestStart.Sub(g.Nodes[0].EarliestStart), "ship waits for design only")
+}
+
+func TestGetGraphHandler_TargetKeepsOnlyUpstream(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	design, _, ship := seedGraph(t, r)
+	release := createTestTask(t, r, map[string]any{"title": "Release notes"})
+	addDependency(r, release.ID, ship.ID)
+
+	g := getGraph(t, r, "?target="+design.ID)
+	assert.Len(t, g.Nodes, 1)
+	assert.Empty(t, g.Edges)
+	assert.Equal(t, []string{design.ID}, g.CriticalPath)
+
+	g = getGraph(t, r, "?target="+ship.ID)
+	assert.Len(t, g.Nodes, 3)
+
+	w := makeJSONRequest(r, "GET", "/api/graph?target=TASK-404", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetGraphHandler_DueDatesAndLateTasks(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	inTwoDays := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+	tomorrow := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	// Without an estimate the vendor is assumed to deliver on the due date
+	vendor := createTestTask(t, r, map[string]any{"title": "Vendor delivery", "due_at": inTwoDays})
+	install := createTestTask(t, r, map[string]any{"title": "Install", "estimate_hours": 3, "due_at": tomorrow})
+	addDependency(r, install.ID, vendor.ID)
+
+	g := getGraph(t, r, "?target="+install.ID)
+
+	assert.True(t, g.Target.EarliestFinish.Equal(inTwoDays.Add(3*time.Hour)))
+	assert.True(t, g.Target.Late)
+	assert.Equal(t, []string{vendor.ID, install.ID}, g.CriticalPath)
+}
+
+func TestGetGraphHandler_DOT(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	design, build, ship := seedGraph(t, r)
+
+	w := makeJSONRequest(r, "GET", "/api/graph?format=dot", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/vnd.graphviz")
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "digraph tasks {"))
+	assert.Contains(t, body, fmt.Sprintf(`%q -> %q [color="red"`, build.ID, ship.ID))
+	assert.Contains(t, body, fmt.Sprintf(`%q -> %q;`, design.ID, ship.ID))
+	assert.Contains(t, body, `label="`+ship.ID+`\nShip"`)
+
+	w = makeJSONRequest(r, "GET", "/api/graph?format=svg", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetGraphHandler_Empty(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	createTestTask(t, r, map[string]any{"title": "Alone"})
+
+	g := getGraph(t, r, "")
+
+	assert.Empty(t, g.Nodes)
+	assert.Empty(t, g.CriticalPath)
+	assert.Nil(t, g.Target)
+}
+
+func TestEstimateHours_Validation(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	assert.Equal(t, http.StatusBadRequest, makePostRequest(r, []byte(`{"title":"Negative","estimate_hours":-1}`)).Code)
+
+	created := createTestTask(t, r, map[string]any{"title": "Estimated", "estimate_hours": 1.5})
+	assert.Equal(t, 1.5, *created.EstimateHours)
+
+	assert.Equal(t, http.StatusBadRequest, makePatchRequest(r, created.ID, `{"estimate_hours":"lots"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, makePatchRequest(r, created.ID, `{"estimate_hours":20000}`).Code)
+
+	w := makePatchRequest(r, created.ID, `{"estimate_hours":null}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, getTask(t, r, created.ID).EstimateHours)
+}
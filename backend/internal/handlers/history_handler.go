@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTaskHistoryHandler handles GET /api/task/:id/history requests. It lists
+// the task's events newest first, each with the fields it changed. Deleted
+// tasks can still be looked up by the key they had last.
+func GetTaskHistoryHandler(c *gin.Context) {
+	events, err := repository.Tasks.GetTaskHistory(currentUserID(c), c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func getHistory(t *testing.T, r *gin.Engine, id string) []history.Event {
+	w := makeJSONRequest(r, "GET", "/api/task/"+id+"/history", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var events []history.Event
+	json.Unmarshal(w.Body.Bytes(), &events)
+	return events
+}
+
+// change returns a field's before and after values as JSON text
+func change(e history.Event, field string) (string, string) {
+	c, ok := e.Changes[field]
+	if !ok {
+		return "", ""
+	}
+	return string(c.Before), string(c.After)
+}
+
+func TestGetTaskHistoryHandler_RecordsEveryWrite(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Write report", "status": "TODO"})
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, created.ID, `{"title":"Write the report","priority":"High"}`).Code)
+	assert.Equal(t, http.StatusOK, moveTask(r, created.ID, `{"status":"In Progress"}`).Code)
+
+	events := getHistory(t, r, created.ID)
+	assert.Len(t, events, 3)
+
+	// Newest first
+	move, update, create := events[0], events[1], events[2]
+
+	assert.Equal(t, history.ActionCreate, create.Action)
+	assert.Equal(t, testUserID, create.ActorID)
+	assert.Equal(t, int64(1), create.Version)
+	before, after := change(create, "title")
+	assert.Equal(t, "null", before)
+	assert.Equal(t, `"Write report"`, after)
+
+	assert.Equal(t, history.ActionUpdate, update.Action)
+	assert.Len(t, update.Changes, 2)
+	before, after = change(update, "title")
+	assert.Equal(t, `"Write report"`, before)
+	assert.Equal(t, `"Write the report"`, after)
+	before, after = change(update, "priority")
+	assert.Equal(t, `"Medium"`, before)
+	assert.Equal(t, `"High"`, after)
+
+	assert.Equal(t, history.ActionMove, move.Action)
+	assert.Equal(t, getTask(t, r, created.ID).Version, move.Version)
+	before, after = change(move, "status")
+	assert.Equal(t, `"TODO"`, before)
+	assert.Equal(t, `"In Progress"`, after)
+}
+
+func TestGetTaskHistoryHandler_SkipsWritesThatChangeNothing(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Same", "status": "TODO"})
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, created.ID, `{"title":"Same"}`).Code)
+
+	assert.Len(t, getHistory(t, r, created.ID), 1)
+}
+
+func TestGetTaskHistoryHandler_KeepsDeletedTasks(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, map[string]any{"title": "Parent", "status": "TODO"})
+	child := createSubtask(t, r, parent.ID, "Child")
+
+	w := makeJSONRequest(r, "DELETE", "/api/task/"+parent.ID+"?subtasks=cascade", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	for _, id := range []string{parent.ID, child.ID} {
+		events := getHistory(t, r, id)
+		assert.Len(t, events, 2)
+		assert.Equal(t, history.ActionDelete, events[0].Action)
+		before, after := change(events[0], "title")
+		assert.NotEqual(t, "null", before)
+		assert.Equal(t, "null", after)
+	}
+
+	w = makeJSONRequest(r, "GET", "/api/task/TASK-999/history", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetTaskHistoryHandler_FollowsRekey(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Errand", "status": "TODO"})
+	w := makeRekeyRequest(r, created.ID, "HOME")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var rekeyed task.Task
+	json.Unmarshal(w.Body.Bytes(), &rekeyed)
+
+	// The history moves with the task and the new key is part of it
+	events := getHistory(t, r, "HOME-001")
+	assert.Len(t, events, 2)
+	assert.Equal(t, history.ActionRekey, events[0].Action)
+	assert.Equal(t, rekeyed.Version, events[0].Version)
+	before, after := change(events[0], "id")
+	assert.Equal(t, `"TASK-001"`, before)
+	assert.Equal(t, `"HOME-001"`, after)
+	assert.Len(t, getHistory(t, r, created.ID), 2)
+}
+
+func TestGetTaskHistoryHandler_RecordsProjectDeletion(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	proj := createTestProject(t, r, "Garden")
+	inProject := createTestTask(t, r, map[string]any{"title": "Plant tulips", "project_id": proj.ID})
+
+	w := makeJSONRequest(r, "DELETE", fmt.Sprintf("/api/projects/%d", proj.ID), nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	events := getHistory(t, r, inProject.ID)
+	assert.Len(t, events, 2)
+	assert.Equal(t, history.ActionUpdate, events[0].Action)
+	before, after := change(events[0], "project_id")
+	assert.Equal(t, fmt.Sprint(proj.ID), before)
+	assert.Equal(t, "null", after)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"tasker/internal/idempotency"
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotentMethods are the methods whose requests may carry an Idempotency-Key
+var idempotentMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// recordingWriter keeps a copy of the response body as it is written
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// validateIdempotencyKey returns a problem with a key, or "" when it is usable
+func validateIdempotencyKey(key string) string {
+	if key == "" || len(key) > idempotency.MaxKeyLength {
+		return "Idempotency-Key must be 1-255 characters, such as a UUID"
+	}
+	if strings.IndexFunc(key, func(r rune) bool { return r < '!' || r > '~' }) >= 0 {
+		return "Idempotency-Key must be printable ASCII without spaces"
+	}
+	return ""
+}
+
+// Idempotency makes writes safe to retry. The first response to a request with
+// an Idempotency-Key header is kept for the caller for 24 hours and replayed to
+// every retry with the same key. Reusing a key for a different request is
+// refused with 422, and a retry that arrives while the first request is still
+// running gets 409. Server errors and 401/403 responses are not kept, so they
+// can be retried, e.g. with a token that has the missing scope. It must run
+// after RequireAuth.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys := c.Request.Header.Values(idempotency.Header)
+		if len(keys) == 0 || !slices.Contains(idempotentMethods, c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		key := keys[0]
+		problem := validateIdempotencyKey(key)
+		if len(keys) > 1 {
+			problem = "send a single Idempotency-Key"
+		}
+		if problem != "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"idempotency_key": problem}})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID := currentUserID(c)
+		fingerprint := idempotency.Fingerprint(c.Request.Method, c.Request.URL.RequestURI(), body)
+		existing, err := repository.IdempotencyKeys.ReserveKey(userID, key, fingerprint, idempotency.TTL, idempotency.LockTimeout)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check idempotency key"})
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			case existing.StatusCode == nil:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still in progress"})
+			default:
+				for name, value := range existing.Headers {
+					c.Header(name, value)
+				}
+				c.Header(idempotency.ReplayedHeader, "true")
+				c.Status(*existing.StatusCode)
+				c.Writer.Write(existing.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		// Give the key up if the handler panics. Once it has answered, the key
+		// is only given up for responses that changed nothing, because a
+		// retry would otherwise repeat a write that already happened.
+		answered := false
+		defer func() {
+			if !answered {
+				releaseIdempotencyKey(userID, key)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		answered = true
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusUnauthorized || status == http.StatusForbidden {
+			releaseIdempotencyKey(userID, key)
+			return
+		}
+
+		headers := make(idempotency.Headers)
+		for _, name := range idempotency.ReplayedHeaders {
+			if value := writer.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		// When this fails the key stays reserved, and retries get 409 until
+		// idempotency.LockTimeout has passed
+		if err := repository.IdempotencyKeys.CompleteKey(userID, key, status, headers, writer.body.Bytes()); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// releaseIdempotencyKey gives up a reservation so the request can be retried
+func releaseIdempotencyKey(userID int64, key string) {
+	if err := repository.IdempotencyKeys.ReleaseKey(userID, key); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tasker/internal/idempotency"
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeIdempotentRequest(r *gin.Engine, method string, path string, key string, body []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idempotency.Header, key)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestIdempotency_ReplaysRetry(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	body := []byte(`{"title": "Renew passport"}`)
+
+	first := makeIdempotentRequest(r, "POST", "/api/task", "key-1", body)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+
+	retry := makeIdempotentRequest(r, "POST", "/api/task", "key-1", body)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, first.Header().Get("ETag"), retry.Header().Get("ETag"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+
+	tasks, _ := mockRepo.GetAllTasks(testUserID)
+	assert.Len(t, tasks, 1)
+
+	// Another key is another request
+	w := makeIdempotentRequest(r, "POST", "/api/task", "key-2", body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	tasks, _ = mockRepo.GetAllTasks(testUserID)
+	assert.Len(t, tasks, 2)
+}
+
+func TestIdempotency_DifferentRequest(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	w := makeIdempotentRequest(r, "POST", "/api/task", "key-1", []byte(`{"title": "One"}`))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = makeIdempotentRequest(r, "POST", "/api/task", "key-1", []byte(`{"title": "Two"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = makeIdempotentRequest(r, "POST", "/api/projects", "key-1", []byte(`{"title": "One"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	tasks, _ := mockRepo.GetAllTasks(testUserID)
+	assert.Len(t, tasks, 1)
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	body := []byte(`{"title": "Slow"}`)
+	fingerprint := idempotency.Fingerprint("POST", "/api/task", body)
+	mockIdempotencyRepo.ReserveKey(testUserID, "key-1", fingerprint, idempotency.TTL, idempotency.LockTimeout)
+
+	w := makeIdempotentRequest(r, "POST", "/api/task", "key-1", body)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	tasks, _ := mockRepo.GetAllTasks(testUserID)
+	assert.Empty(t, tasks)
+}
+
+func TestIdempotency_FailuresAreNotKept(t *testing.T) {
+	tests := []struct {
+		name string
+		fail func(c *gin.Context)
+	}{
+		{"server error", func(c *gin.Context) { c.JSON(http.StatusInternalServerError, gin.H{"error": "try again"}) }},
+		{"panic", func(c *gin.Context) { panic("boom") }},
+		{"missing scope", func(c *gin.Context) { c.JSON(http.StatusForbidden, gin.H{"error": "token lacks scope"}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest()
+			defer tearDownTest()
+
+			r := setupTestRouter()
+			calls := 0
+			r.POST("/api/flaky", func(c *gin.Context) {
+				calls++
+				if calls == 1 {
+					tt.fail(c)
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"calls": calls})
+			})
+
+			w := makeIdempotentRequest(r, "POST", "/api/flaky", "key-1", nil)
+			assert.NotEqual(t, http.StatusOK, w.Code)
+
+			w = makeIdempotentRequest(r, "POST", "/api/flaky", "key-1", nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get(idempotency.ReplayedHeader))
+
+			w = makeIdempotentRequest(r, "POST", "/api/flaky", "key-1", nil)
+			assert.Equal(t, "true", w.Header().Get(idempotency.ReplayedHeader))
+			assert.Equal(t, 2, calls)
+		})
+	}
+}
+
+// failingCompleteRepository can't store responses
+type failingCompleteRepository struct {
+	*MockIdempotencyKeyRepository
+}
+
+func (failingCompleteRepository) CompleteKey(int64, string, int, idempotency.Headers, []byte) error {
+	return errors.New("connection reset")
+}
+
+func TestIdempotency_UnstoredWriteIsNotRepeated(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	repository.IdempotencyKeys = failingCompleteRepository{mockIdempotencyRepo}
+	body := []byte(`{"title": "Renew passport"}`)
+
+	w := makeIdempotentRequest(r, "POST", "/api/task", "key-1", body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// The task was created, so the key stays taken instead of running it again
+	w = makeIdempotentRequest(r, "POST", "/api/task", "key-1", body)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	tasks, _ := mockRepo.GetAllTasks(testUserID)
+	assert.Len(t, tasks, 1)
+}
+
+func TestIdempotency_AbandonedReservation(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	body := []byte(`{"title": "Renew passport"}`)
+	fingerprint := idempotency.Fingerprint("POST", "/api/task", body)
+	mockIdempotencyRepo.ReserveKey(testUserID, "key-1", fingerprint, idempotency.TTL, idempotency.LockTimeout)
+
+	// The request that reserved the key died with the server
+	id := idempotencyKeyID{testUserID, "key-1"}
+	record := mockIdempotencyRepo.keys[id]
+	record.CreatedAt = time.Now().Add(-idempotency.LockTimeout - time.Second)
+	mockIdempotencyRepo.keys[id] = record
+
+	w := makeIdempotentRequest(r, "POST", "/api/task", "key-1", body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(idempotency.ReplayedHeader))
+
+	w = makeIdempotentRequest(r, "POST", "/api/task", "key-1", body)
+	assert.Equal(t, "true", w.Header().Get(idempotency.ReplayedHeader))
+}
+
+func TestIdempotency_ExpiredKeyIsReused(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	w := makeIdempotentRequest(r, "POST", "/api/task", "key-1", []byte(`{"title": "One"}`))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	id := idempotencyKeyID{testUserID, "key-1"}
+	record := mockIdempotencyRepo.keys[id]
+	record.CreatedAt = time.Now().Add(-idempotency.TTL - time.Minute)
+	mockIdempotencyRepo.keys[id] = record
+
+	w = makeIdempotentRequest(r, "POST", "/api/task", "key-1", []byte(`{"title": "Two"}`))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(idempotency.ReplayedHeader))
+
+	purged, _ := mockIdempotencyRepo.PurgeKeys(idempotency.TTL)
+	assert.Equal(t, int64(0), purged)
+}
+
+func TestIdempotency_IgnoresReads(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	w := makeIdempotentRequest(r, "GET", "/api/task", "key-1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, mockIdempotencyRepo.keys)
+}
+
+func TestIdempotency_InvalidKey(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	for _, key := range []string{"has space", "ключ", string(bytes.Repeat([]byte("a"), 256))} {
+		w := makeIdempotentRequest(r, "POST", "/api/task", key, []byte(`{"title": "One"}`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "idempotency_key")
+	}
+
+	tasks, _ := mockRepo.GetAllTasks(testUserID)
+	assert.Empty(t, tasks)
+}
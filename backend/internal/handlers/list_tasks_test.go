@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	task "tasker/internal/Task"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func listTasks(t *testing.T, r *gin.Engine, query string) ([]task.Task, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/task"+query, nil)
+	r.ServeHTTP(w, req)
+
+	var tasks []task.Task
+	if w.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tasks))
+	}
+	return tasks, w
+}
+
+// listTaskPage fetches a page of tasks, for queries with limit or cursor
+func listTaskPage(t *testing.T, r *gin.Engine, query string) (taskPage, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/task"+query, nil)
+	r.ServeHTTP(w, req)
+
+	var page taskPage
+	if w.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	}
+	return page, w
+}
+
+func taskTitles(tasks []task.Task) []string {
+	titles := make([]string, len(tasks))
+	for i, t := range tasks {
+		titles[i] = t.Title
+	}
+	return titles
+}
+
+func seedListTasks(t *testing.T, r *gin.Engine) {
+	seed := []struct{ title, description, status, priority string }{
+		{"Pay rent", "Transfer before the first", "TODO", "High"},
+		{"Water plants", "Balcony and kitchen", "Done", "Low"},
+		{"Book dentist", "Annual checkup", "In Progress", "Medium"},
+		{"Renew passport", "Photos and the renewal form", "TODO", "Low"},
+	}
+	for _, s := range seed {
+		w := makePostRequest(r, marshalTaskBody(s.title, s.description, s.status, s.priority))
+		assert.Equal(t, http.StatusCreated, w.Code)
+		// Keep created_at distinct so the default order is deterministic
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func TestGetTaskHandler_DefaultOrderIsNewestFirst(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	seedListTasks(t, r)
+
+	tasks, w := listTasks(t, r, "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"Renew passport", "Book dentist", "Water plants", "Pay rent"}, taskTitles(tasks))
+	assert.NotContains(t, w.Body.String(), "next_cursor")
+}
+
+func TestGetTaskHandler_Filters(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	seedListTasks(t, r)
+
+	tests := []struct {
+		name   string
+		query  string
+		titles []string
+	}{
+		{"single status", "?status=TODO", []string{"Renew passport", "Pay rent"}},
+		{"comma-separated statuses", "?status=Done,In%20Progress", []string{"Book dentist", "Water plants"}},
+		{"repeated statuses", "?status=Done&status=In%20Progress", []string{"Book dentist", "Water plants"}},
+		{"status and priority", "?status=TODO&priority=Low", []string{"Renew passport"}},
+		{"created before everything", "?created_before=2000-01-01", []string{}},
+		{"created after now", "?created_after=" + time.Now().Add(time.Hour).Format(time.RFC3339), []string{}},
+		{"updated within range", "?updated_after=2000-01-01&updated_before=2999-01-01", []string{"Renew passport", "Book dentist", "Water plants", "Pay rent"}},
+		{"search title", "?q=rent", []string{"Pay rent"}},
+		{"search description", "?q=kitchen", []string{"Water plants"}},
+		{"search all words", "?q=renewal%20photos", []string{"Renew passport"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, w := listTasks(t, r, tt.query)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.titles, taskTitles(tasks))
+		})
+	}
+}
+
+func TestGetTaskHandler_Sort(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	seedListTasks(t, r)
+
+	tests := []struct {
+		query  string
+		titles []string
+	}{
+		{"?sort=created_at", []string{"Pay rent", "Water plants", "Book dentist", "Renew passport"}},
+		{"?sort=title", []string{"Book dentist", "Pay rent", "Renew passport", "Water plants"}},
+		// Ties fall back to the task id, in the same direction
+		{"?sort=-priority", []string{"Pay rent", "Book dentist", "Renew passport", "Water plants"}},
+		{"?sort=status", []string{"Pay rent", "Renew passport", "Book dentist", "Water plants"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			tasks, w := listTasks(t, r, tt.query)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.titles, taskTitles(tasks))
+		})
+	}
+}
+
+func TestGetTaskHandler_CursorPagination(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	seedListTasks(t, r)
+
+	var titles []string
+	query := "?sort=title&limit=3"
+	pages := 0
+	for {
+		page, w := listTaskPage(t, r, query)
+		assert.Equal(t, http.StatusOK, w.Code)
+		titles = append(titles, taskTitles(page.Tasks)...)
+		pages++
+
+		if page.NextCursor == nil {
+			break
+		}
+		query = "?sort=title&limit=3&cursor=" + *page.NextCursor
+	}
+
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, []string{"Book dentist", "Pay rent", "Renew passport", "Water plants"}, titles)
+}
+
+func TestGetTaskHandler_ExactPageHasNoCursor(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	seedListTasks(t, r)
+
+	page, w := listTaskPage(t, r, "?limit=4")
+
+	assert.Len(t, page.Tasks, 4)
+	assert.Contains(t, w.Body.String(), `"next_cursor":null`)
+}
+
+func TestGetTaskHandler_CursorFromDifferentSort(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	seedListTasks(t, r)
+
+	page, _ := listTaskPage(t, r, "?sort=title&limit=1")
+	if !assert.NotNil(t, page.NextCursor) {
+		return
+	}
+
+	_, w := listTasks(t, r, "?sort=-title&limit=1&cursor="+*page.NextCursor)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "cursor")
+}
+
+func TestGetTaskHandler_InvalidListParams(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	tests := []struct {
+		query string
+		field string
+	}{
+		{"?category=blocked", "category"},
+		{"?priority=Urgent", "priority"},
+		{"?sort=description", "sort"},
+		{"?sort=-user_id", "sort"},
+		{"?limit=0", "limit"},
+		{"?limit=501", "limit"},
+		{"?limit=ten", "limit"},
+		{"?cursor=not-a-cursor", "cursor"},
+		{"?created_after=yesterday", "created_after"},
+		{"?updated_before=2024-13-01", "updated_before"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, w := listTasks(t, r, tt.query)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response map[string]any
+			json.Unmarshal(w.Body.Bytes(), &response)
+			details, _ := response["details"].(map[string]any)
+			assert.Contains(t, details, tt.field)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"tasker/internal/apitoken"
+)
+
+type mockAPIToken struct {
+	token   apitoken.APIToken
+	hash    string
+	revoked bool
+}
+
+// MockAPITokenRepository is an in-memory implementation for testing
+type MockAPITokenRepository struct {
+	tokens map[int64]*mockAPIToken
+	nextID int64
+	mu     sync.RWMutex
+}
+
+func NewMockAPITokenRepository() *MockAPITokenRepository {
+	return &MockAPITokenRepository{
+		tokens: make(map[int64]*mockAPIToken),
+		nextID: 1,
+	}
+}
+
+func (m *MockAPITokenRepository) CreateToken(userID int64, t apitoken.APIToken, hash string) (*apitoken.APIToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t.ID = m.nextID
+	m.nextID++
+	t.UserID = userID
+	t.CreatedAt = time.Now()
+
+	m.tokens[t.ID] = &mockAPIToken{token: t, hash: hash}
+	return &t, nil
+}
+
+func (m *MockAPITokenRepository) ListTokens(userID int64) ([]apitoken.APIToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]apitoken.APIToken, 0)
+	for _, t := range m.tokens {
+		if t.token.UserID == userID && !t.revoked {
+			result = append(result, t.token)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockAPITokenRepository) GetActiveTokenByHash(hash string) (*apitoken.APIToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, t := range m.tokens {
+		if t.hash != hash || t.revoked {
+			continue
+		}
+		if t.token.ExpiresAt != nil && !t.token.ExpiresAt.After(time.Now()) {
+			continue
+		}
+		found := t.token
+		return &found, nil
+	}
+	return nil, fmt.Errorf("api token not found")
+}
+
+func (m *MockAPITokenRepository) RevokeToken(userID int64, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tokens[id]
+	if !ok || t.token.UserID != userID || t.revoked {
+		return fmt.Errorf("api token not found: %d", id)
+	}
+	t.revoked = true
+	return nil
+}
+
+func (m *MockAPITokenRepository) MarkTokenUsed(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t, ok := m.tokens[id]; ok {
+		now := time.Now()
+		t.token.LastUsedAt = &now
+	}
+	return nil
+}
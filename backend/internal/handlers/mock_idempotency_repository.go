@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"tasker/internal/idempotency"
+)
+
+// idempotencyKeyID identifies a key as used by one caller
+type idempotencyKeyID struct {
+	userID int64
+	key    string
+}
+
+// MockIdempotencyKeyRepository is an in-memory implementation for testing
+type MockIdempotencyKeyRepository struct {
+	keys map[idempotencyKeyID]idempotency.Record
+	mu   sync.Mutex
+}
+
+func NewMockIdempotencyKeyRepository() *MockIdempotencyKeyRepository {
+	return &MockIdempotencyKeyRepository{keys: make(map[idempotencyKeyID]idempotency.Record)}
+}
+
+func (m *MockIdempotencyKeyRepository) ReserveKey(userID int64, key string, fingerprint string, ttl time.Duration, lockTimeout time.Duration) (*idempotency.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := idempotencyKeyID{userID, key}
+	if existing, ok := m.keys[id]; ok {
+		age := time.Since(existing.CreatedAt)
+		if age <= ttl && (existing.StatusCode != nil || age <= lockTimeout) {
+			return &existing, nil
+		}
+	}
+
+	m.keys[id] = idempotency.Record{UserID: userID, Key: key, Fingerprint: fingerprint, CreatedAt: time.Now()}
+	return nil, nil
+}
+
+func (m *MockIdempotencyKeyRepository) CompleteKey(userID int64, key string, statusCode int, headers idempotency.Headers, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := idempotencyKeyID{userID, key}
+	if r, ok := m.keys[id]; ok {
+		r.StatusCode = &statusCode
+		r.Headers = headers
+		r.Body = body
+		m.keys[id] = r
+	}
+	return nil
+}
+
+func (m *MockIdempotencyKeyRepository) ReleaseKey(userID int64, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := idempotencyKeyID{userID, key}
+	if r, ok := m.keys[id]; ok && r.StatusCode == nil {
+		delete(m.keys, id)
+	}
+	return nil
+}
+
+func (m *MockIdempotencyKeyRepository) PurgeKeys(ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int64
+	for id, r := range m.keys {
+		if time.Since(r.CreatedAt) > ttl {
+			delete(m.keys, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"tasker/internal/history"
+	"tasker/internal/project"
+	"tasker/internal/repository"
+)
+
+// MockProjectRepository is an in-memory implementation for testing.
+// It shares the task mock so project deletion can cascade, orphan or move tasks.
+type MockProjectRepository struct {
+	projects map[int64]project.Project
+	tasks    *MockTaskRepository
+	nextID   int64
+	mu       sync.RWMutex
+}
+
+func NewMockProjectRepository(tasks *MockTaskRepository) *MockProjectRepository {
+	return &MockProjectRepository{
+		projects: make(map[int64]project.Project),
+		tasks:    tasks,
+		nextID:   1,
+	}
+}
+
+func (m *MockProjectRepository) GetAllProjects(userID int64) ([]project.Project, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]project.Project, 0, len(m.projects))
+	for _, p := range m.projects {
+		if p.UserID == userID {
+			result = append(result, p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (m *MockProjectRepository) GetProjectByID(userID int64, id int64) (*project.Project, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if p, ok := m.projects[id]; ok && p.UserID == userID {
+		return &p, nil
+	}
+	return nil, fmt.Errorf("project not found: %d", id)
+}
+
+func (m *MockProjectRepository) CreateProject(userID int64, p project.Project) (*project.Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	p.UserID = userID
+	p.ID = m.nextID
+	m.nextID++
+	p.CreatedAt = now
+	p.UpdatedAt = now
+
+	m.projects[p.ID] = p
+	return &p, nil
+}
+
+func (m *MockProjectRepository) UpdateProject(userID int64, id int64, p project.Project) (*project.Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.projects[id]
+	if !ok || existing.UserID != userID {
+		return nil, fmt.Errorf("project not found: %d", id)
+	}
+
+	if p.Name != "" {
+		existing.Name = p.Name
+	}
+	if p.Description != "" {
+		existing.Description = p.Description
+	}
+	if p.ColorHex != "" {
+		existing.ColorHex = p.ColorHex
+	}
+	existing.UpdatedAt = time.Now()
+
+	m.projects[id] = existing
+	return &existing, nil
+}
+
+func (m *MockProjectRepository) DeleteProject(userID int64, id int64, mode repository.ProjectDeleteMode, moveTo *int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if p, ok := m.projects[id]; !ok || p.UserID != userID {
+		return fmt.Errorf("project not found: %d", id)
+	}
+
+	m.tasks.mu.Lock()
+	defer m.tasks.mu.Unlock()
+
+	m.tasks.begin()
+	now := time.Now()
+	for taskID, t := range m.tasks.tasks {
+		if t.ProjectID == nil || *t.ProjectID != id {
+			continue
+		}
+		switch mode {
+		case repository.ProjectDeleteCascade:
+			m.tasks.record(userID, history.ActionDelete, &t, nil)
+			m.tasks.moveToTrash(taskID, now)
+			// Subtasks outside the project are promoted
+			for childID, child := range m.tasks.tasks {
+				if child.ParentID != nil && *child.ParentID == taskID {
+					child.ParentID = nil
+					m.tasks.tasks[childID] = child
+				}
+			}
+		case repository.ProjectDeleteOrphan:
+			before := t
+			t.ProjectID = nil
+			t.Version++
+			m.tasks.tasks[taskID] = t
+			m.tasks.record(userID, history.ActionUpdate, &before, &t)
+		case repository.ProjectDeleteMove:
+			before := t
+			target := *moveTo
+			t.ProjectID = &target
+			t.Version++
+			m.tasks.tasks[taskID] = t
+			m.tasks.record(userID, history.ActionUpdate, &before, &t)
+		}
+	}
+
+	// Tasks in the trash lose the project through ON DELETE SET NULL
+	for taskID, t := range m.tasks.trash {
+		if t.ProjectID != nil && *t.ProjectID == id {
+			t.ProjectID = nil
+			m.tasks.trash[taskID] = t
+		}
+	}
+
+	delete(m.projects, id)
+	return nil
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"tasker/internal/history"
+	"tasker/internal/tag"
+)
+
+// MockTagRepository is an in-memory implementation for testing.
+// It shares the task mock so renames and deletes reach the tasks using a tag.
+type MockTagRepository struct {
+	tags   map[int64]tag.Tag
+	tasks  *MockTaskRepository
+	nextID int64
+	mu     sync.RWMutex
+}
+
+func NewMockTagRepository(tasks *MockTaskRepository) *MockTagRepository {
+	m := &MockTagRepository{
+		tags:   make(map[int64]tag.Tag),
+		tasks:  tasks,
+		nextID: 1,
+	}
+	tasks.tags = m
+	return m
+}
+
+// sortTagNames orders names the way the task query aggregates them
+func sortTagNames(names []string) {
+	slices.SortFunc(names, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+}
+
+// findByName returns the user's tag matching name in any case; callers must hold the lock
+func (m *MockTagRepository) findByName(userID int64, name string) (tag.Tag, bool) {
+	for _, t := range m.tags {
+		if t.UserID == userID && strings.EqualFold(t.Name, name) {
+			return t, true
+		}
+	}
+	return tag.Tag{}, false
+}
+
+// ensureTags creates any missing tags and returns the stored spelling of each name
+func (m *MockTagRepository) ensureTags(userID int64, names []string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		existing, ok := m.findByName(userID, name)
+		if !ok {
+			now := time.Now()
+			existing = tag.Tag{ID: m.nextID, UserID: userID, Name: name, ColorHex: "#6B7280", CreatedAt: now, UpdatedAt: now}
+			m.nextID++
+			m.tags[existing.ID] = existing
+		}
+		result = append(result, existing.Name)
+	}
+	sortTagNames(result)
+	return result
+}
+
+// withCount fills in TaskCount; callers must hold the lock
+func (m *MockTagRepository) withCount(t tag.Tag) tag.Tag {
+	m.tasks.mu.RLock()
+	defer m.tasks.mu.RUnlock()
+
+	t.TaskCount = 0
+	for _, tk := range m.tasks.tasks {
+		if tk.UserID == t.UserID && slices.Contains(tk.Tags, t.Name) {
+			t.TaskCount++
+		}
+	}
+	return t
+}
+
+func (m *MockTagRepository) GetAllTags(userID int64) ([]tag.Tag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]tag.Tag, 0, len(m.tags))
+	for _, t := range m.tags {
+		if t.UserID == userID {
+			result = append(result, m.withCount(t))
+		}
+	}
+	slices.SortFunc(result, func(a, b tag.Tag) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return result, nil
+}
+
+func (m *MockTagRepository) GetTagByID(userID int64, id int64) (*tag.Tag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if t, ok := m.tags[id]; ok && t.UserID == userID {
+		t = m.withCount(t)
+		return &t, nil
+	}
+	return nil, fmt.Errorf("tag not found: %d", id)
+}
+
+func (m *MockTagRepository) CreateTag(userID int64, t tag.Tag) (*tag.Tag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.findByName(userID, t.Name); ok {
+		return nil, fmt.Errorf("tag already exists: %s", t.Name)
+	}
+
+	now := time.Now()
+	t.UserID = userID
+	t.ID = m.nextID
+	m.nextID++
+	t.TaskCount = 0
+	t.CreatedAt = now
+	t.UpdatedAt = now
+
+	m.tags[t.ID] = t
+	return &t, nil
+}
+
+// retagTasks swaps oldName for newName on every task of the user, or drops it
+// when newName is empty, recording the change in each task's history
+func (m *MockTagRepository) retagTasks(userID int64, oldName, newName string) {
+	m.tasks.mu.Lock()
+	defer m.tasks.mu.Unlock()
+
+	m.tasks.begin()
+	for _, id := range slices.Sorted(maps.Keys(m.tasks.tasks)) {
+		t := m.tasks.tasks[id]
+		i := slices.Index(t.Tags, oldName)
+		if t.UserID != userID || i < 0 {
+			continue
+		}
+		before := m.tasks.withComputed(t)
+		tags := slices.Clone(t.Tags)
+		if newName == "" {
+			tags = slices.Delete(tags, i, i+1)
+		} else {
+			tags[i] = newName
+			sortTagNames(tags)
+		}
+		t.Tags = tags
+		t.Version++
+		m.tasks.tasks[id] = t
+		after := m.tasks.withComputed(t)
+		m.tasks.record(userID, history.ActionUpdate, &before, &after)
+	}
+}
+
+func (m *MockTagRepository) UpdateTag(userID int64, id int64, t tag.Tag) (*tag.Tag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.tags[id]
+	if !ok || existing.UserID != userID {
+		return nil, fmt.Errorf("tag not found: %d", id)
+	}
+
+	if t.Name != "" && t.Name != existing.Name {
+		if other, ok := m.findByName(userID, t.Name); ok && other.ID != id {
+			return nil, fmt.Errorf("tag already exists: %s", t.Name)
+		}
+		m.retagTasks(userID, existing.Name, t.Name)
+		existing.Name = t.Name
+	}
+	if t.ColorHex != "" {
+		existing.ColorHex = t.ColorHex
+	}
+	existing.UpdatedAt = time.Now()
+
+	m.tags[id] = existing
+	existing = m.withCount(existing)
+	return &existing, nil
+}
+
+func (m *MockTagRepository) DeleteTag(userID int64, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.tags[id]
+	if !ok || existing.UserID != userID {
+		return fmt.Errorf("tag not found: %d", id)
+	}
+
+	m.retagTasks(userID, existing.Name, "")
+	delete(m.tags, id)
+	return nil
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"tasker/internal/template"
+)
+
+// MockTemplateRepository is an in-memory implementation for testing
+type MockTemplateRepository struct {
+	templates map[int64]template.Template
+	nextID    int64
+	mu        sync.RWMutex
+}
+
+func NewMockTemplateRepository() *MockTemplateRepository {
+	return &MockTemplateRepository{
+		templates: make(map[int64]template.Template),
+		nextID:    1,
+	}
+}
+
+// nameTaken reports whether another of the user's templates has name in any case; callers must hold the lock
+func (m *MockTemplateRepository) nameTaken(userID int64, id int64, name string) bool {
+	for _, t := range m.templates {
+		if t.UserID == userID && t.ID != id && strings.EqualFold(t.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MockTemplateRepository) GetAllTemplates(userID int64) ([]template.Template, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]template.Template, 0, len(m.templates))
+	for _, t := range m.templates {
+		if t.UserID == userID {
+			result = append(result, t)
+		}
+	}
+	slices.SortFunc(result, func(a, b template.Template) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return result, nil
+}
+
+func (m *MockTemplateRepository) GetTemplateByID(userID int64, id int64) (*template.Template, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if t, ok := m.templates[id]; ok && t.UserID == userID {
+		return &t, nil
+	}
+	return nil, fmt.Errorf("template not found: %d", id)
+}
+
+func (m *MockTemplateRepository) CreateTemplate(userID int64, t template.Template) (*template.Template, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.nameTaken(userID, 0, t.Name) {
+		return nil, fmt.Errorf("template already exists: %s", t.Name)
+	}
+
+	now := time.Now()
+	t.UserID = userID
+	t.ID = m.nextID
+	m.nextID++
+	t.CreatedAt = now
+	t.UpdatedAt = now
+
+	m.templates[t.ID] = t
+	return &t, nil
+}
+
+func (m *MockTemplateRepository) UpdateTemplate(userID int64, id int64, t template.Template) (*template.Template, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.templates[id]
+	if !ok || existing.UserID != userID {
+		return nil, fmt.Errorf("template not found: %d", id)
+	}
+	if m.nameTaken(userID, id, t.Name) {
+		return nil, fmt.Errorf("template already exists: %s", t.Name)
+	}
+
+	t.ID = id
+	t.UserID = userID
+	t.CreatedAt = existing.CreatedAt
+	t.UpdatedAt = time.Now()
+
+	m.templates[id] = t
+	return &t, nil
+}
+
+func (m *MockTemplateRepository) DeleteTemplate(userID int64, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.templates[id]
+	if !ok || existing.UserID != userID {
+		return fmt.Errorf("template not found: %d", id)
+	}
+
+	delete(m.templates, id)
+	return nil
+}
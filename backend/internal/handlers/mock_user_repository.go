@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"tasker/internal/user"
+)
+
+// MockUserRepository is an in-memory implementation for testing
+type MockUserRepository struct {
+	users   map[int64]user.User
+	revoked map[string]time.Time
+	nextID  int64
+	mu      sync.RWMutex
+}
+
+func NewMockUserRepository() *MockUserRepository {
+	return &MockUserRepository{
+		users:   make(map[int64]user.User),
+		revoked: make(map[string]time.Time),
+		nextID:  1,
+	}
+}
+
+func (m *MockUserRepository) CreateUser(u user.User) (*user.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.users {
+		if strings.EqualFold(existing.Email, u.Email) {
+			return nil, fmt.Errorf("email already registered: %s", u.Email)
+		}
+	}
+
+	now := time.Now()
+	if u.Timezone == "" {
+		u.Timezone = "UTC"
+	}
+	u.ID = m.nextID
+	m.nextID++
+	u.CreatedAt = now
+	u.UpdatedAt = now
+
+	m.users[u.ID] = u
+	return &u, nil
+}
+
+func (m *MockUserRepository) GetUserByID(id int64) (*user.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if u, ok := m.users[id]; ok {
+		return &u, nil
+	}
+	return nil, fmt.Errorf("user not found: %d", id)
+}
+
+func (m *MockUserRepository) GetUserByEmail(email string) (*user.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.users {
+		if strings.EqualFold(u.Email, strings.TrimSpace(email)) {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found: %s", email)
+}
+
+func (m *MockUserRepository) UpdateUser(u user.User) (*user.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.users[u.ID]
+	if !ok {
+		return nil, fmt.Errorf("user not found: %d", u.ID)
+	}
+
+	existing.Name = u.Name
+	existing.Timezone = u.Timezone
+	existing.UpdatedAt = time.Now()
+
+	m.users[u.ID] = existing
+	return &existing, nil
+}
+
+func (m *MockUserRepository) RevokeToken(jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.revoked[jti] = expiresAt
+	return nil
+}
+
+func (m *MockUserRepository) IsTokenRevoked(jti string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.revoked[jti]
+	return ok, nil
+}
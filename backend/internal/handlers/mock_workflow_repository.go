@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+	"tasker/internal/repository"
+	"tasker/internal/workflow"
+)
+
+// MockWorkflowRepository is an in-memory implementation for testing.
+// It shares the task mock so saving a workflow reaches the tasks on the board.
+type MockWorkflowRepository struct {
+	workflows map[workflowKey]workflow.Workflow
+	tasks     *MockTaskRepository
+	nextID    int64
+	mu        sync.RWMutex
+}
+
+// workflowKey identifies a board; project 0 is the user's default board
+type workflowKey struct {
+	userID, projectID int64
+}
+
+func NewMockWorkflowRepository(tasks *MockTaskRepository) *MockWorkflowRepository {
+	m := &MockWorkflowRepository{
+		workflows: make(map[workflowKey]workflow.Workflow),
+		tasks:     tasks,
+		nextID:    1,
+	}
+	tasks.workflows = m
+	return m
+}
+
+func boardKey(userID int64, projectID *int64) workflowKey {
+	if projectID == nil {
+		return workflowKey{userID: userID}
+	}
+	return workflowKey{userID: userID, projectID: *projectID}
+}
+
+func (m *MockWorkflowRepository) GetWorkflow(userID int64, projectID *int64) (*workflow.Workflow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	w := m.boardWorkflow(userID, projectID)
+	return &w, nil
+}
+
+// boardWorkflow returns the workflow of a project's board with GetWorkflow's
+// fallbacks. Workflows are only written while holding the task lock as well,
+// so callers may hold either lock.
+func (m *MockWorkflowRepository) boardWorkflow(userID int64, projectID *int64) workflow.Workflow {
+	if w, ok := m.workflows[boardKey(userID, projectID)]; ok {
+		return w
+	}
+	if w, ok := m.workflows[boardKey(userID, nil)]; ok {
+		return w
+	}
+	return workflow.Default()
+}
+
+// onBoard reports whether a task belongs to the board of projectID; callers must hold the lock
+func (m *MockWorkflowRepository) onBoard(userID int64, projectID *int64, t task.Task) bool {
+	if t.UserID != userID {
+		return false
+	}
+	if projectID != nil {
+		return t.ProjectID != nil && *t.ProjectID == *projectID
+	}
+	if t.ProjectID == nil {
+		return true
+	}
+	_, own := m.workflows[boardKey(userID, t.ProjectID)]
+	return !own
+}
+
+func (m *MockWorkflowRepository) SaveWorkflow(userID int64, w workflow.Workflow) (*workflow.Workflow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tasks.mu.Lock()
+	defer m.tasks.mu.Unlock()
+
+	key := boardKey(userID, w.ProjectID)
+	previous, exists := m.workflows[key]
+
+	// Check the board as it will be once this workflow is in place
+	m.workflows[key] = w
+	var stranded []string
+	for _, t := range m.tasks.tasks {
+		if m.onBoard(userID, w.ProjectID, t) && w.Category(t.Status) == "" && !slices.Contains(stranded, t.Status) {
+			stranded = append(stranded, t.Status)
+		}
+	}
+	if len(stranded) > 0 {
+		if exists {
+			m.workflows[key] = previous
+		} else {
+			delete(m.workflows, key)
+		}
+		slices.Sort(stranded)
+		return nil, &repository.StatusInUseError{Statuses: stranded}
+	}
+
+	now := time.Now()
+	w.UserID = userID
+	if exists {
+		w.ID, w.CreatedAt = previous.ID, previous.CreatedAt
+	} else {
+		w.ID, w.CreatedAt = m.nextID, now
+		m.nextID++
+	}
+	w.UpdatedAt = now
+	m.workflows[key] = w
+
+	m.tasks.begin()
+	for id, t := range m.tasks.tasks {
+		if category := w.Category(t.Status); m.onBoard(userID, w.ProjectID, t) && category != t.StatusCategory {
+			before := t
+			t.StatusCategory = category
+			t.Version++
+			m.tasks.tasks[id] = t
+			m.tasks.markDone(&before, t, now)
+
+			from, _ := json.Marshal(before.StatusCategory)
+			to, _ := json.Marshal(category)
+			m.tasks.recordEvent(userID, id, history.ActionWorkflow, history.Changes{"status_category": {Before: from, After: to}}, t.Version)
+		}
+	}
+
+	return &w, nil
+}
+
+func (m *MockWorkflowRepository) CountTasksByStatus(userID int64, projectID *int64) (map[string]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.tasks.mu.RLock()
+	defer m.tasks.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, t := range m.tasks.tasks {
+		if m.onBoard(userID, projectID, t) && t.ArchivedAt == nil {
+			counts[t.Status]++
+		}
+	}
+	return counts, nil
+}
+
+func (m *MockWorkflowRepository) GetBoardTasks(userID int64, projectID *int64) ([]task.Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.tasks.mu.RLock()
+	defer m.tasks.mu.RUnlock()
+
+	tasks := []task.Task{}
+	for _, t := range m.tasks.tasks {
+		if m.onBoard(userID, projectID, t) && t.ArchivedAt == nil {
+			tasks = append(tasks, m.tasks.withComputed(t))
+		}
+	}
+	slices.SortFunc(tasks, func(a, b task.Task) int {
+		return cmp.Or(strings.Compare(a.Rank, b.Rank), strings.Compare(a.ID, b.ID))
+	})
+	return tasks, nil
+}
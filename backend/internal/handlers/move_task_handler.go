@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// moveTaskRequest is the POST /api/task/:id/move body. The card lands in status
+// (its current one when empty) right after after_id, or right before
+// before_id; with neither it goes to the bottom of the column.
+type moveTaskRequest struct {
+	Status   string `json:"status"`
+	AfterID  string `json:"after_id"`
+	BeforeID string `json:"before_id"`
+}
+
+// MoveTaskHandler handles POST /api/task/:id/move requests from drag and drop,
+// setting a task's status and its place in the column in one write. An
+// archived task that is moved goes back on the board.
+func MoveTaskHandler(c *gin.Context) {
+	taskID := c.Param("id")
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+
+	var req moveTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	existing, err := repository.Tasks.GetTaskByID(currentUserID(c), taskID)
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+		return
+	}
+	if req.Status == "" {
+		req.Status = existing.Status
+	}
+
+	wf, ok := loadWorkflow(c, existing.ProjectID)
+	if !ok {
+		return
+	}
+
+	validationErrors := make(map[string]string)
+	validateStatus(wf, req.Status, validationErrors)
+	validateTransition(wf, existing.Status, req.Status, validationErrors)
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	category := wf.Category(req.Status)
+	var limit *repository.WIPLimit
+	if req.Status != existing.Status || existing.ArchivedAt != nil {
+		if limit, ok = wipLimit(c, wf, existing.ID, req.Status); !ok {
+			return
+		}
+	}
+
+	moved, err := repository.Tasks.MoveTask(currentUserID(c), existing.ID, repository.TaskMove{
+		Status:   req.Status,
+		Category: category,
+		AfterID:  req.AfterID,
+		BeforeID: req.BeforeID,
+		Version:  version,
+		Limit:    limit,
+	})
+	if err != nil {
+		if respondIfBlocked(c, err) || respondIfOverLimit(c, err) {
+			return
+		}
+		switch msg := err.Error(); {
+		case strings.Contains(msg, "task not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		case strings.Contains(msg, "task version conflict"):
+			respondPreconditionFailed(c, taskID)
+		case strings.Contains(msg, "after task not in column"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"after_id": "task is not in the target column"}})
+		case strings.Contains(msg, "before task not in column"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"before_id": "task is not in the target column"}})
+		case strings.Contains(msg, "move neighbours out of order"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"before_id": "before_id must come after after_id in the column"}})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to move task"})
+		}
+		return
+	}
+
+	c.Header("ETag", taskETag(moved))
+	c.JSON(http.StatusOK, moved)
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	task "tasker/internal/Task"
+	"tasker/internal/rank"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func moveTask(r *gin.Engine, id string, body string) *httptest.ResponseRecorder {
+	return makeJSONRequest(r, "POST", "/api/task/"+id+"/move", []byte(body))
+}
+
+// columnTitles returns the titles of a board column's cards in order
+func columnTitles(t *testing.T, r *gin.Engine, status string) []string {
+	for _, col := range getBoard(t, r, "").Columns {
+		if col.Name == status {
+			return taskTitles(col.Tasks)
+		}
+	}
+	t.Fatalf("no column %q", status)
+	return nil
+}
+
+func TestBoard_NewTasksGoOnTop(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	createTestTask(t, r, map[string]any{"title": "First", "status": "TODO"})
+	createTestTask(t, r, map[string]any{"title": "Second", "status": "TODO"})
+	createTestTask(t, r, map[string]any{"title": "Started", "status": "In Progress"})
+	createTestTask(t, r, map[string]any{"title": "Third", "status": "TODO"})
+
+	assert.Equal(t, []string{"Third", "Second", "First"}, columnTitles(t, r, "TODO"))
+	assert.Equal(t, []string{"Started"}, columnTitles(t, r, "In Progress"))
+}
+
+func TestMoveTaskHandler_ReordersWithinColumn(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	a := createTestTask(t, r, map[string]any{"title": "A", "status": "TODO"})
+	b := createTestTask(t, r, map[string]any{"title": "B", "status": "TODO"})
+	c := createTestTask(t, r, map[string]any{"title": "C", "status": "TODO"})
+	// Board order is C, B, A
+
+	w := moveTask(r, c.ID, `{"after_id":"`+a.ID+`"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var moved task.Task
+	json.Unmarshal(w.Body.Bytes(), &moved)
+	assert.Equal(t, "TODO", moved.Status)
+	assert.Greater(t, moved.Version, c.Version)
+	assert.Equal(t, taskETag(&moved), w.Header().Get("ETag"))
+	assert.Equal(t, []string{"B", "A", "C"}, columnTitles(t, r, "TODO"))
+
+	assert.Equal(t, http.StatusOK, moveTask(r, a.ID, `{"before_id":"`+b.ID+`"}`).Code)
+	assert.Equal(t, []string{"A", "B", "C"}, columnTitles(t, r, "TODO"))
+
+	assert.Equal(t, http.StatusOK, moveTask(r, c.ID, `{"after_id":"`+a.ID+`","before_id":"`+b.ID+`"}`).Code)
+	assert.Equal(t, []string{"A", "C", "B"}, columnTitles(t, r, "TODO"))
+
+	// Without neighbours the card goes to the bottom
+	assert.Equal(t, http.StatusOK, moveTask(r, a.ID, `{}`).Code)
+	assert.Equal(t, []string{"C", "B", "A"}, columnTitles(t, r, "TODO"))
+}
+
+func TestMoveTaskHandler_ChangesColumn(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	todo := createTestTask(t, r, map[string]any{"title": "Todo", "status": "TODO"})
+	first := createTestTask(t, r, map[string]any{"title": "First", "status": "In Progress"})
+	second := createTestTask(t, r, map[string]any{"title": "Second", "status": "In Progress"})
+	// In Progress is Second, First
+
+	w := moveTask(r, todo.ID, `{"status":"In Progress","after_id":"`+second.ID+`","before_id":"`+first.ID+`"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var moved task.Task
+	json.Unmarshal(w.Body.Bytes(), &moved)
+	assert.Equal(t, "In Progress", moved.Status)
+	assert.Equal(t, "active", moved.StatusCategory)
+
+	assert.Equal(t, []string{"Second", "Todo", "First"}, columnTitles(t, r, "In Progress"))
+	assert.Empty(t, columnTitles(t, r, "TODO"))
+
+	// Changing status with PATCH puts the card on top of its new column
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, first.ID, `{"status":"TODO"}`).Code)
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, second.ID, `{"status":"TODO"}`).Code)
+	assert.Equal(t, []string{"Second", "First"}, columnTitles(t, r, "TODO"))
+}
+
+func TestMoveTaskHandler_Errors(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	a := createTestTask(t, r, map[string]any{"title": "A", "status": "TODO"})
+	b := createTestTask(t, r, map[string]any{"title": "B", "status": "TODO"})
+	started := createTestTask(t, r, map[string]any{"title": "Started", "status": "In Progress"})
+
+	tests := []struct {
+		name  string
+		id    string
+		body  string
+		code  int
+		field string
+	}{
+		{"unknown task", "TASK-999", `{}`, http.StatusNotFound, ""},
+		{"invalid JSON", a.ID, `{`, http.StatusBadRequest, ""},
+		{"unknown status", a.ID, `{"status":"Blocked"}`, http.StatusBadRequest, "status"},
+		{"neighbour in another column", a.ID, `{"after_id":"` + started.ID + `"}`, http.StatusBadRequest, "after_id"},
+		{"missing neighbour", a.ID, `{"before_id":"TASK-999"}`, http.StatusBadRequest, "before_id"},
+		{"next to itself", a.ID, `{"after_id":"` + a.ID + `"}`, http.StatusBadRequest, "after_id"},
+		// B is above A, so nothing fits after A and before B
+		{"neighbours out of order", started.ID, `{"status":"TODO","after_id":"` + a.ID + `","before_id":"` + b.ID + `"}`, http.StatusBadRequest, "before_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := moveTask(r, tt.id, tt.body)
+			assert.Equal(t, tt.code, w.Code)
+
+			if tt.field != "" {
+				var resp map[string]any
+				json.Unmarshal(w.Body.Bytes(), &resp)
+				details := resp["details"].(map[string]any)
+				assert.Contains(t, details, tt.field)
+			}
+		})
+	}
+
+	assert.Equal(t, "In Progress", getTask(t, r, started.ID).Status)
+
+	w := makeConditionalRequest(r, "POST", "/api/task/"+a.ID+"/move", `"99"`, []byte(`{}`))
+	assertPreconditionFailed(t, w, a.Version)
+}
+
+func TestMoveTaskHandler_RespectsWorkflowRules(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	assert.Equal(t, http.StatusOK, putWorkflow(r, "", limitedWorkflow).Code)
+	createTestTask(t, r, map[string]any{"title": "Build", "status": "In Progress"})
+	createTestTask(t, r, map[string]any{"title": "Test", "status": "In Progress"})
+	waiting := createTestTask(t, r, map[string]any{"title": "Deploy", "status": "TODO"})
+	blocker := createTestTask(t, r, map[string]any{"title": "Approve", "status": "TODO"})
+	assert.Equal(t, http.StatusOK, addDependency(r, waiting.ID, blocker.ID).Code)
+
+	assert.Equal(t, http.StatusConflict, moveTask(r, waiting.ID, `{"status":"In Progress"}`).Code)
+	assert.Equal(t, http.StatusConflict, moveTask(r, waiting.ID, `{"status":"Done"}`).Code)
+	assert.Equal(t, "TODO", getTask(t, r, waiting.ID).Status)
+}
+
+func TestMoveTaskHandler_RebalancesQuietly(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	bottom := createTestTask(t, r, map[string]any{"title": "Bottom", "status": "TODO"})
+	top := createTestTask(t, r, map[string]any{"title": "Top", "status": "TODO"})
+
+	// Dropping cards into the same gap over and over uses the gap up
+	var titles []string
+	for i := range 200 {
+		title := "Card " + string(rune('A'+i%26)) + string(rune('a'+i/26))
+		created := createTestTask(t, r, map[string]any{"title": title, "status": "TODO"})
+		assert.Equal(t, http.StatusOK, moveTask(r, created.ID, `{"after_id":"`+top.ID+`","before_id":"`+bottom.ID+`"}`).Code)
+		titles = append([]string{title}, titles...)
+	}
+
+	want := append(append([]string{"Top"}, titles...), "Bottom")
+	assert.Equal(t, want, columnTitles(t, r, "TODO"))
+
+	for _, col := range getBoard(t, r, "").Columns {
+		for _, card := range col.Tasks {
+			assert.LessOrEqual(t, len(card.Rank), rank.MaxLength)
+		}
+	}
+
+	// Spreading the column out again isn't an edit anyone needs to see
+	assert.Equal(t, top.Version, getTask(t, r, top.ID).Version)
+	assert.Equal(t, bottom.Version, getTask(t, r, bottom.ID).Version)
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	task "tasker/internal/Task"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// originalTask holds the fields of a task before a patch
+var originalTask = map[string]any{"title": "Original Title", "description": "Original Description", "status": "In Progress", "priority": "Medium"}
+
+func makePatchRequest(r *gin.Engine, id string, body string) *httptest.ResponseRecorder {
+	return makeJSONRequest(r, "PATCH", "/api/task/"+id, []byte(body))
+}
+
+func TestPatchTaskHandler_MissingKeysAreUntouched(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, originalTask)
+
+	time.Sleep(10 * time.Millisecond)
+
+	w := makePatchRequest(r, created.ID, `{"status": "Done"}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response task.Task
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "Original Title", response.Title)
+	assert.Equal(t, "Original Description", response.Description)
+	assert.Equal(t, "Done", response.Status)
+	assert.Equal(t, "Medium", response.Priority)
+	assert.True(t, response.UpdatedAt.After(created.UpdatedAt))
+}
+
+func TestPatchTaskHandler_MultipleFields(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, originalTask)
+
+	w := makePatchRequest(r, created.ID, `{"title": "Updated Title", "priority": "High"}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response task.Task
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "Updated Title", response.Title)
+	assert.Equal(t, "High", response.Priority)
+	assert.Equal(t, "In Progress", response.Status)
+}
+
+func TestPatchTaskHandler_NullClearsDescription(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, originalTask)
+
+	w := makePatchRequest(r, created.ID, `{"description": null}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response task.Task
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "", response.Description)
+	assert.Equal(t, "Original Title", response.Title)
+}
+
+func TestPatchTaskHandler_EmptyStringClearsDescription(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, originalTask)
+
+	w := makePatchRequest(r, created.ID, `{"description": ""}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response task.Task
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "", response.Description)
+}
+
+func TestPatchTaskHandler_ProjectID(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	home := createTestProject(t, r, "Home")
+	created := createTestTask(t, r, map[string]any{"title": "Test Task", "project_id": home.ID})
+
+	// Unrelated fields leave the project alone
+	w := makePatchRequest(r, created.ID, `{"status": "Done"}`)
+	var response task.Task
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, &home.ID, response.ProjectID)
+
+	// null detaches the task
+	w = makePatchRequest(r, created.ID, `{"project_id": null}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Nil(t, response.ProjectID)
+
+	w = makePatchRequest(r, created.ID, `{"project_id": 999}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "project does not exist")
+}
+
+func TestPatchTaskHandler_ValidationErrors(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, originalTask)
+
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"null title", `{"title": null}`, "title"},
+		{"blank title", `{"title": "   "}`, "title"},
+		{"invalid status", `{"status": "Blocked"}`, "status"},
+		{"null status", `{"status": null}`, "status"},
+		{"null priority", `{"priority": null}`, "priority"},
+		{"non-string description", `{"description": 42}`, "description"},
+		{"non-numeric project", `{"project_id": "home"}`, "project_id"},
+		{"read-only field", `{"id": "TASK-999"}`, "id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := makePatchRequest(r, created.ID, tt.body)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response map[string]any
+			json.Unmarshal(w.Body.Bytes(), &response)
+
+			assert.Equal(t, "validation failed", response["error"])
+			details, ok := response["details"].(map[string]any)
+			assert.True(t, ok)
+			assert.Len(t, details, 1)
+			assert.NotNil(t, details[tt.field])
+		})
+	}
+
+	stored, _ := mockRepo.GetTaskByID(testUserID, created.ID)
+	assert.Equal(t, created.Title, stored.Title)
+	assert.Equal(t, created.Status, stored.Status)
+}
+
+func TestPatchTaskHandler_InvalidJSON(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, originalTask)
+
+	for _, body := range []string{"invalid json", `["title"]`, "null"} {
+		w := makePatchRequest(r, created.ID, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestPatchTaskHandler_TaskNotFound(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	w := makePatchRequest(r, "NON-EXISTENT", `{"status": "Done"}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPatchTaskHandler_ByAlias(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, originalTask)
+	rekeyed, _ := mockRepo.RekeyTask(testUserID, created.ID, "HOME", 0)
+
+	w := makePatchRequest(r, created.ID, `{"status": "Done"}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response task.Task
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, rekeyed.ID, response.ID)
+	assert.Equal(t, "Done", response.Status)
+}
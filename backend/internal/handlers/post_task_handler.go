@@ -1,86 +1,124 @@
 package handlers
 
 import (
-	"fmt"
+	"maps"
 	"net/http"
+	"regexp"
 	"slices"
 	"strings"
 
 	task "tasker/internal/Task"
 	"tasker/internal/repository"
+	"tasker/internal/workflow"
 
 	"github.com/gin-gonic/gin"
 )
 
-var (
-	nextID int = 1
-)
+// taskKeyPrefixPattern allows short upper-case prefixes such as TASK, HOME or Q3
+var taskKeyPrefixPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,19}$`)
 
-// InitTaskIDGenerator initializes the ID generator from existing tasks
-func InitTaskIDGenerator(existingTasks []task.Task) {
-	for _, t := range existingTasks {
-		var id int
-		fmt.Sscanf(t.ID, "TASK-%d", &id)
-		if id >= nextID {
-			nextID = id + 1
-		}
+// createTaskRequest is the POST /api/task body: a task plus the key prefix to file it under
+type createTaskRequest struct {
+	task.Task
+	Prefix string `json:"prefix"`
+}
+
+// normalizeKeyPrefix upper-cases a requested prefix, falling back to the default one
+func normalizeKeyPrefix(prefix string) string {
+	prefix = strings.ToUpper(strings.TrimSpace(prefix))
+	if prefix == "" {
+		return task.DefaultKeyPrefix
 	}
+	return prefix
 }
 
-func generateNextID() string {
-	id := fmt.Sprintf("TASK-%03d", nextID)
-	nextID++
-	return id
+func validateKeyPrefix(prefix string) map[string]string {
+	errors := make(map[string]string)
+
+	if !taskKeyPrefixPattern.MatchString(prefix) {
+		errors["prefix"] = "prefix must be 1-20 letters or digits and start with a letter"
+	}
+
+	return errors
 }
 
-func validateTask(task task.Task) map[string]string {
+// validateTask checks a task's fields, its status against the workflow of its board
+func validateTask(t task.Task, wf *workflow.Workflow) map[string]string {
 	errors := make(map[string]string)
 
-	if strings.TrimSpace(task.Title) == "" {
+	if strings.TrimSpace(t.Title) == "" {
 		errors["title"] = "title is required"
 	}
 
-	validStatuses := []string{"TODO", "In Progress", "Done"}
-	if task.Status != "" && !slices.Contains(validStatuses, task.Status) {
-		errors["status"] = "status must be one of: TODO, In Progress, Done"
+	if t.Status != "" {
+		validateStatus(wf, t.Status, errors)
 	}
 
-	validPriorities := []string{"Low", "Medium", "High"}
-	if task.Priority != "" && !slices.Contains(validPriorities, task.Priority) {
-		errors["priority"] = "priority must be one of: Low, Medium, High"
+	if t.Priority != "" && !slices.Contains(task.Priorities, t.Priority) {
+		errors["priority"] = "priority must be one of: " + strings.Join(task.Priorities, ", ")
 	}
 
+	validateSchedule(t, errors)
+	validateTaskTags(t.Tags, errors)
+
 	return errors
 }
 
 // PostTaskHandler creates a new task
 func PostTaskHandler(c *gin.Context) {
-	var newTask task.Task
-	if err := c.ShouldBindJSON(&newTask); err != nil {
+	var req createTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
 		return
 	}
 
-	if validationErrors := validateTask(newTask); len(validationErrors) > 0 {
+	createTask(c, req)
+}
+
+// createTask validates and saves the task in req, responding with the created task
+func createTask(c *gin.Context, req createTaskRequest) {
+	newTask := req.Task
+	prefix := normalizeKeyPrefix(req.Prefix)
+
+	wf, ok := loadWorkflow(c, newTask.ProjectID)
+	if !ok {
+		return
+	}
+
+	validationErrors := validateTask(newTask, wf)
+	validateProjectReference(currentUserID(c), newTask.ProjectID, validationErrors)
+	validateParentReference(currentUserID(c), &newTask, validationErrors)
+	maps.Copy(validationErrors, validateKeyPrefix(prefix))
+	if len(validationErrors) > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
 		return
 	}
 
-	// Generate ID and set defaults
-	newTask.ID = generateNextID()
+	// Set defaults; the repository assigns the ID
 	if newTask.Status == "" {
-		newTask.Status = "TODO"
+		newTask.Status = wf.InitialStatus()
 	}
+	newTask.StatusCategory = wf.Category(newTask.Status)
 	if newTask.Priority == "" {
 		newTask.Priority = "Medium"
 	}
+	newTask.Tags = normalizeTags(newTask.Tags)
+
+	limit, ok := wipLimit(c, wf, "", newTask.Status)
+	if !ok {
+		return
+	}
 
 	// Save via repository
-	createdTask, err := repository.Tasks.CreateTask(newTask)
+	createdTask, err := repository.Tasks.CreateTask(currentUserID(c), newTask, prefix, limit)
 	if err != nil {
+		if respondIfBadParent(c, err) || respondIfOverLimit(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save task"})
 		return
 	}
 
+	c.Header("ETag", taskETag(createdTask))
 	c.JSON(http.StatusCreated, createdTask)
 }
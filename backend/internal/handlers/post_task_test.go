@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	task "tasker/internal/Task"
+	"tasker/internal/workflow"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -225,9 +226,10 @@ func TestValidateTask(t *testing.T) {
 		},
 	}
 
+	def := workflow.Default()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			errors := validateTask(tt.task)
+			errors := validateTask(tt.task, &def)
 
 			if tt.expectError && len(errors) == 0 {
 				t.Error("Expected validation errors, got none")
@@ -246,27 +248,62 @@ func TestValidateTask(t *testing.T) {
 	}
 }
 
-func TestGenerateNextID(t *testing.T) {
+func TestPostTaskHandler_PerPrefixSequences(t *testing.T) {
 	setupTest()
 	defer tearDownTest()
 
+	r := setupTestRouter()
+
+	createWithPrefix := func(prefix string) task.Task {
+		body, _ := json.Marshal(map[string]string{"title": "Task", "prefix": prefix})
+		w := makePostRequest(r, body)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var created task.Task
+		json.Unmarshal(w.Body.Bytes(), &created)
+		return created
+	}
+
+	assert.Equal(t, "HOME-001", createWithPrefix("HOME").ID)
+	assert.Equal(t, "WORK-001", createWithPrefix("work").ID)
+	assert.Equal(t, "HOME-002", createWithPrefix("HOME").ID)
+	assert.Equal(t, "TASK-001", createWithPrefix("").ID)
+}
+
+func TestPostTaskHandler_InvalidPrefix(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]string{"title": "Task", "prefix": "NO-DASHES"})
+	w := makePostRequest(r, body)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response map[string]any
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	details, ok := response["details"].(map[string]any)
+	assert.True(t, ok)
+	assert.NotNil(t, details["prefix"])
+}
+
+func TestNormalizeKeyPrefix(t *testing.T) {
 	tests := []struct {
 		name     string
-		setupID  int
+		prefix   string
 		expected string
 	}{
-		{"First ID", 1, "TASK-001"},
-		{"Tenth ID", 10, "TASK-010"},
-		{"Hundredth ID", 100, "TASK-100"},
+		{"Empty uses default", "", "TASK"},
+		{"Whitespace uses default", "  ", "TASK"},
+		{"Lower case is upper-cased", "home", "HOME"},
+		{"Trimmed", " WORK ", "WORK"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			nextID = tt.setupID
-			result := generateNextID()
-
-			assert.Equal(t, tt.expected, result)
-			assert.Equal(t, tt.setupID+1, nextID)
+			assert.Equal(t, tt.expected, normalizeKeyPrefix(tt.prefix))
 		})
 	}
 }
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"tasker/internal/project"
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+var colorHexPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+func validateProject(p project.Project) map[string]string {
+	errors := make(map[string]string)
+
+	if strings.TrimSpace(p.Name) == "" {
+		errors["name"] = "name is required"
+	}
+
+	if p.ColorHex != "" && !colorHexPattern.MatchString(p.ColorHex) {
+		errors["color_hex"] = "color_hex must look like #RRGGBB"
+	}
+
+	return errors
+}
+
+// validateProjectUpdate validates only the fields that are being updated (non-empty)
+func validateProjectUpdate(p project.Project) map[string]string {
+	errors := make(map[string]string)
+
+	if p.Name != "" && strings.TrimSpace(p.Name) == "" {
+		errors["name"] = "name cannot be empty or whitespace only"
+	}
+
+	if p.ColorHex != "" && !colorHexPattern.MatchString(p.ColorHex) {
+		errors["color_hex"] = "color_hex must look like #RRGGBB"
+	}
+
+	return errors
+}
+
+// validateProjectReference adds a project_id error when a task points at a project that doesn't exist
+func validateProjectReference(userID int64, projectID *int64, errors map[string]string) {
+	if projectID == nil {
+		return
+	}
+	if _, err := repository.Projects.GetProjectByID(userID, *projectID); err != nil {
+		errors["project_id"] = "project does not exist"
+	}
+}
+
+func parseProjectID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return 0, false
+	}
+	return id, true
+}
+
+// GetProjectsHandler returns all projects
+func GetProjectsHandler(c *gin.Context) {
+	projects, err := repository.Projects.GetAllProjects(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get projects"})
+		return
+	}
+
+	c.JSON(http.StatusOK, projects)
+}
+
+// GetProjectHandler handles GET /api/projects/:id requests
+func GetProjectHandler(c *gin.Context) {
+	id, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	p, err := repository.Projects.GetProjectByID(currentUserID(c), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "project not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get project"})
+		return
+	}
+
+	c.JSON(http.StatusOK, p)
+}
+
+// PostProjectHandler creates a new project
+func PostProjectHandler(c *gin.Context) {
+	var newProject project.Project
+	if err := c.ShouldBindJSON(&newProject); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if validationErrors := validateProject(newProject); len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	newProject.Name = strings.TrimSpace(newProject.Name)
+	if newProject.ColorHex == "" {
+		newProject.ColorHex = "#6B7280"
+	}
+
+	createdProject, err := repository.Projects.CreateProject(currentUserID(c), newProject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save project"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdProject)
+}
+
+// PutProjectHandler handles PUT /api/projects/:id requests
+func PutProjectHandler(c *gin.Context) {
+	id, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	var p project.Project
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if validationErrors := validateProjectUpdate(p); len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	updatedProject, err := repository.Projects.UpdateProject(currentUserID(c), id, p)
+	if err != nil {
+		if strings.Contains(err.Error(), "project not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update project"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedProject)
+}
+
+// DeleteProjectHandler handles DELETE /api/projects/:id requests.
+// The ?tasks= query parameter decides what happens to the project's tasks:
+// "orphan" (default) detaches them, "cascade" deletes them, and "move"
+// reassigns them to the project given in ?move_to=<id>.
+func DeleteProjectHandler(c *gin.Context) {
+	id, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	mode := repository.ProjectDeleteMode(c.DefaultQuery("tasks", string(repository.ProjectDeleteOrphan)))
+
+	var moveTo *int64
+	switch mode {
+	case repository.ProjectDeleteCascade, repository.ProjectDeleteOrphan:
+	case repository.ProjectDeleteMove:
+		target, err := strconv.ParseInt(c.Query("move_to"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"move_to": "move_to must be a project id"}})
+			return
+		}
+		if target == id {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"move_to": "move_to must be a different project"}})
+			return
+		}
+		if _, err := repository.Projects.GetProjectByID(currentUserID(c), target); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"move_to": "project does not exist"}})
+			return
+		}
+		moveTo = &target
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"tasks": "tasks must be one of: cascade, orphan, move"}})
+		return
+	}
+
+	if err := repository.Projects.DeleteProject(currentUserID(c), id, mode, moveTo); err != nil {
+		if strings.Contains(err.Error(), "project not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete project"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
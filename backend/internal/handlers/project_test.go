@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	task "tasker/internal/Task"
+	"tasker/internal/project"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeJSONRequest(r *gin.Engine, method string, path string, body []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func createTestProject(t *testing.T, r *gin.Engine, name string) project.Project {
+	body, _ := json.Marshal(map[string]string{"name": name})
+	w := makeJSONRequest(r, "POST", "/api/projects", body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var p project.Project
+	json.Unmarshal(w.Body.Bytes(), &p)
+	return p
+}
+
+func getTasks(r *gin.Engine, query string) []task.Task {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/task"+query, nil)
+	r.ServeHTTP(w, req)
+
+	var tasks []task.Task
+	json.Unmarshal(w.Body.Bytes(), &tasks)
+	return tasks
+}
+
+func TestPostProjectHandler_Success(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]string{"name": "Home", "description": "Chores", "color_hex": "#FF8800"})
+	w := makeJSONRequest(r, "POST", "/api/projects", body)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response project.Project
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, int64(1), response.ID)
+	assert.Equal(t, "Home", response.Name)
+	assert.Equal(t, "Chores", response.Description)
+	assert.Equal(t, "#FF8800", response.ColorHex)
+}
+
+func TestPostProjectHandler_ValidationErrors(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]string{"name": "  ", "color_hex": "orange"})
+	w := makeJSONRequest(r, "POST", "/api/projects", body)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response map[string]any
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	details, ok := response["details"].(map[string]any)
+	assert.True(t, ok)
+	assert.NotNil(t, details["name"])
+	assert.NotNil(t, details["color_hex"])
+}
+
+func TestGetProjectsHandler(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	createTestProject(t, r, "Work")
+	createTestProject(t, r, "Home")
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/projects", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var projects []project.Project
+	json.Unmarshal(w.Body.Bytes(), &projects)
+
+	assert.Equal(t, 2, len(projects))
+	assert.Equal(t, "Home", projects[0].Name)
+	assert.Equal(t, "Work", projects[1].Name)
+}
+
+func TestPutProjectHandler_PartialUpdate(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	created := createTestProject(t, r, "Side project")
+
+	body, _ := json.Marshal(map[string]string{"color_hex": "#112233"})
+	w := makeJSONRequest(r, "PUT", fmt.Sprintf("/api/projects/%d", created.ID), body)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response project.Project
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "Side project", response.Name)
+	assert.Equal(t, "#112233", response.ColorHex)
+}
+
+func TestPutProjectHandler_NotFound(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]string{"name": "Renamed"})
+	w := makeJSONRequest(r, "PUT", "/api/projects/42", body)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetTaskHandler_FilterByProject(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	home := createTestProject(t, r, "Home")
+	work := createTestProject(t, r, "Work")
+
+	homeTask := createTestTask(t, r, map[string]any{"title": "Pay rent", "project_id": home.ID})
+	createTestTask(t, r, map[string]any{"title": "Write report", "project_id": work.ID})
+	makePostRequest(r, marshalTaskBody("Unfiled task", "", "", ""))
+
+	tasks := getTasks(r, fmt.Sprintf("?project=%d", home.ID))
+
+	assert.Equal(t, 1, len(tasks))
+	assert.Equal(t, homeTask.ID, tasks[0].ID)
+	assert.Equal(t, 3, len(getTasks(r, "")))
+}
+
+func TestGetTaskHandler_InvalidProjectFilter(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/task?project=abc", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPostTaskHandler_UnknownProject(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]any{"title": "Lost task", "project_id": 99})
+	w := makePostRequest(r, body)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response map[string]any
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	details, ok := response["details"].(map[string]any)
+	assert.True(t, ok)
+	assert.NotNil(t, details["project_id"])
+}
+
+func TestDeleteProjectHandler_OrphanByDefault(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	p := createTestProject(t, r, "Home")
+	created := createTestTask(t, r, map[string]any{"title": "Pay rent", "project_id": p.ID})
+
+	w := makeJSONRequest(r, "DELETE", fmt.Sprintf("/api/projects/%d", p.ID), nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	tasks := getTasks(r, "")
+	assert.Equal(t, 1, len(tasks))
+	assert.Equal(t, created.ID, tasks[0].ID)
+	assert.Nil(t, tasks[0].ProjectID)
+}
+
+func TestDeleteProjectHandler_Cascade(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	p := createTestProject(t, r, "Home")
+	createTestTask(t, r, map[string]any{"title": "Pay rent", "project_id": p.ID})
+	makePostRequest(r, marshalTaskBody("Unfiled task", "", "", ""))
+
+	w := makeJSONRequest(r, "DELETE", fmt.Sprintf("/api/projects/%d?tasks=cascade", p.ID), nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	tasks := getTasks(r, "")
+	assert.Equal(t, 1, len(tasks))
+	assert.Equal(t, "Unfiled task", tasks[0].Title)
+}
+
+func TestDeleteProjectHandler_Move(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	from := createTestProject(t, r, "Old")
+	to := createTestProject(t, r, "New")
+	createTestTask(t, r, map[string]any{"title": "Carry me over", "project_id": from.ID})
+
+	w := makeJSONRequest(r, "DELETE", fmt.Sprintf("/api/projects/%d?tasks=move&move_to=%d", from.ID, to.ID), nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	tasks := getTasks(r, fmt.Sprintf("?project=%d", to.ID))
+	assert.Equal(t, 1, len(tasks))
+	assert.Equal(t, "Carry me over", tasks[0].Title)
+}
+
+func TestDeleteProjectHandler_MoveToUnknownProject(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	p := createTestProject(t, r, "Home")
+
+	w := makeJSONRequest(r, "DELETE", fmt.Sprintf("/api/projects/%d?tasks=move&move_to=99", p.ID), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = makeJSONRequest(r, "DELETE", fmt.Sprintf("/api/projects/%d?tasks=move&move_to=%d", p.ID, p.ID), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeleteProjectHandler_InvalidMode(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	p := createTestProject(t, r, "Home")
+
+	w := makeJSONRequest(r, "DELETE", fmt.Sprintf("/api/projects/%d?tasks=shred", p.ID), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeleteProjectHandler_NotFound(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	w := makeJSONRequest(r, "DELETE", "/api/projects/7", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -1,65 +1,329 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"slices"
 	"strings"
 	task "tasker/internal/Task"
 	"tasker/internal/repository"
+	"tasker/internal/workflow"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// validateTaskUpdate validates only the fields that are being updated (non-empty)
-// For PUT /api/task/:id, we allow partial updates, so we only validate provided fields
-func validateTaskUpdate(t task.Task) map[string]string {
-	errors := make(map[string]string)
+// taskPatch is an RFC 7396 JSON Merge Patch for a task. A missing key leaves the
+// field untouched and an explicit null clears it.
+type taskPatch map[string]json.RawMessage
+
+func isJSONNull(raw json.RawMessage) bool {
+	return strings.TrimSpace(string(raw)) == "null"
+}
+
+// validateTaskReplacement validates a PUT body, which must carry every required field
+func validateTaskReplacement(t task.Task, wf *workflow.Workflow) map[string]string {
+	errors := validateTask(t, wf)
 
-	// Only validate title if it's being updated (non-empty)
-	// Check if title is provided but consists only of whitespace
-	if t.Title != "" && strings.TrimSpace(t.Title) == "" {
-		errors["title"] = "title cannot be empty or whitespace only"
+	if t.Status == "" {
+		errors["status"] = "status is required"
 	}
 
-	// Only validate status if it's being updated (non-empty)
-	validStatuses := []string{"TODO", "In Progress", "Done"}
-	if t.Status != "" && !slices.Contains(validStatuses, t.Status) {
-		errors["status"] = "status must be one of: TODO, In Progress, Done"
+	if t.Priority == "" {
+		errors["priority"] = "priority is required"
 	}
 
-	// Only validate priority if it's being updated (non-empty)
-	validPriorities := []string{"Low", "Medium", "High"}
-	if t.Priority != "" && !slices.Contains(validPriorities, t.Priority) {
-		errors["priority"] = "priority must be one of: Low, Medium, High"
+	return errors
+}
+
+// validateTaskUpdate validates each field present in a PATCH body on its own.
+// Fields that are absent are not checked since they won't change.
+func validateTaskUpdate(patch taskPatch) map[string]string {
+	errors := make(map[string]string)
+
+	for field, raw := range patch {
+		switch field {
+		case "title":
+			var title string
+			if isJSONNull(raw) || json.Unmarshal(raw, &title) != nil {
+				errors["title"] = "title must be a string"
+			} else if strings.TrimSpace(title) == "" {
+				errors["title"] = "title cannot be empty or whitespace only"
+			}
+		case "description":
+			var description *string
+			if json.Unmarshal(raw, &description) != nil {
+				errors["description"] = "description must be a string or null"
+			}
+		case "status":
+			// Whether the status exists depends on the board, which is checked once merged
+			var status string
+			if json.Unmarshal(raw, &status) != nil || status == "" {
+				errors["status"] = "status must be a workflow column"
+			}
+		case "priority":
+			var priority string
+			if json.Unmarshal(raw, &priority) != nil || !slices.Contains(task.Priorities, priority) {
+				errors["priority"] = "priority must be one of: " + strings.Join(task.Priorities, ", ")
+			}
+		case "project_id":
+			var projectID *int64
+			if json.Unmarshal(raw, &projectID) != nil {
+				errors["project_id"] = "project_id must be a project id or null"
+			}
+		case "start_at", "due_at":
+			var at *time.Time
+			if json.Unmarshal(raw, &at) != nil {
+				errors[field] = field + " must be an RFC 3339 timestamp or null"
+			}
+		case "estimate_hours":
+			var estimate *float64
+			if json.Unmarshal(raw, &estimate) != nil {
+				errors["estimate_hours"] = "estimate_hours must be a number or null"
+			}
+		case "parent_id":
+			var parentID *string
+			if json.Unmarshal(raw, &parentID) != nil {
+				errors["parent_id"] = "parent_id must be a task key or null"
+			}
+		case "tags":
+			var tags []string
+			if json.Unmarshal(raw, &tags) != nil {
+				errors["tags"] = "tags must be a list of names or null"
+			} else {
+				validateTaskTags(tags, errors)
+			}
+		default:
+			errors[field] = "field cannot be updated"
+		}
 	}
 
 	return errors
 }
 
-// PutTaskHandler handles PUT /api/task/:id requests
+// applyTaskPatch merges a validated patch into t
+func applyTaskPatch(t *task.Task, patch taskPatch) {
+	for field, raw := range patch {
+		switch field {
+		case "title":
+			json.Unmarshal(raw, &t.Title)
+			t.Title = strings.TrimSpace(t.Title)
+		case "description":
+			// null clears the description
+			var description *string
+			json.Unmarshal(raw, &description)
+			t.Description = ""
+			if description != nil {
+				t.Description = *description
+			}
+		case "status":
+			json.Unmarshal(raw, &t.Status)
+		case "priority":
+			json.Unmarshal(raw, &t.Priority)
+		case "project_id":
+			t.ProjectID = nil
+			json.Unmarshal(raw, &t.ProjectID)
+		case "start_at":
+			t.StartAt = nil
+			json.Unmarshal(raw, &t.StartAt)
+		case "due_at":
+			t.DueAt = nil
+			json.Unmarshal(raw, &t.DueAt)
+		case "estimate_hours":
+			t.EstimateHours = nil
+			json.Unmarshal(raw, &t.EstimateHours)
+		case "parent_id":
+			// null moves the task to the top level
+			t.ParentID = nil
+			json.Unmarshal(raw, &t.ParentID)
+		case "tags":
+			// null removes every tag
+			var tags []string
+			json.Unmarshal(raw, &tags)
+			t.Tags = normalizeTags(tags)
+		}
+	}
+}
+
+// PutTaskHandler handles PUT /api/task/:id requests by replacing the task's
+// editable fields. Omitted optional fields are cleared. With If-Match the
+// replacement only applies to the version the client last saw.
 func PutTaskHandler(c *gin.Context) {
 	taskID := c.Param("id")
 
-	var task task.Task
-	if err := c.ShouldBindJSON(&task); err != nil {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+
+	var replacement task.Task
+	if err := c.ShouldBindJSON(&replacement); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	wf, ok := loadWorkflow(c, replacement.ProjectID)
+	if !ok {
+		return
+	}
+
+	validationErrors := validateTaskReplacement(replacement, wf)
+	validateProjectReference(currentUserID(c), replacement.ProjectID, validationErrors)
+	validateParentReference(currentUserID(c), &replacement, validationErrors)
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	existing, err := repository.Tasks.GetTaskByID(currentUserID(c), taskID)
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+		return
+	}
+
+	validateTransition(wf, existing.Status, replacement.Status, validationErrors)
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	replacement.StatusCategory = wf.Category(replacement.Status)
+
+	var limit *repository.WIPLimit
+	entering, ok := entersColumn(c, *existing, replacement, wf)
+	if ok && entering {
+		limit, ok = wipLimit(c, wf, existing.ID, replacement.Status)
+	}
+	if !ok {
+		return
+	}
+
+	replacement.Title = strings.TrimSpace(replacement.Title)
+	replacement.Tags = normalizeTags(replacement.Tags)
+	replacement.Version = version
+	updatedTask, err := repository.Tasks.UpdateTask(currentUserID(c), taskID, replacement, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "task version conflict") {
+			respondPreconditionFailed(c, taskID)
+			return
+		}
+		if respondIfBadParent(c, err) || respondIfBlocked(c, err) || respondIfOverLimit(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update task"})
+		return
+	}
+
+	c.Header("ETag", taskETag(updatedTask))
+	c.JSON(http.StatusOK, updatedTask)
+}
+
+// PatchTaskHandler handles PATCH /api/task/:id requests. The body is a JSON
+// Merge Patch (RFC 7396) applied on top of the current task. The write is
+// conditional on the version that was patched, or on If-Match when given.
+func PatchTaskHandler(c *gin.Context) {
+	taskID := c.Param("id")
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+
+	var patch taskPatch
+	if err := c.ShouldBindJSON(&patch); err != nil || patch == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
 		return
 	}
 
-	if validationErrors := validateTaskUpdate(task); len(validationErrors) > 0 {
+	validationErrors := validateTaskUpdate(patch)
+	if len(validationErrors) > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
 		return
 	}
 
-	updatedTask, err := repository.Tasks.UpdateTask(taskID, task)
+	existing, err := repository.Tasks.GetTaskByID(currentUserID(c), taskID)
 	if err != nil {
 		if strings.Contains(err.Error(), "task not found") {
 			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+		return
+	}
+
+	if version != 0 && version != existing.Version {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+
+	previous := *existing
+	applyTaskPatch(existing, patch)
+
+	// Checks that depend on the merged task rather than a single field
+	if _, ok := patch["project_id"]; ok {
+		validateProjectReference(currentUserID(c), existing.ProjectID, validationErrors)
+	}
+	if _, ok := patch["parent_id"]; ok {
+		validateParentReference(currentUserID(c), existing, validationErrors)
+	}
+	validateSchedule(*existing, validationErrors)
+
+	// The status has to fit the board the task ends up on
+	_, statusChanged := patch["status"]
+	_, projectChanged := patch["project_id"]
+	var wf *workflow.Workflow
+	if statusChanged || projectChanged {
+		if wf, ok = loadWorkflow(c, existing.ProjectID); !ok {
+			return
+		}
+		validateStatus(wf, existing.Status, validationErrors)
+		validateTransition(wf, previous.Status, existing.Status, validationErrors)
+		existing.StatusCategory = wf.Category(existing.Status)
+	}
+
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	var limit *repository.WIPLimit
+	if wf != nil {
+		entering, ok := entersColumn(c, previous, *existing, wf)
+		if ok && entering {
+			limit, ok = wipLimit(c, wf, existing.ID, existing.Status)
+		}
+		if !ok {
+			return
+		}
+	}
+
+	updatedTask, err := repository.Tasks.UpdateTask(currentUserID(c), existing.ID, *existing, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "task version conflict") {
+			respondPreconditionFailed(c, taskID)
+			return
+		}
+		if respondIfBadParent(c, err) || respondIfBlocked(c, err) || respondIfOverLimit(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update task"})
 		return
 	}
 
+	c.Header("ETag", taskETag(updatedTask))
 	c.JSON(http.StatusOK, updatedTask)
 }
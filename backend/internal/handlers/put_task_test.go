@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"time"
 	task "tasker/internal/Task"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -56,35 +56,6 @@ func TestPutTaskHandler_Success_FullUpdate(t *testing.T) {
 	assert.True(t, response.UpdatedAt.After(createdTask.UpdatedAt))
 }
 
-func TestPutTaskHandler_Success_PartialUpdate(t *testing.T) {
-	setupTest()
-	defer tearDownTest()
-
-	r := setupTestRouter()
-
-	// Create a task
-	jsonBody := marshalTaskBody("Original Title", "Original Description", "TODO", "Medium")
-	postW := makePostRequest(r, jsonBody)
-
-	var createdTask task.Task
-	json.Unmarshal(postW.Body.Bytes(), &createdTask)
-
-	// Update only status
-	updateBody := marshalTaskBody("", "", "In Progress", "")
-	putW := makePutRequest(r, createdTask.ID, updateBody)
-
-	assert.Equal(t, http.StatusOK, putW.Code)
-
-	var response task.Task
-	json.Unmarshal(putW.Body.Bytes(), &response)
-
-	assert.Equal(t, createdTask.ID, response.ID)
-	assert.Equal(t, "Original Title", response.Title)
-	assert.Equal(t, "Original Description", response.Description)
-	assert.Equal(t, "In Progress", response.Status)
-	assert.Equal(t, "Medium", response.Priority)
-}
-
 func TestPutTaskHandler_TaskNotFound(t *testing.T) {
 	setupTest()
 	defer tearDownTest()
@@ -171,22 +142,21 @@ func TestPutTaskHandler_EmptyTitle(t *testing.T) {
 	var createdTask task.Task
 	json.Unmarshal(postW.Body.Bytes(), &createdTask)
 
-	// Empty title should be treated as "no change", not a validation error
-	// This allows partial updates
+	// PUT replaces the whole task, so a missing title is an error rather than "no change"
 	updateBody := marshalTaskBody("", "Updated Description", "Done", "High")
 	putW := makePutRequest(r, createdTask.ID, updateBody)
 
-	// Should succeed because empty title means "skip updating title"
-	assert.Equal(t, http.StatusOK, putW.Code)
+	assert.Equal(t, http.StatusBadRequest, putW.Code)
 
-	var response task.Task
+	var response map[string]any
 	json.Unmarshal(putW.Body.Bytes(), &response)
 
-	// Title should remain unchanged (not set to empty)
-	assert.Equal(t, "Original Title", response.Title)
-	assert.Equal(t, "Updated Description", response.Description)
-	assert.Equal(t, "Done", response.Status)
-	assert.Equal(t, "High", response.Priority)
+	details, ok := response["details"].(map[string]any)
+	assert.True(t, ok)
+	assert.NotNil(t, details["title"])
+
+	stored, _ := mockRepo.GetTaskByID(testUserID, createdTask.ID)
+	assert.Equal(t, "Original Title", stored.Title)
 }
 
 func TestPutTaskHandler_MultipleValidationErrors(t *testing.T) {
@@ -202,7 +172,6 @@ func TestPutTaskHandler_MultipleValidationErrors(t *testing.T) {
 	var createdTask task.Task
 	json.Unmarshal(postW.Body.Bytes(), &createdTask)
 
-	// Try to update with invalid status and priority (empty title is treated as "no change")
 	updateBody := marshalTaskBody("", "Updated Description", "InvalidStatus", "InvalidPriority")
 	putW := makePutRequest(r, createdTask.ID, updateBody)
 
@@ -213,8 +182,7 @@ func TestPutTaskHandler_MultipleValidationErrors(t *testing.T) {
 
 	details, ok := response["details"].(map[string]any)
 	assert.True(t, ok)
-	// Empty title is not a validation error (it means "no change")
-	assert.Nil(t, details["title"])
+	assert.NotNil(t, details["title"])
 	assert.NotNil(t, details["status"])
 	assert.NotNil(t, details["priority"])
 }
@@ -261,7 +229,7 @@ func TestPutTaskHandler_UpdatedAtTimestampUpdates(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 
 	// Update the task
-	updateBody := marshalTaskBody("Updated Title", "", "", "")
+	updateBody := marshalTaskBody("Updated Title", "", "TODO", "Medium")
 	putW := makePutRequest(r, createdTask.ID, updateBody)
 
 	assert.Equal(t, http.StatusOK, putW.Code)
@@ -272,112 +240,61 @@ func TestPutTaskHandler_UpdatedAtTimestampUpdates(t *testing.T) {
 	assert.True(t, response.UpdatedAt.After(originalUpdatedAt))
 }
 
-func TestPutTaskHandler_StatusOnlyUpdate(t *testing.T) {
+func TestPutTaskHandler_EmptyID(t *testing.T) {
 	setupTest()
 	defer tearDownTest()
 
 	r := setupTestRouter()
 
-	// Create a task
-	jsonBody := marshalTaskBody("Test Task", "Test Description", "TODO", "Medium")
-	postW := makePostRequest(r, jsonBody)
-
-	var createdTask task.Task
-	json.Unmarshal(postW.Body.Bytes(), &createdTask)
-
-	// Update only status
-	updateBody := marshalTaskBody("", "", "Done", "")
-	putW := makePutRequest(r, createdTask.ID, updateBody)
-
-	assert.Equal(t, http.StatusOK, putW.Code)
-
-	var response task.Task
-	json.Unmarshal(putW.Body.Bytes(), &response)
+	updateBody := marshalTaskBody("Updated Title", "Updated Description", "Done", "High")
+	putW := makePutRequest(r, "", updateBody)
 
-	assert.Equal(t, "Test Task", response.Title)
-	assert.Equal(t, "Test Description", response.Description)
-	assert.Equal(t, "Done", response.Status)
-	assert.Equal(t, "Medium", response.Priority)
+	assert.Equal(t, http.StatusNotFound, putW.Code)
 }
 
-func TestPutTaskHandler_PriorityOnlyUpdate(t *testing.T) {
+func TestPutTaskHandler_MissingRequiredFields(t *testing.T) {
 	setupTest()
 	defer tearDownTest()
 
 	r := setupTestRouter()
 
-	// Create a task
-	jsonBody := marshalTaskBody("Test Task", "Test Description", "TODO", "Medium")
+	jsonBody := marshalTaskBody("Test Task", "Test Description", "In Progress", "Medium")
 	postW := makePostRequest(r, jsonBody)
 
 	var createdTask task.Task
 	json.Unmarshal(postW.Body.Bytes(), &createdTask)
 
-	// Update only priority
-	updateBody := marshalTaskBody("", "", "", "High")
-	putW := makePutRequest(r, createdTask.ID, updateBody)
+	// Status and priority are required for a full replacement
+	putW := makePutRequest(r, createdTask.ID, marshalTaskBody("Updated Title", "", "", ""))
 
-	assert.Equal(t, http.StatusOK, putW.Code)
+	assert.Equal(t, http.StatusBadRequest, putW.Code)
 
-	var response task.Task
+	var response map[string]any
 	json.Unmarshal(putW.Body.Bytes(), &response)
 
-	assert.Equal(t, "Test Task", response.Title)
-	assert.Equal(t, "Test Description", response.Description)
-	assert.Equal(t, "TODO", response.Status)
-	assert.Equal(t, "High", response.Priority)
-}
-
-func TestPutTaskHandler_EmptyID(t *testing.T) {
-	setupTest()
-	defer tearDownTest()
-
-	r := setupTestRouter()
-
-	updateBody := marshalTaskBody("Updated Title", "Updated Description", "Done", "High")
-	putW := makePutRequest(r, "", updateBody)
-
-	assert.Equal(t, http.StatusNotFound, putW.Code)
+	details, ok := response["details"].(map[string]any)
+	assert.True(t, ok)
+	assert.Equal(t, "status is required", details["status"])
+	assert.Equal(t, "priority is required", details["priority"])
 }
 
-// TestPutTaskHandler_OmittedFieldsPreserveExistingValues tests that when a field
-// is omitted from the update request (resulting in empty string after JSON binding),
-// the existing database value is preserved. This prevents tasks from disappearing
-// when partial updates are sent.
-func TestPutTaskHandler_OmittedFieldsPreserveExistingValues(t *testing.T) {
+func TestPutTaskHandler_OmittedOptionalFieldsAreCleared(t *testing.T) {
 	setupTest()
 	defer tearDownTest()
 
 	r := setupTestRouter()
+	home := createTestProject(t, r, "Home")
+	created := createTestTask(t, r, map[string]any{"title": "Test Task", "project_id": home.ID})
 
-	// Create a task with all fields populated
-	jsonBody := marshalTaskBody("Original Title", "Original Description", "In Progress", "Medium")
-	postW := makePostRequest(r, jsonBody)
-
-	var createdTask task.Task
-	json.Unmarshal(postW.Body.Bytes(), &createdTask)
-
-	// Send a partial update with only some fields (others will be empty strings after binding)
-	partialUpdate := map[string]string{
-		"title":       "Updated Title",
-		"description": "Updated Description",
-		"priority":    "High",
-		// Note: "status" field is intentionally omitted
-	}
-	payloadBytes, _ := json.Marshal(partialUpdate)
-
-	putW := makePutRequest(r, createdTask.ID, payloadBytes)
+	putW := makePutRequest(r, created.ID, marshalTaskBody("Test Task", "", "Done", "Low"))
 
 	assert.Equal(t, http.StatusOK, putW.Code)
 
 	var response task.Task
 	json.Unmarshal(putW.Body.Bytes(), &response)
 
-	// Verify sent fields were updated
-	assert.Equal(t, "Updated Title", response.Title)
-	assert.Equal(t, "Updated Description", response.Description)
-	assert.Equal(t, "High", response.Priority)
-
-	// CRITICAL: Verify omitted field was preserved (not overwritten to empty string)
-	assert.Equal(t, "In Progress", response.Status, "omitted fields must preserve existing database values")
+	assert.Equal(t, "", response.Description)
+	assert.Nil(t, response.ProjectID)
+	assert.Equal(t, "Done", response.Status)
+	assert.Equal(t, "Low", response.Priority)
 }
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"tasker/internal/recurrence"
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recurrenceRequest is the PUT /api/task/:id/recurrence body. Rule is an RRULE
+// such as FREQ=WEEKLY;BYDAY=MO; with after_completion the rule counts from
+// when the last occurrence was done instead of from the first one's due date.
+type recurrenceRequest struct {
+	Rule            string `json:"rule"`
+	AfterCompletion bool   `json:"after_completion"`
+}
+
+// PutRecurrenceHandler handles PUT /api/task/:id/recurrence requests, making a
+// task recur. For a task that already recurs, the new rule applies from this
+// occurrence on. The rule's days and time of day follow ?tz= or the user's
+// time zone.
+func PutRecurrenceHandler(c *gin.Context) {
+	taskID := c.Param("id")
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+
+	var req recurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	current, err := repository.Tasks.GetTaskByID(currentUserID(c), taskID)
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+		return
+	}
+	if version != 0 && version != current.Version {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+
+	validationErrors := make(map[string]string)
+	rule, err := recurrence.Parse(req.Rule)
+	if err != nil {
+		validationErrors["rule"] = err.Error()
+	}
+	if current.DueAt == nil && !req.AfterCompletion {
+		validationErrors["due_at"] = "a recurring task needs a due date unless it recurs after completion"
+	}
+	loc, err := requestLocation(c)
+	if err != nil {
+		validationErrors["tz"] = "tz must be an IANA time zone such as Europe/Berlin"
+	}
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	setRecurrence(c, current.ID, version, rule, req.AfterCompletion, loc)
+}
+
+// DeleteRecurrenceHandler handles DELETE /api/task/:id/recurrence requests,
+// ending the task's series. Its occurrences stay as plain tasks.
+func DeleteRecurrenceHandler(c *gin.Context) {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		respondPreconditionFailed(c, c.Param("id"))
+		return
+	}
+	setRecurrence(c, c.Param("id"), version, nil, false, time.UTC)
+}
+
+// setRecurrence writes the task's recurrence, if it is still at version when
+// that isn't zero, and responds with the task
+func setRecurrence(c *gin.Context, taskID string, version int64, rule *recurrence.Rule, afterCompletion bool, loc *time.Location) {
+	updated, err := repository.Tasks.SetRecurrence(currentUserID(c), taskID, version, rule, afterCompletion, loc)
+	if err != nil {
+		switch msg := err.Error(); {
+		case strings.Contains(msg, "task not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		case strings.Contains(msg, "task version conflict"):
+			respondPreconditionFailed(c, taskID)
+		case strings.Contains(msg, "task has no due date"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"due_at": "a recurring task needs a due date unless it recurs after completion"}})
+		case strings.Contains(msg, "rule never recurs"):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"rule": "rule has no occurrence after the due date"}})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set recurrence"})
+		}
+		return
+	}
+
+	c.Header("ETag", taskETag(updated))
+	c.JSON(http.StatusOK, updated)
+}
+
+// SkipOccurrenceHandler handles POST /api/task/:id/skip requests. The
+// occurrence goes to the trash and the series carries on; the response is the
+// next occurrence, or 204 when the series has none.
+func SkipOccurrenceHandler(c *gin.Context) {
+	next, err := repository.Tasks.SkipOccurrence(currentUserID(c), c.Param("id"))
+	if err != nil {
+		switch msg := err.Error(); {
+		case strings.Contains(msg, "task not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		case strings.Contains(msg, "task not recurring"):
+			c.JSON(http.StatusConflict, gin.H{"error": "task does not recur"})
+		case strings.Contains(msg, "task already done"):
+			c.JSON(http.StatusConflict, gin.H{"error": "only open occurrences can be skipped"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to skip occurrence"})
+		}
+		return
+	}
+
+	if next == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.Header("ETag", taskETag(next))
+	c.JSON(http.StatusOK, next)
+}
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// putRecurrence sets a task's rule, kept in UTC
+func putRecurrence(r *gin.Engine, id string, body string) *httptest.ResponseRecorder {
+	return makeJSONRequest(r, "PUT", "/api/task/"+id+"/recurrence?tz=UTC", []byte(body))
+}
+
+// createRecurringTask creates a task due at dueAt that recurs by rule
+func createRecurringTask(t *testing.T, r *gin.Engine, title string, dueAt time.Time, rule string) task.Task {
+	created := createTestTask(t, r, map[string]any{"title": title, "status": "TODO", "due_at": dueAt.Format(time.RFC3339)})
+	w := putRecurrence(r, created.ID, `{"rule":"`+rule+`"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var recurring task.Task
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &recurring))
+	return recurring
+}
+
+// occurrences lists the live tasks titled title, newest first
+func occurrences(t *testing.T, r *gin.Engine, title string) []task.Task {
+	tasks, _ := listTasks(t, r, "")
+	var matching []task.Task
+	for _, tk := range tasks {
+		if tk.Title == title {
+			matching = append(matching, tk)
+		}
+	}
+	return matching
+}
+
+func TestPutRecurrenceHandler_StartsSeries(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	created := createTestTask(t, r, map[string]any{"title": "Weekly review", "status": "TODO", "due_at": monday.Format(time.RFC3339)})
+
+	w := putRecurrence(r, created.ID, `{"rule":"RRULE:FREQ=WEEKLY;BYDAY=FR,MO"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var recurring task.Task
+	json.Unmarshal(w.Body.Bytes(), &recurring)
+
+	assert.NotNil(t, recurring.Recurrence)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,FR", recurring.Recurrence.Rule)
+	assert.False(t, recurring.Recurrence.AfterCompletion)
+	assert.True(t, recurring.Recurrence.OccursAt.Equal(monday))
+	assert.True(t, recurring.Recurrence.NextAt.Equal(monday.AddDate(0, 0, 4)))
+	assert.Equal(t, created.Version+1, recurring.Version)
+	assert.Equal(t, taskETag(&recurring), w.Header().Get("ETag"))
+
+	assert.Equal(t, recurring.Recurrence, getTask(t, r, created.ID).Recurrence)
+}
+
+func TestPutRecurrenceHandler_UsesTimezone(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	la, _ := time.LoadLocation("America/Los_Angeles")
+	monday := time.Date(2026, 3, 2, 20, 0, 0, 0, la)
+	created := createTestTask(t, r, map[string]any{"title": "Take out the bins", "status": "TODO", "due_at": monday.Format(time.RFC3339)})
+
+	w := makeJSONRequest(r, "PUT", "/api/task/"+created.ID+"/recurrence?tz=Mars/Olympus_Mons", []byte(`{"rule":"FREQ=WEEKLY;BYDAY=MO"}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"tz"`)
+
+	// Mondays at 20:00 in Los Angeles, across the start of DST
+	w = makeJSONRequest(r, "PUT", "/api/task/"+created.ID+"/recurrence?tz=America/Los_Angeles", []byte(`{"rule":"FREQ=WEEKLY;BYDAY=MO"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var recurring task.Task
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &recurring))
+	assert.Equal(t, "America/Los_Angeles", recurring.Recurrence.Timezone)
+	assert.True(t, recurring.Recurrence.NextAt.Equal(time.Date(2026, 3, 9, 20, 0, 0, 0, la)))
+
+	w = makePatchRequest(r, created.ID, `{"status": "Done"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	next := occurrences(t, r, "Take out the bins")[0]
+	assert.Equal(t, time.Monday, next.DueAt.In(la).Weekday())
+	assert.True(t, next.DueAt.Equal(time.Date(2026, 3, 9, 20, 0, 0, 0, la)))
+	assert.True(t, next.Recurrence.NextAt.Equal(time.Date(2026, 3, 16, 20, 0, 0, 0, la)))
+}
+
+func TestPutRecurrenceHandler_Validation(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	undated := createTestTask(t, r, map[string]any{"title": "Undated", "status": "TODO"})
+	feb := time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC)
+	dated := createTestTask(t, r, map[string]any{"title": "Dated", "status": "TODO", "due_at": feb.Format(time.RFC3339)})
+
+	tests := []struct {
+		name   string
+		id     string
+		body   string
+		status int
+		field  string
+	}{
+		{"unsupported rule", dated.ID, `{"rule":"FREQ=YEARLY"}`, http.StatusBadRequest, "rule"},
+		{"missing rule", dated.ID, `{}`, http.StatusBadRequest, "rule"},
+		{"no due date", undated.ID, `{"rule":"FREQ=DAILY"}`, http.StatusBadRequest, "due_at"},
+		{"never recurs", dated.ID, `{"rule":"FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=30"}`, http.StatusBadRequest, "rule"},
+		{"unknown task", "TASK-999", `{"rule":"FREQ=DAILY"}`, http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := putRecurrence(r, tt.id, tt.body)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.field != "" {
+				var resp map[string]any
+				json.Unmarshal(w.Body.Bytes(), &resp)
+				assert.Contains(t, resp["details"], tt.field)
+			}
+		})
+	}
+
+	// Counting from completion doesn't need a due date
+	w := putRecurrence(r, undated.ID, `{"rule":"FREQ=DAILY;INTERVAL=3","after_completion":true}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, getTask(t, r, undated.ID).Recurrence.NextAt)
+}
+
+func TestRecurrence_DoneCreatesNextOccurrence(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rent := createRecurringTask(t, r, "Pay rent", first, "FREQ=MONTHLY;BYMONTHDAY=1")
+	w := makePatchRequest(r, rent.ID, `{"priority":"High","tags":["home"],"start_at":"2025-12-29T00:00:00Z"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, rent.ID, `{"status":"Done"}`).Code)
+
+	series := occurrences(t, r, "Pay rent")
+	assert.Len(t, series, 2)
+	next := series[0]
+	assert.NotEqual(t, rent.ID, next.ID)
+	assert.Equal(t, "TODO", next.Status)
+	assert.Equal(t, "High", next.Priority)
+	assert.Equal(t, []string{"home"}, []string(next.Tags))
+	assert.True(t, next.DueAt.Equal(first.AddDate(0, 1, 0)))
+	assert.True(t, next.StartAt.Equal(first.AddDate(0, 1, -3)))
+	assert.Equal(t, rent.Recurrence.SeriesID, next.Recurrence.SeriesID)
+	assert.True(t, next.Recurrence.NextAt.Equal(first.AddDate(0, 2, 0)))
+	assert.Equal(t, []string{"Pay rent"}, columnTitles(t, r, "TODO"))
+
+	// Reopening and finishing again doesn't double up while the next one is open
+	assert.Equal(t, http.StatusOK, moveTask(r, rent.ID, `{"status":"TODO"}`).Code)
+	assert.Equal(t, http.StatusOK, moveTask(r, rent.ID, `{"status":"Done"}`).Code)
+	assert.Len(t, occurrences(t, r, "Pay rent"), 2)
+
+	// Dragging the next one to Done carries on the series
+	assert.Equal(t, http.StatusOK, moveTask(r, next.ID, `{"status":"Done"}`).Code)
+	series = occurrences(t, r, "Pay rent")
+	assert.Len(t, series, 3)
+	assert.True(t, series[0].DueAt.Equal(first.AddDate(0, 2, 0)))
+}
+
+func TestRecurrence_UndoingCompletionKeepsNextOccurrence(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	review := createRecurringTask(t, r, "Weekly review", monday, "FREQ=WEEKLY")
+	assert.Equal(t, http.StatusOK, moveTask(r, review.ID, `{"status":"Done"}`).Code)
+
+	w := makeJSONRequest(r, "POST", "/api/undo", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "TODO", getTask(t, r, review.ID).Status)
+	assert.Len(t, occurrences(t, r, "Weekly review"), 2)
+}
+
+func TestRecurrence_EditingAnOccurrenceKeepsSchedule(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	review := createRecurringTask(t, r, "Weekly review", monday, "FREQ=WEEKLY")
+
+	// Pushed back a day this week only
+	w := makePatchRequest(r, review.ID, `{"due_at":"2026-03-03T09:00:00Z","status":"Done"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	next := occurrences(t, r, "Weekly review")[0]
+	assert.True(t, next.DueAt.Equal(monday.AddDate(0, 0, 7)))
+	assert.True(t, getTask(t, r, review.ID).Recurrence.OccursAt.Equal(monday))
+}
+
+func TestRecurrence_AfterCompletion(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	plants := createTestTask(t, r, map[string]any{"title": "Water plants", "status": "TODO"})
+	w := putRecurrence(r, plants.ID, `{"rule":"FREQ=DAILY;INTERVAL=3","after_completion":true}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, http.StatusOK, moveTask(r, plants.ID, `{"status":"Done"}`).Code)
+
+	series := occurrences(t, r, "Water plants")
+	assert.Len(t, series, 2)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 3), *series[0].DueAt, time.Minute)
+	assert.True(t, series[0].Recurrence.AfterCompletion)
+	assert.Nil(t, series[0].Recurrence.NextAt)
+}
+
+func TestSkipOccurrenceHandler(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	review := createRecurringTask(t, r, "Weekly review", monday, "FREQ=WEEKLY")
+	plain := createTestTask(t, r, map[string]any{"title": "Plain", "status": "TODO"})
+
+	w := makeJSONRequest(r, "POST", "/api/task/"+review.ID+"/skip", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var next task.Task
+	json.Unmarshal(w.Body.Bytes(), &next)
+	assert.True(t, next.DueAt.Equal(monday.AddDate(0, 0, 7)))
+
+	// The skipped one is in the trash and the series carries on
+	trash := getTrash(t, r)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, review.ID, trash[0].ID)
+	assert.Equal(t, []string{"Weekly review", "Plain"}, columnTitles(t, r, "TODO"))
+
+	assert.Equal(t, http.StatusConflict, makeJSONRequest(r, "POST", "/api/task/"+plain.ID+"/skip", nil).Code)
+	assert.Equal(t, http.StatusOK, moveTask(r, next.ID, `{"status":"Done"}`).Code)
+	assert.Equal(t, http.StatusConflict, makeJSONRequest(r, "POST", "/api/task/"+next.ID+"/skip", nil).Code)
+	assert.Equal(t, http.StatusNotFound, makeJSONRequest(r, "POST", "/api/task/TASK-999/skip", nil).Code)
+}
+
+func TestDeleteRecurrenceHandler_EndsSeries(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	review := createRecurringTask(t, r, "Weekly review", monday, "FREQ=WEEKLY")
+	assert.Equal(t, http.StatusOK, moveTask(r, review.ID, `{"status":"Done"}`).Code)
+	next := occurrences(t, r, "Weekly review")[0]
+
+	w := makeJSONRequest(r, "DELETE", "/api/task/"+next.ID+"/recurrence", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var ended task.Task
+	json.Unmarshal(w.Body.Bytes(), &ended)
+	assert.Nil(t, ended.Recurrence)
+	assert.Nil(t, getTask(t, r, review.ID).Recurrence)
+
+	// Finishing it no longer schedules another
+	assert.Equal(t, http.StatusOK, moveTask(r, next.ID, `{"status":"Done"}`).Code)
+	assert.Len(t, occurrences(t, r, "Weekly review"), 2)
+
+	// Ending a series that isn't there is a no-op
+	w = makeJSONRequest(r, "DELETE", "/api/task/"+next.ID+"/recurrence", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCreateDueOccurrences_CreatesLatestMissedOccurrence(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	lastWeek := time.Now().UTC().AddDate(0, 0, -7).Truncate(time.Hour)
+	standup := createRecurringTask(t, r, "Standup notes", lastWeek, "FREQ=DAILY")
+	future := createRecurringTask(t, r, "Renew passport", time.Now().AddDate(1, 0, 0), "FREQ=MONTHLY")
+
+	created, err := mockRepo.CreateDueOccurrences()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), created)
+
+	series := occurrences(t, r, "Standup notes")
+	assert.Len(t, series, 2)
+	assert.WithinDuration(t, time.Now().Add(-12*time.Hour), *series[0].DueAt, 12*time.Hour)
+	assert.True(t, series[0].Recurrence.NextAt.After(time.Now()))
+	assert.Equal(t, standup.Recurrence.SeriesID, series[0].Recurrence.SeriesID)
+	assert.Len(t, occurrences(t, r, future.Title), 1)
+
+	// Nothing more is due until tomorrow
+	created, err = mockRepo.CreateDueOccurrences()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), created)
+}
+
+func TestRecurrence_RecordedInHistory(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	review := createRecurringTask(t, r, "Weekly review", monday, "FREQ=WEEKLY")
+	assert.Equal(t, http.StatusOK, moveTask(r, review.ID, `{"status":"Done"}`).Code)
+	next := occurrences(t, r, "Weekly review")[0]
+
+	events := getHistory(t, r, review.ID)
+	assert.Equal(t, history.ActionRecurrence, events[len(events)-2].Action)
+	assert.Contains(t, events[len(events)-2].Changes, "recurrence")
+
+	// A stale If-Match leaves the series alone
+	path := "/api/task/" + next.ID + "/recurrence?tz=UTC"
+	w := makeConditionalRequest(r, "PUT", path, `"99"`, []byte(`{"rule":"FREQ=DAILY"}`))
+	assertPreconditionFailed(t, w, next.Version)
+	w = makeConditionalRequest(r, "DELETE", path, `"99"`, nil)
+	assertPreconditionFailed(t, w, next.Version)
+
+	// Changing the rule changes every occurrence, each with an event
+	w = makeConditionalRequest(r, "PUT", path, taskETag(&next), []byte(`{"rule":"FREQ=DAILY"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, id := range []string{review.ID, next.ID} {
+		current := getTask(t, r, id)
+		assert.Equal(t, "FREQ=DAILY", current.Recurrence.Rule)
+		events := getHistory(t, r, id)
+		assert.Equal(t, history.ActionRecurrence, events[0].Action)
+		assert.Equal(t, current.Version, events[0].Version)
+	}
+
+	// Recurrence changes aren't undone
+	w = makeJSONRequest(r, "POST", "/api/undo", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "FREQ=DAILY", getTask(t, r, next.ID).Recurrence.Rule)
+}
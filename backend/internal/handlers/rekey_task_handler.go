@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RekeyTaskHandler handles POST /api/task/:id/rekey requests.
+// The task gets the next key under the requested prefix and its old key
+// keeps resolving through GET /api/task/:id.
+func RekeyTaskHandler(c *gin.Context) {
+	taskID := c.Param("id")
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+
+	var req struct {
+		Prefix string `json:"prefix"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	prefix := strings.ToUpper(strings.TrimSpace(req.Prefix))
+	if validationErrors := validateKeyPrefix(prefix); len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	rekeyedTask, err := repository.Tasks.RekeyTask(currentUserID(c), taskID, prefix, version)
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "task version conflict") {
+			respondPreconditionFailed(c, taskID)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rekey task"})
+		return
+	}
+
+	c.Header("ETag", taskETag(rekeyedTask))
+	c.JSON(http.StatusOK, rekeyedTask)
+}
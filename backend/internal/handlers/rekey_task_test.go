@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	task "tasker/internal/Task"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeRekeyRequest(r *gin.Engine, id string, prefix string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"prefix": prefix})
+	return makeJSONRequest(r, "POST", "/api/task/"+id+"/rekey", body)
+}
+
+func TestRekeyTaskHandler_Success(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	makePostRequest(r, marshalTaskBody("Fix the sink", "", "", ""))
+
+	w := makeRekeyRequest(r, "TASK-001", "home")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var rekeyed task.Task
+	json.Unmarshal(w.Body.Bytes(), &rekeyed)
+
+	assert.Equal(t, "HOME-001", rekeyed.ID)
+	assert.Equal(t, "Fix the sink", rekeyed.Title)
+
+	// The old key still resolves to the task under its new key
+	getW := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/task/TASK-001", nil)
+	r.ServeHTTP(getW, req)
+
+	assert.Equal(t, http.StatusOK, getW.Code)
+
+	var resolved task.Task
+	json.Unmarshal(getW.Body.Bytes(), &resolved)
+	assert.Equal(t, "HOME-001", resolved.ID)
+
+	// The old number is never handed out again
+	postW := makePostRequest(r, marshalTaskBody("Next task", "", "", ""))
+	var next task.Task
+	json.Unmarshal(postW.Body.Bytes(), &next)
+	assert.Equal(t, "TASK-002", next.ID)
+}
+
+func TestRekeyTaskHandler_ChainedAliases(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	makePostRequest(r, marshalTaskBody("Wandering task", "", "", ""))
+	makeRekeyRequest(r, "TASK-001", "HOME")
+	w := makeRekeyRequest(r, "HOME-001", "WORK")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, key := range []string{"TASK-001", "HOME-001", "WORK-001"} {
+		getW := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/task/"+key, nil)
+		r.ServeHTTP(getW, req)
+
+		var resolved task.Task
+		json.Unmarshal(getW.Body.Bytes(), &resolved)
+		assert.Equal(t, "WORK-001", resolved.ID, key)
+	}
+}
+
+func TestRekeyTaskHandler_SamePrefixIsNoop(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	makePostRequest(r, marshalTaskBody("Stay put", "", "", ""))
+
+	w := makeRekeyRequest(r, "TASK-001", "TASK")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var rekeyed task.Task
+	json.Unmarshal(w.Body.Bytes(), &rekeyed)
+	assert.Equal(t, "TASK-001", rekeyed.ID)
+}
+
+func TestRekeyTaskHandler_InvalidPrefix(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	makePostRequest(r, marshalTaskBody("Task", "", "", ""))
+
+	w := makeRekeyRequest(r, "TASK-001", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRekeyTaskHandler_TaskNotFound(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	w := makeRekeyRequest(r, "TASK-404", "HOME")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRekeyTaskHandler_IfMatch(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Fix the sink"})
+	body := []byte(`{"prefix":"HOME"}`)
+
+	// A stale version leaves the key alone
+	w := makeConditionalRequest(r, "POST", "/api/task/"+created.ID+"/rekey", `"99"`, body)
+	assertPreconditionFailed(t, w, created.Version)
+	assert.Equal(t, "TASK-001", getTask(t, r, created.ID).ID)
+
+	w = makeConditionalRequest(r, "POST", "/api/task/"+created.ID+"/rekey", taskETag(&created), body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "HOME-001", getTask(t, r, created.ID).ID)
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+	"tasker/internal/repository"
+	"tasker/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+)
+
+// undoWindow is how far back POST /api/undo reaches
+const undoWindow = 15 * time.Minute
+
+// rewoundTask puts fields, as returned by history.Rewind, on top of t
+func rewoundTask(t task.Task, fields map[string]json.RawMessage) task.Task {
+	applyTaskPatch(&t, taskPatch(fields))
+	json.Unmarshal(fields["rank"], &t.Rank)
+	return t
+}
+
+// validateRestore checks a task that is about to be put back the way it was.
+// Only what may have changed since is checked: that its board still has the
+// column and its project and parent still exist. Parents in restoring are
+// coming back in the same write.
+func validateRestore(c *gin.Context, t *task.Task, restoring map[string]bool, errors map[string]string) (*workflow.Workflow, bool) {
+	wf, ok := loadWorkflow(c, t.ProjectID)
+	if !ok {
+		return nil, false
+	}
+
+	validateStatus(wf, t.Status, errors)
+	t.StatusCategory = wf.Category(t.Status)
+	validateProjectReference(currentUserID(c), t.ProjectID, errors)
+	if t.ParentID == nil || !restoring[*t.ParentID] {
+		validateParentReference(currentUserID(c), t, errors)
+	}
+	return wf, true
+}
+
+// RevertTaskHandler handles POST /api/task/:id/revert?to=<version> requests by
+// writing the fields the task had at that version back over the current ones.
+// The revert is a write of its own, so it gets a new version and can be undone.
+func RevertTaskHandler(c *gin.Context) {
+	taskID := c.Param("id")
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+
+	to, err := strconv.ParseInt(c.Query("to"), 10, 64)
+	if err != nil || to < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"to": "to must be a version number"}})
+		return
+	}
+
+	current, err := repository.Tasks.GetTaskByID(currentUserID(c), taskID)
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+		return
+	}
+
+	if version != 0 && version != current.Version {
+		respondPreconditionFailed(c, taskID)
+		return
+	}
+	if to > current.Version {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"to": "task has no such version"}})
+		return
+	}
+	if to == current.Version {
+		c.Header("ETag", taskETag(current))
+		c.JSON(http.StatusOK, current)
+		return
+	}
+
+	events, err := repository.Tasks.GetTaskHistory(currentUserID(c), current.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task history"})
+		return
+	}
+
+	fields, ok := history.Rewind(history.Fields(current), events, to)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"to": "version is older than the task's history"}})
+		return
+	}
+	target := rewoundTask(*current, fields)
+
+	validationErrors := make(map[string]string)
+	wf, ok := validateRestore(c, &target, nil, validationErrors)
+	if !ok {
+		return
+	}
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "task can't be reverted to this version", "details": validationErrors})
+		return
+	}
+
+	var limit *repository.WIPLimit
+	entering, ok := entersColumn(c, *current, target, wf)
+	if ok && entering {
+		limit, ok = wipLimit(c, wf, current.ID, target.Status)
+	}
+	if !ok {
+		return
+	}
+
+	// Conditional on the version that was rewound from
+	target.Version = current.Version
+	reverted, err := repository.Tasks.RestoreTask(currentUserID(c), target, history.ActionRevert, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "task version conflict") {
+			respondPreconditionFailed(c, taskID)
+			return
+		}
+		if respondIfBadParent(c, err) || respondIfBlocked(c, err) || respondIfOverLimit(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revert task"})
+		return
+	}
+
+	c.Header("ETag", taskETag(reverted))
+	c.JSON(http.StatusOK, reverted)
+}
+
+// UndoHandler handles POST /api/undo requests by reversing the caller's last
+// edit, move, delete or revert made within undoWindow. Deleted tasks come back
+// under their old keys, subtasks included. Undoing puts back a state the tasks
+// were already in, so WIP limits and transition rules don't apply; an undo
+// can't be undone itself.
+func UndoHandler(c *gin.Context) {
+	events, err := repository.Tasks.GetUndoableMutation(currentUserID(c), undoWindow)
+	if err != nil {
+		if strings.Contains(err.Error(), "nothing to undo") {
+			c.JSON(http.StatusConflict, gin.H{"error": "nothing to undo"})
+			return
+		}
+		if message, ok := parentError(err); ok {
+			c.JSON(http.StatusConflict, gin.H{"error": "last change can't be undone", "details": gin.H{"parent_id": message}})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get last change"})
+		return
+	}
+
+	restoring := make(map[string]bool)
+	for _, e := range events {
+		if e.Action == history.ActionDelete {
+			restoring[e.TaskID] = true
+		}
+	}
+
+	targets := make([]task.Task, 0, len(events))
+	details := gin.H{}
+	for _, e := range events {
+		// Creates are left alone, such as the next occurrence a recurring
+		// task's completion created; it stays when the completion is undone
+		if e.Action == history.ActionCreate {
+			continue
+		}
+		target := task.Task{ID: e.TaskID}
+		var fields map[string]json.RawMessage
+
+		current, err := repository.Tasks.GetTaskByID(currentUserID(c), e.TaskID)
+		switch {
+		case err == nil:
+			target = *current
+			fields = history.Fields(current)
+		case !strings.Contains(err.Error(), "task not found"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+			return
+		case e.Action != history.ActionDelete:
+			details[e.TaskID] = gin.H{"id": "task has been deleted since"}
+			continue
+		}
+
+		fields, _ = history.Rewind(fields, []history.Event{e}, e.Version-1)
+		target = rewoundTask(target, fields)
+
+		validationErrors := make(map[string]string)
+		if _, ok := validateRestore(c, &target, restoring, validationErrors); !ok {
+			return
+		}
+		if len(validationErrors) > 0 {
+			details[e.TaskID] = validationErrors
+		}
+		targets = append(targets, target)
+	}
+
+	if len(details) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "last change can't be undone", "details": details})
+		return
+	}
+
+	restored, err := repository.Tasks.UndoMutation(currentUserID(c), events[0].MutationID, targets)
+	if err != nil {
+		if strings.Contains(err.Error(), "nothing to undo") {
+			c.JSON(http.StatusConflict, gin.H{"error": "nothing to undo"})
+			return
+		}
+		if message, ok := parentError(err); ok {
+			c.JSON(http.StatusConflict, gin.H{"error": "last change can't be undone", "details": gin.H{"parent_id": message}})
+			return
+		}
+		// A deleted task purged from the trash since can't come back
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusConflict, gin.H{"error": "last change can't be undone", "details": gin.H{"id": "task has been purged since"}})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to undo"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"action": events[0].Action, "tasks": restored})
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func revertTask(r *gin.Engine, id string, to int64) *httptest.ResponseRecorder {
+	return makeJSONRequest(r, "POST", "/api/task/"+id+"/revert?to="+strconv.FormatInt(to, 10), nil)
+}
+
+type undoResponse struct {
+	Action string      `json:"action"`
+	Tasks  []task.Task `json:"tasks"`
+}
+
+func undo(t *testing.T, r *gin.Engine) undoResponse {
+	w := makeJSONRequest(r, "POST", "/api/undo", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp undoResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestRevertTaskHandler_RestoresEarlierVersion(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Draft", "status": "TODO"})
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, created.ID, `{"title":"Outline"}`).Code)
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, created.ID, `{"priority":"High","description":"Notes","tags":["writing"]}`).Code)
+
+	w := revertTask(r, created.ID, 1)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var reverted task.Task
+	json.Unmarshal(w.Body.Bytes(), &reverted)
+	assert.Equal(t, "Draft", reverted.Title)
+	assert.Equal(t, "Medium", reverted.Priority)
+	assert.Empty(t, reverted.Description)
+	assert.Empty(t, reverted.Tags)
+	assert.Equal(t, int64(4), reverted.Version)
+	assert.Equal(t, taskETag(&reverted), w.Header().Get("ETag"))
+
+	events := getHistory(t, r, created.ID)
+	assert.Equal(t, history.ActionRevert, events[0].Action)
+
+	// Versions from before the revert are still there to go back to
+	assert.Equal(t, http.StatusOK, revertTask(r, created.ID, 3).Code)
+	got := getTask(t, r, created.ID)
+	assert.Equal(t, "Outline", got.Title)
+	assert.Equal(t, "High", got.Priority)
+	assert.Equal(t, []string{"writing"}, []string(got.Tags))
+}
+
+func TestRevertTaskHandler_PutsCardBack(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	bottom := createTestTask(t, r, map[string]any{"title": "Bottom", "status": "TODO"})
+	middle := createTestTask(t, r, map[string]any{"title": "Middle", "status": "TODO"})
+	createTestTask(t, r, map[string]any{"title": "Top", "status": "TODO"})
+
+	assert.Equal(t, http.StatusOK, moveTask(r, middle.ID, `{"status":"Done"}`).Code)
+	assert.Equal(t, http.StatusOK, moveTask(r, bottom.ID, `{"status":"Done"}`).Code)
+
+	assert.Equal(t, http.StatusOK, revertTask(r, middle.ID, middle.Version).Code)
+	assert.Equal(t, []string{"Top", "Middle"}, columnTitles(t, r, "TODO"))
+	assert.Equal(t, "todo", getTask(t, r, middle.ID).StatusCategory)
+}
+
+func TestRevertTaskHandler_Errors(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Draft", "status": "TODO"})
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, created.ID, `{"title":"Outline"}`).Code)
+
+	tests := []struct {
+		name  string
+		path  string
+		code  int
+		field string
+	}{
+		{"missing version", "/api/task/" + created.ID + "/revert", http.StatusBadRequest, "to"},
+		{"version zero", "/api/task/" + created.ID + "/revert?to=0", http.StatusBadRequest, "to"},
+		{"future version", "/api/task/" + created.ID + "/revert?to=3", http.StatusBadRequest, "to"},
+		{"unknown task", "/api/task/TASK-999/revert?to=1", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := makeJSONRequest(r, "POST", tt.path, nil)
+			assert.Equal(t, tt.code, w.Code)
+
+			if tt.field != "" {
+				var resp map[string]any
+				json.Unmarshal(w.Body.Bytes(), &resp)
+				details := resp["details"].(map[string]any)
+				assert.Contains(t, details, tt.field)
+			}
+		})
+	}
+
+	w := makeConditionalRequest(r, "POST", "/api/task/"+created.ID+"/revert?to=1", `"1"`, nil)
+	assertPreconditionFailed(t, w, 2)
+
+	// Reverting to the current version writes nothing
+	w = revertTask(r, created.ID, 2)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(2), getTask(t, r, created.ID).Version)
+}
+
+func TestRevertTaskHandler_RefusesColumnsThatAreGone(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	assert.Equal(t, http.StatusOK, putWorkflow(r, "", `{"columns":[
+		{"name":"TODO","category":"todo"},
+		{"name":"Review","category":"active"},
+		{"name":"Done","category":"done"}
+	]}`).Code)
+	created := createTestTask(t, r, map[string]any{"title": "Proofread", "status": "Review"})
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, created.ID, `{"status":"TODO"}`).Code)
+	assert.Equal(t, http.StatusOK, putWorkflow(r, "", limitedWorkflow).Code)
+
+	w := revertTask(r, created.ID, 1)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Contains(t, resp["details"], "status")
+	assert.Equal(t, "TODO", getTask(t, r, created.ID).Status)
+}
+
+func TestRevertTaskHandler_RefusesParentCycle(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	outer := createTestTask(t, r, map[string]any{"title": "Outer"})
+	inner := createSubtask(t, r, outer.ID, "Inner")
+
+	// Turn the tree upside down, so inner's first version would put it under
+	// its own subtask
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, inner.ID, `{"parent_id":null}`).Code)
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, outer.ID, fmt.Sprintf(`{"parent_id":%q}`, inner.ID)).Code)
+
+	w := revertTask(r, inner.ID, 1)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Contains(t, resp["details"], "parent_id")
+	assert.Nil(t, getTask(t, r, inner.ID).ParentID)
+}
+
+func TestRevertTaskHandler_RefusesCompletingBlockedTask(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	blocked := createTestTask(t, r, map[string]any{"title": "Ship release", "status": "TODO"})
+	blocker := createTestTask(t, r, map[string]any{"title": "Run tests", "status": "TODO"})
+	assert.Equal(t, http.StatusOK, moveTask(r, blocked.ID, `{"status":"Done"}`).Code)
+	assert.Equal(t, http.StatusOK, moveTask(r, blocked.ID, `{"status":"TODO"}`).Code)
+	assert.Equal(t, http.StatusOK, addDependency(r, blocked.ID, blocker.ID).Code)
+
+	w := revertTask(r, blocked.ID, 2)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), blocker.ID)
+	assert.Equal(t, "TODO", getTask(t, r, blocked.ID).Status)
+
+	assert.Equal(t, http.StatusOK, moveTask(r, blocker.ID, `{"status":"Done"}`).Code)
+	assert.Equal(t, http.StatusOK, revertTask(r, blocked.ID, 2).Code)
+	assert.Equal(t, "Done", getTask(t, r, blocked.ID).Status)
+}
+
+func TestUndoHandler_ReversesLastChange(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	first := createTestTask(t, r, map[string]any{"title": "First", "status": "TODO"})
+	second := createTestTask(t, r, map[string]any{"title": "Second", "status": "TODO"})
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, first.ID, `{"title":"First draft"}`).Code)
+	assert.Equal(t, http.StatusOK, moveTask(r, second.ID, `{"status":"Done"}`).Code)
+
+	resp := undo(t, r)
+	assert.Equal(t, history.ActionMove, resp.Action)
+	assert.Len(t, resp.Tasks, 1)
+	assert.Equal(t, "TODO", resp.Tasks[0].Status)
+	assert.Equal(t, []string{"Second", "First draft"}, columnTitles(t, r, "TODO"))
+
+	events := getHistory(t, r, second.ID)
+	assert.Equal(t, history.ActionUndo, events[0].Action)
+	assert.True(t, events[1].Undone)
+
+	// Each undo goes one change further back; creates are left alone
+	assert.Equal(t, history.ActionUpdate, undo(t, r).Action)
+	assert.Equal(t, "First", getTask(t, r, first.ID).Title)
+
+	w := makeJSONRequest(r, "POST", "/api/undo", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Len(t, columnTitles(t, r, "TODO"), 2)
+}
+
+func TestUndoHandler_RestoresDeletedTasks(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, map[string]any{"title": "Parent", "status": "TODO"})
+	child := createSubtask(t, r, parent.ID, "Child")
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, child.ID, `{"tags":["home"],"priority":"Low"}`).Code)
+
+	w := makeJSONRequest(r, "DELETE", "/api/task/"+parent.ID+"?subtasks=cascade", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	resp := undo(t, r)
+	assert.Equal(t, history.ActionDelete, resp.Action)
+	assert.Len(t, resp.Tasks, 2)
+
+	restored := getTask(t, r, child.ID)
+	assert.Equal(t, "Child", restored.Title)
+	assert.Equal(t, &parent.ID, restored.ParentID)
+	assert.Equal(t, []string{"home"}, []string(restored.Tags))
+	assert.Equal(t, "Low", restored.Priority)
+	assert.Equal(t, int64(3), restored.Version)
+	assert.Equal(t, "Parent", getTask(t, r, parent.ID).Title)
+}
+
+func TestUndoHandler_RefusesWhenDeletedTaskIsPurged(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Old receipts", "status": "TODO"})
+	w := makeJSONRequest(r, "DELETE", "/api/task/"+created.ID, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// A purge racing the undo takes the task after its delete was looked up
+	delete(mockRepo.trash, created.ID)
+
+	w = makeJSONRequest(r, "POST", "/api/undo", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "last change can't be undone")
+}
+
+func TestUndoHandler_OnlyReachesBackSoFar(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Old news", "status": "TODO"})
+	assert.Equal(t, http.StatusOK, moveTask(r, created.ID, `{"status":"Done"}`).Code)
+
+	for i := range mockRepo.events {
+		mockRepo.events[i].CreatedAt = time.Now().Add(-undoWindow - time.Minute)
+	}
+
+	w := makeJSONRequest(r, "POST", "/api/undo", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "Done", getTask(t, r, created.ID).Status)
+}
+
+func TestUndoHandler_RefusesWhenProjectIsGone(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	proj := createTestProject(t, r, "Garden")
+	created := createTestTask(t, r, map[string]any{"title": "Plant tulips", "project_id": proj.ID})
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, created.ID, `{"project_id":null}`).Code)
+	w := makeJSONRequest(r, "DELETE", "/api/projects/"+strconv.FormatInt(proj.ID, 10), nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = makeJSONRequest(r, "POST", "/api/undo", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Contains(t, resp["details"], created.ID)
+	assert.Nil(t, getTask(t, r, created.ID).ProjectID)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	task "tasker/internal/Task"
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validateParentReference checks that t.ParentID names a task and swaps an old
+// key for its current one. Whether t can sit there, neither under itself nor
+// too deep, is settled by the repository inside the write; see parentError.
+func validateParentReference(userID int64, t *task.Task, errors map[string]string) {
+	if t.ParentID == nil {
+		return
+	}
+
+	parent, err := repository.Tasks.GetTaskByID(userID, *t.ParentID)
+	if err != nil {
+		errors["parent_id"] = "parent task does not exist"
+		return
+	}
+	t.ParentID = &parent.ID
+}
+
+// parentError returns the parent_id validation message for a parent the
+// repository refused, or false for any other error
+func parentError(err error) (string, bool) {
+	switch {
+	case strings.Contains(err.Error(), "parent not found"):
+		return "parent task does not exist", true
+	case strings.Contains(err.Error(), "parent cycle"):
+		return "a task cannot be nested under itself or one of its subtasks", true
+	case strings.Contains(err.Error(), "parent too deep"):
+		return fmt.Sprintf("subtasks can be nested at most %d levels deep", repository.MaxTaskDepth), true
+	}
+	return "", false
+}
+
+// respondIfBadParent answers 400 when the repository refused the task's parent
+func respondIfBadParent(c *gin.Context, err error) bool {
+	message, ok := parentError(err)
+	if ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"parent_id": message}})
+	}
+	return ok
+}
+
+// getParentTask loads the task named in the :id path parameter, responding with 404 when it is missing
+func getParentTask(c *gin.Context) (*task.Task, bool) {
+	parent, err := repository.Tasks.GetTaskByID(currentUserID(c), c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+		return nil, false
+	}
+	return parent, true
+}
+
+// GetSubtasksHandler handles GET /api/task/:id/subtasks requests. It lists the
+// direct subtasks and takes the same query parameters as GET /api/task.
+func GetSubtasksHandler(c *gin.Context) {
+	parent, ok := getParentTask(c)
+	if !ok {
+		return
+	}
+
+	opts, validationErrors := parseListOptions(c, "-"+repository.DefaultTaskSort)
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+	opts.ParentID = &parent.ID
+
+	respondTaskList(c, opts)
+}
+
+// PostSubtaskHandler handles POST /api/task/:id/subtasks requests. The subtask
+// takes its parent's key prefix and project unless the body names others.
+func PostSubtaskHandler(c *gin.Context) {
+	parent, ok := getParentTask(c)
+	if !ok {
+		return
+	}
+
+	var req createTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	req.ParentID = &parent.ID
+	if strings.TrimSpace(req.Prefix) == "" {
+		req.Prefix = task.KeyPrefix(parent.ID)
+	}
+	if req.ProjectID == nil {
+		req.ProjectID = parent.ProjectID
+	}
+
+	createTask(c, req)
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	task "tasker/internal/Task"
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func createSubtask(t *testing.T, r *gin.Engine, parentID string, title string) task.Task {
+	body, _ := json.Marshal(map[string]any{"title": title})
+	w := makeJSONRequest(r, "POST", "/api/task/"+parentID+"/subtasks", body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created task.Task
+	json.Unmarshal(w.Body.Bytes(), &created)
+	return created
+}
+
+func getTask(t *testing.T, r *gin.Engine, id string) task.Task {
+	w := makeJSONRequest(r, "GET", "/api/task/"+id, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got task.Task
+	json.Unmarshal(w.Body.Bytes(), &got)
+	return got
+}
+
+func TestPostSubtaskHandler_InheritsPrefixAndProject(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	p := createTestProject(t, r, "Home")
+	body, _ := json.Marshal(map[string]any{"title": "Move house", "prefix": "HOME", "project_id": p.ID})
+	w := makePostRequest(r, body)
+	var parent task.Task
+	json.Unmarshal(w.Body.Bytes(), &parent)
+
+	child := createSubtask(t, r, parent.ID, "Pack books")
+
+	assert.Equal(t, "HOME-002", child.ID)
+	assert.Equal(t, parent.ID, *child.ParentID)
+	assert.Equal(t, p.ID, *child.ProjectID)
+	assert.Nil(t, child.Progress)
+}
+
+func TestPostSubtaskHandler_ParentNotFound(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	w := makeJSONRequest(r, "POST", "/api/task/TASK-404/subtasks", []byte(`{"title":"Orphan"}`))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetSubtasksHandler(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, originalTask)
+	first := createSubtask(t, r, parent.ID, "Step one")
+	createSubtask(t, r, parent.ID, "Step two")
+	createSubtask(t, r, first.ID, "Step one, part a")
+	makePostRequest(r, marshalTaskBody("Unrelated", "", "", ""))
+
+	w := makeJSONRequest(r, "GET", "/api/task/"+parent.ID+"/subtasks?sort=title", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var subtasks []task.Task
+	json.Unmarshal(w.Body.Bytes(), &subtasks)
+	assert.Equal(t, []string{"Step one", "Step two"}, taskTitles(subtasks))
+	assert.Equal(t, &task.Progress{Done: 0, Total: 1, Percent: 0}, subtasks[0].Progress)
+
+	w = makeJSONRequest(r, "GET", "/api/task/TASK-404/subtasks", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTaskProgress_RollsUpDoneSubtasks(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, originalTask)
+	first := createSubtask(t, r, parent.ID, "Step one")
+	createSubtask(t, r, parent.ID, "Step two")
+	createSubtask(t, r, parent.ID, "Step three")
+
+	makePatchRequest(r, first.ID, `{"status":"Done"}`)
+
+	assert.Equal(t, &task.Progress{Done: 1, Total: 3, Percent: 33}, getTask(t, r, parent.ID).Progress)
+}
+
+func TestPatchTaskHandler_Reparent(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, originalTask)
+	other := createTestTask(t, r, originalTask)
+	child := createSubtask(t, r, parent.ID, "Step one")
+
+	w := makePatchRequest(r, child.ID, fmt.Sprintf(`{"parent_id":%q}`, other.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, getTask(t, r, parent.ID).Progress)
+	assert.Equal(t, 1, getTask(t, r, other.ID).Progress.Total)
+
+	w = makePatchRequest(r, child.ID, `{"parent_id":null}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, getTask(t, r, child.ID).ParentID)
+}
+
+func TestParentReference_RejectsCycles(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	root := createTestTask(t, r, originalTask)
+	child := createSubtask(t, r, root.ID, "Child")
+	grandchild := createSubtask(t, r, child.ID, "Grandchild")
+
+	tests := []struct {
+		name     string
+		id       string
+		parentID string
+	}{
+		{"own parent", root.ID, root.ID},
+		{"under its child", root.ID, child.ID},
+		{"under its grandchild", root.ID, grandchild.ID},
+		{"unknown parent", child.ID, "TASK-404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := makePatchRequest(r, tt.id, fmt.Sprintf(`{"parent_id":%q}`, tt.parentID))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response map[string]any
+			json.Unmarshal(w.Body.Bytes(), &response)
+			assert.Contains(t, response["details"], "parent_id")
+		})
+	}
+
+	body, _ := json.Marshal(map[string]any{"title": "Root", "status": "TODO", "priority": "Low", "parent_id": grandchild.ID})
+	w := makeJSONRequest(r, "PUT", "/api/task/"+root.ID, body)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestParentReference_CapsDepth(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	deepest := createTestTask(t, r, originalTask)
+	for range repository.MaxTaskDepth - 1 {
+		deepest = createSubtask(t, r, deepest.ID, "Level")
+	}
+
+	w := makeJSONRequest(r, "POST", "/api/task/"+deepest.ID+"/subtasks", []byte(`{"title":"Too deep"}`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Moving a two-level subtree under a level-4 task would also go too deep
+	branch := createTestTask(t, r, originalTask)
+	createSubtask(t, r, branch.ID, "Branch child")
+	levelFour := getTask(t, r, *deepest.ParentID)
+	w = makePatchRequest(r, branch.ID, fmt.Sprintf(`{"parent_id":%q}`, levelFour.ID))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = makePatchRequest(r, branch.ID, fmt.Sprintf(`{"parent_id":%q}`, *levelFour.ParentID))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestDeleteTaskHandler_RejectsWhileSubtasksExist(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, originalTask)
+	child := createSubtask(t, r, parent.ID, "Step one")
+
+	w := makeDeleteRequest(r, parent.ID)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = makeDeleteRequest(r, child.ID)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = makeDeleteRequest(r, parent.ID)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestDeleteTaskHandler_CascadeDeletesSubtree(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, originalTask)
+	child := createSubtask(t, r, parent.ID, "Step one")
+	createSubtask(t, r, child.ID, "Step one, part a")
+	createTestTask(t, r, originalTask)
+
+	w := makeJSONRequest(r, "DELETE", "/api/task/"+parent.ID+"?subtasks=cascade", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Len(t, getTasks(r, ""), 1)
+
+	w = makeJSONRequest(r, "DELETE", "/api/task/"+parent.ID+"?subtasks=orphan", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"tasker/internal/repository"
+	"tasker/internal/tag"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTagsPerTask keeps a single task from being buried in labels
+const maxTagsPerTask = 20
+
+// validateTagName returns a problem with name, or "" when it is usable.
+// Commas are reserved because tag filters are comma-separated.
+func validateTagName(name string) string {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "tag names cannot be empty"
+	case utf8.RuneCountInString(name) > 50:
+		return "tag names must be at most 50 characters"
+	case strings.Contains(name, ","):
+		return "tag names cannot contain commas"
+	}
+	return ""
+}
+
+// normalizeTags trims tag names and drops repeats that differ only in case
+func normalizeTags(names []string) []string {
+	result := make([]string, 0, len(names))
+	seen := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if lowered := strings.ToLower(name); !slices.Contains(seen, lowered) {
+			seen = append(seen, lowered)
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// validateTaskTags adds a tags error when a task's tag list can't be saved
+func validateTaskTags(names []string, errors map[string]string) {
+	for _, name := range names {
+		if problem := validateTagName(name); problem != "" {
+			errors["tags"] = problem
+			return
+		}
+	}
+	if len(normalizeTags(names)) > maxTagsPerTask {
+		errors["tags"] = "a task can have at most 20 tags"
+	}
+}
+
+func validateTag(t tag.Tag) map[string]string {
+	errors := make(map[string]string)
+
+	if problem := validateTagName(t.Name); problem != "" {
+		errors["name"] = problem
+	}
+
+	if t.ColorHex != "" && !colorHexPattern.MatchString(t.ColorHex) {
+		errors["color_hex"] = "color_hex must look like #RRGGBB"
+	}
+
+	return errors
+}
+
+// validateTagUpdate validates only the fields that are being updated (non-empty)
+func validateTagUpdate(t tag.Tag) map[string]string {
+	errors := make(map[string]string)
+
+	if t.Name != "" {
+		if problem := validateTagName(t.Name); problem != "" {
+			errors["name"] = problem
+		}
+	}
+
+	if t.ColorHex != "" && !colorHexPattern.MatchString(t.ColorHex) {
+		errors["color_hex"] = "color_hex must look like #RRGGBB"
+	}
+
+	return errors
+}
+
+func parseTagID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+		return 0, false
+	}
+	return id, true
+}
+
+// GetTagsHandler returns all tags with how many tasks use each
+func GetTagsHandler(c *gin.Context) {
+	tags, err := repository.Tags.GetAllTags(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// GetTagHandler handles GET /api/tags/:id requests
+func GetTagHandler(c *gin.Context) {
+	id, ok := parseTagID(c)
+	if !ok {
+		return
+	}
+
+	t, err := repository.Tags.GetTagByID(currentUserID(c), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "tag not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get tag"})
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// PostTagHandler creates a new tag. Tags are also created on the fly when a task uses a new name.
+func PostTagHandler(c *gin.Context) {
+	var newTag tag.Tag
+	if err := c.ShouldBindJSON(&newTag); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if validationErrors := validateTag(newTag); len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	newTag.Name = strings.TrimSpace(newTag.Name)
+	if newTag.ColorHex == "" {
+		newTag.ColorHex = "#6B7280"
+	}
+
+	createdTag, err := repository.Tags.CreateTag(currentUserID(c), newTag)
+	if err != nil {
+		if strings.Contains(err.Error(), "tag already exists") {
+			c.JSON(http.StatusConflict, gin.H{"error": "tag already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save tag"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdTag)
+}
+
+// PutTagHandler handles PUT /api/tags/:id requests. Renaming a tag renames it on every task.
+func PutTagHandler(c *gin.Context) {
+	id, ok := parseTagID(c)
+	if !ok {
+		return
+	}
+
+	var t tag.Tag
+	if err := c.ShouldBindJSON(&t); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if validationErrors := validateTagUpdate(t); len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	t.Name = strings.TrimSpace(t.Name)
+	updatedTag, err := repository.Tags.UpdateTag(currentUserID(c), id, t)
+	if err != nil {
+		if strings.Contains(err.Error(), "tag not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "tag already exists") {
+			c.JSON(http.StatusConflict, gin.H{"error": "tag already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update tag"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedTag)
+}
+
+// DeleteTagHandler handles DELETE /api/tags/:id requests. The tag is removed from every task.
+func DeleteTagHandler(c *gin.Context) {
+	id, ok := parseTagID(c)
+	if !ok {
+		return
+	}
+
+	if err := repository.Tags.DeleteTag(currentUserID(c), id); err != nil {
+		if strings.Contains(err.Error(), "tag not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete tag"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+	"tasker/internal/tag"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTags(t *testing.T, r *gin.Engine) []tag.Tag {
+	w := makeJSONRequest(r, "GET", "/api/tags", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var tags []tag.Tag
+	json.Unmarshal(w.Body.Bytes(), &tags)
+	return tags
+}
+
+func TestPostTagHandler_Success(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	w := makeJSONRequest(r, "POST", "/api/tags", []byte(`{"name":" urgent ","color_hex":"#FF0000"}`))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created tag.Tag
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.Equal(t, "urgent", created.Name)
+	assert.Equal(t, "#FF0000", created.ColorHex)
+
+	w = makeJSONRequest(r, "POST", "/api/tags", []byte(`{"name":"plain"}`))
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.Equal(t, "#6B7280", created.ColorHex)
+}
+
+func TestPostTagHandler_DuplicateIgnoresCase(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	makeJSONRequest(r, "POST", "/api/tags", []byte(`{"name":"Urgent"}`))
+	w := makeJSONRequest(r, "POST", "/api/tags", []byte(`{"name":"URGENT"}`))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestPostTagHandler_ValidationErrors(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"empty name", `{"name":"  "}`, "name"},
+		{"comma in name", `{"name":"a,b"}`, "name"},
+		{"long name", `{"name":"` + strings.Repeat("x", 51) + `"}`, "name"},
+		{"bad color", `{"name":"ok","color_hex":"red"}`, "color_hex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := makeJSONRequest(r, "POST", "/api/tags", []byte(tt.body))
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response map[string]any
+			json.Unmarshal(w.Body.Bytes(), &response)
+			details := response["details"].(map[string]any)
+			assert.Contains(t, details, tt.field)
+		})
+	}
+}
+
+func TestTaskTags_CreatedOnAssignment(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Pay rent", "tags": []string{"home", " Bills ", "HOME"}})
+
+	assert.Equal(t, []string{"Bills", "home"}, []string(created.Tags))
+
+	// A later task reuses the stored spelling
+	second := createTestTask(t, r, map[string]any{"title": "Pay gas", "tags": []string{"bills"}})
+	assert.Equal(t, []string{"Bills"}, []string(second.Tags))
+
+	tags := getTags(t, r)
+	assert.Len(t, tags, 2)
+	assert.Equal(t, "Bills", tags[0].Name)
+	assert.Equal(t, 2, tags[0].TaskCount)
+	assert.Equal(t, 1, tags[1].TaskCount)
+}
+
+func TestTaskTags_PatchAndPut(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Pay rent", "tags": []string{"home"}})
+
+	w := makePatchRequest(r, created.ID, `{"title":"Pay the rent"}`)
+	var patched task.Task
+	json.Unmarshal(w.Body.Bytes(), &patched)
+	assert.Equal(t, []string{"home"}, []string(patched.Tags), "tags are untouched when the key is absent")
+
+	w = makePatchRequest(r, created.ID, `{"tags":["work","home"]}`)
+	json.Unmarshal(w.Body.Bytes(), &patched)
+	assert.Equal(t, []string{"home", "work"}, []string(patched.Tags))
+
+	w = makePatchRequest(r, created.ID, `{"tags":null}`)
+	json.Unmarshal(w.Body.Bytes(), &patched)
+	assert.Empty(t, patched.Tags)
+
+	w = makeJSONRequest(r, "PUT", "/api/task/"+created.ID, []byte(`{"title":"Pay rent","status":"TODO","priority":"High","tags":["money"]}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var replaced task.Task
+	json.Unmarshal(w.Body.Bytes(), &replaced)
+	assert.Equal(t, []string{"money"}, []string(replaced.Tags))
+}
+
+func TestTaskTags_Validation(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Pay rent"})
+
+	tooMany := make([]string, maxTagsPerTask+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("t", i+1)
+	}
+	body, _ := json.Marshal(map[string]any{"title": "Busy", "tags": tooMany})
+	assert.Equal(t, http.StatusBadRequest, makePostRequest(r, body).Code)
+
+	assert.Equal(t, http.StatusBadRequest, makePostRequest(r, []byte(`{"title":"Busy","tags":["a,b"]}`)).Code)
+	assert.Equal(t, http.StatusBadRequest, makePatchRequest(r, created.ID, `{"tags":"home"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, makePatchRequest(r, created.ID, `{"tags":[""]}`).Code)
+}
+
+func TestPutTagHandler_RenamePropagatesToTasks(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Pay rent", "tags": []string{"home", "bills"}})
+	tags := getTags(t, r)
+	homeID := tags[1].ID
+
+	w := makeJSONRequest(r, "PUT", fmt.Sprintf("/api/tags/%d", homeID), []byte(`{"name":"Household"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	tasks := getTasks(r, "")
+	assert.Equal(t, []string{"bills", "Household"}, []string(tasks[0].Tags))
+	assert.Greater(t, tasks[0].Version, created.Version)
+
+	events := getHistory(t, r, created.ID)
+	assert.Equal(t, history.ActionUpdate, events[0].Action)
+	assert.Equal(t, tasks[0].Version, events[0].Version)
+	before, after := change(events[0], "tags")
+	assert.Equal(t, `["bills","home"]`, before)
+	assert.Equal(t, `["bills","Household"]`, after)
+
+	// A new colour doesn't change the tasks
+	w = makeJSONRequest(r, "PUT", fmt.Sprintf("/api/tags/%d", homeID), []byte(`{"color_hex":"#336699"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, tasks[0].Version, getTasks(r, "")[0].Version)
+	assert.Len(t, getHistory(t, r, created.ID), len(events))
+
+	w = makeJSONRequest(r, "PUT", fmt.Sprintf("/api/tags/%d", homeID), []byte(`{"name":"BILLS"}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = makeJSONRequest(r, "PUT", "/api/tags/999", []byte(`{"name":"other"}`))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeleteTagHandler_RemovesFromTasks(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Pay rent", "tags": []string{"home", "bills"}})
+	tags := getTags(t, r)
+
+	w := makeJSONRequest(r, "DELETE", fmt.Sprintf("/api/tags/%d", tags[0].ID), nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	tasks := getTasks(r, "")
+	assert.Equal(t, []string{"home"}, []string(tasks[0].Tags))
+	assert.Greater(t, tasks[0].Version, created.Version)
+
+	events := getHistory(t, r, created.ID)
+	assert.Equal(t, history.ActionUpdate, events[0].Action)
+	before, after := change(events[0], "tags")
+	assert.Equal(t, `["bills","home"]`, before)
+	assert.Equal(t, `["home"]`, after)
+
+	w = makeJSONRequest(r, "DELETE", fmt.Sprintf("/api/tags/%d", tags[0].ID), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetTaskHandler_FilterByTags(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	createTestTask(t, r, map[string]any{"title": "Pay rent", "tags": []string{"home", "bills"}})
+	createTestTask(t, r, map[string]any{"title": "Fix sink", "tags": []string{"home"}})
+	createTestTask(t, r, map[string]any{"title": "Submit report", "tags": []string{"work"}})
+
+	tests := []struct {
+		name   string
+		query  string
+		titles []string
+	}{
+		{"any by default", "?tags=bills,work&sort=title", []string{"Pay rent", "Submit report"}},
+		{"ignores case", "?tags=HOME&sort=title", []string{"Fix sink", "Pay rent"}},
+		{"all", "?tags=home,bills&tags_match=all", []string{"Pay rent"}},
+		{"unknown tag", "?tags=nope", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks, w := listTasks(t, r, tt.query)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.titles, taskTitles(tasks))
+		})
+	}
+
+	_, w := listTasks(t, r, "?tags=home&tags_match=some")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
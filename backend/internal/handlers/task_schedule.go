@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/repository"
+	"tasker/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dueViews are the shortcuts accepted by ?due= on the task list
+var dueViews = []string{"overdue", "today", "this_week", "none"}
+
+// maxEstimateHours is the largest estimate a single task can carry
+const maxEstimateHours = 10000
+
+// validateSchedule adds a start_at error when a task would start after it is due,
+// and an estimate_hours error for estimates out of range
+func validateSchedule(t task.Task, errors map[string]string) {
+	if t.StartAt != nil && t.DueAt != nil && t.StartAt.After(*t.DueAt) {
+		errors["start_at"] = "start_at cannot be after due_at"
+	}
+	if t.EstimateHours != nil && (*t.EstimateHours < 0 || *t.EstimateHours > maxEstimateHours) {
+		errors["estimate_hours"] = "estimate_hours must be between 0 and 10000"
+	}
+}
+
+// validateTimezone reports whether name is an IANA zone such as Europe/Berlin
+func validateTimezone(name string) bool {
+	_, err := time.LoadLocation(name)
+	return name != "" && err == nil
+}
+
+// requestLocation picks the zone for date views: ?tz= when given, otherwise the user's setting
+func requestLocation(c *gin.Context) (*time.Location, error) {
+	name := c.Query("tz")
+	if name == "" {
+		u, err := repository.Users.GetUserByID(currentUserID(c))
+		if err != nil {
+			return nil, err
+		}
+		name = u.Timezone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" {
+		return nil, errors.New("unknown time zone: " + name)
+	}
+	return loc, nil
+}
+
+// startOfDay returns midnight of t's calendar day in loc
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// applyDueView narrows opts to one of the dueViews as seen at now in loc.
+// Days and weeks follow the calendar in loc; weeks start on Monday.
+func applyDueView(opts *repository.ListOptions, view string, now time.Time, loc *time.Location) {
+	switch view {
+	case "overdue":
+		// Finished work is never overdue
+		opts.DueBefore = &now
+		opts.ExcludeCategories = append(opts.ExcludeCategories, workflow.CategoryDone)
+	case "today":
+		start := startOfDay(now, loc)
+		end := start.AddDate(0, 0, 1)
+		opts.DueAfter, opts.DueBefore = &start, &end
+	case "this_week":
+		today := startOfDay(now, loc)
+		start := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		end := start.AddDate(0, 0, 7)
+		opts.DueAfter, opts.DueBefore = &start, &end
+	case "none":
+		opts.NoDueDate = true
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/repository"
+	"tasker/internal/user"
+	"tasker/internal/workflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostTaskHandler_Schedule(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	w := makePostRequest(r, []byte(`{"title": "File taxes", "start_at": "2026-03-01T09:00:00Z", "due_at": "2026-04-15T17:00:00-04:00"}`))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created task.Task
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.True(t, created.StartAt.Equal(time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, created.DueAt.Equal(time.Date(2026, 4, 15, 21, 0, 0, 0, time.UTC)))
+}
+
+func TestPostTaskHandler_StartAfterDue(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	w := makePostRequest(r, []byte(`{"title": "File taxes", "start_at": "2026-05-01T00:00:00Z", "due_at": "2026-04-15T00:00:00Z"}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "start_at cannot be after due_at")
+}
+
+func TestPatchTaskHandler_Schedule(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, originalTask)
+
+	w := makePatchRequest(r, created.ID, `{"due_at": "2026-04-15T00:00:00Z"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The check runs against the merged task, not just the fields in the patch
+	w = makePatchRequest(r, created.ID, `{"start_at": "2026-05-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "start_at")
+
+	w = makePatchRequest(r, created.ID, `{"due_at": "next tuesday"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = makePatchRequest(r, created.ID, `{"due_at": null, "start_at": "2026-05-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response task.Task
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Nil(t, response.DueAt)
+	assert.NotNil(t, response.StartAt)
+}
+
+func TestApplyDueView(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+	// Wednesday 1 July 2026, 23:30 in New York is already Thursday in UTC
+	now := time.Date(2026, 7, 1, 23, 30, 0, 0, newYork)
+
+	tests := []struct {
+		view       string
+		loc        *time.Location
+		dueAfter   time.Time
+		dueBefore  time.Time
+		checkRange bool
+	}{
+		{"today", newYork, time.Date(2026, 7, 1, 0, 0, 0, 0, newYork), time.Date(2026, 7, 2, 0, 0, 0, 0, newYork), true},
+		{"today", time.UTC, time.Date(2026, 7, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC), true},
+		{"this_week", newYork, time.Date(2026, 6, 29, 0, 0, 0, 0, newYork), time.Date(2026, 7, 6, 0, 0, 0, 0, newYork), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.view+" in "+tt.loc.String(), func(t *testing.T) {
+			var opts repository.ListOptions
+			applyDueView(&opts, tt.view, now, tt.loc)
+
+			assert.True(t, opts.DueAfter.Equal(tt.dueAfter), "due after %v, want %v", opts.DueAfter, tt.dueAfter)
+			assert.True(t, opts.DueBefore.Equal(tt.dueBefore), "due before %v, want %v", opts.DueBefore, tt.dueBefore)
+		})
+	}
+
+	var overdue repository.ListOptions
+	applyDueView(&overdue, "overdue", now, newYork)
+	assert.True(t, overdue.DueBefore.Equal(now))
+	assert.Nil(t, overdue.DueAfter)
+	assert.Equal(t, []string{workflow.CategoryDone}, overdue.ExcludeCategories)
+
+	var none repository.ListOptions
+	applyDueView(&none, "none", now, newYork)
+	assert.True(t, none.NoDueDate)
+}
+
+func TestApplyDueView_WeekStartsMondayAcrossDST(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	// Sunday 29 March 2026 is the day clocks go forward in Berlin
+	now := time.Date(2026, 3, 29, 12, 0, 0, 0, berlin)
+
+	var opts repository.ListOptions
+	applyDueView(&opts, "this_week", now, berlin)
+
+	assert.True(t, opts.DueAfter.Equal(time.Date(2026, 3, 23, 0, 0, 0, 0, berlin)))
+	assert.True(t, opts.DueBefore.Equal(time.Date(2026, 3, 30, 0, 0, 0, 0, berlin)))
+}
+
+func TestGetTaskHandler_DueViews(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	// Halfway between now and midnight is still due today but not yet overdue
+	now := time.Now().UTC()
+	today := now.Add(startOfDay(now, time.UTC).Add(24*time.Hour).Sub(now) / 2)
+	tomorrow := today.Add(24 * time.Hour)
+	lastWeek := today.Add(-7 * 24 * time.Hour)
+
+	createTestTask(t, r, map[string]any{"title": "Due today", "status": "TODO", "due_at": today.Format(time.RFC3339)})
+	createTestTask(t, r, map[string]any{"title": "Due tomorrow", "status": "TODO", "due_at": tomorrow.Format(time.RFC3339)})
+	createTestTask(t, r, map[string]any{"title": "Late", "status": "In Progress", "due_at": lastWeek.Format(time.RFC3339)})
+	createTestTask(t, r, map[string]any{"title": "Late but done", "status": "Done", "due_at": lastWeek.Format(time.RFC3339)})
+	createTestTask(t, r, map[string]any{"title": "Someday", "status": "TODO"})
+
+	tests := []struct {
+		query  string
+		titles []string
+	}{
+		{"?due=overdue&tz=UTC&sort=title", []string{"Late"}},
+		{"?due=today&tz=UTC&sort=title", []string{"Due today"}},
+		{"?due=none&tz=UTC", []string{"Someday"}},
+		{"?due_after=" + tomorrow.Format(time.DateOnly) + "&sort=title", []string{"Due tomorrow"}},
+		{"?sort=due_at", []string{"Late", "Late but done", "Due today", "Due tomorrow", "Someday"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			tasks, w := listTasks(t, r, tt.query)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.titles, taskTitles(tasks))
+		})
+	}
+}
+
+func TestGetTaskHandler_DueViewUsesUserTimezone(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	mockUserRepo.CreateUser(user.User{Email: "sam@example.com", Timezone: "Pacific/Kiritimati"})
+
+	// Kiritimati (UTC+14) and Pago Pago (UTC-11) are one or two dates apart
+	// depending on the hour; pick a moment that is only today on Kiritimati
+	kiritimati, _ := time.LoadLocation("Pacific/Kiritimati")
+	pagoPago, _ := time.LoadLocation("Pacific/Pago_Pago")
+	now := time.Now()
+	dueAt := startOfDay(now, kiritimati).Add(time.Minute)
+	if pagoStart := startOfDay(now, pagoPago); !dueAt.Before(pagoStart) {
+		dueAt = pagoStart.Add(24 * time.Hour)
+	}
+	createTestTask(t, r, map[string]any{"title": "Early on Kiritimati", "status": "TODO", "due_at": dueAt.Format(time.RFC3339)})
+
+	tasks, w := listTasks(t, r, "?due=today")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"Early on Kiritimati"}, taskTitles(tasks))
+
+	// ?tz= overrides the user's setting
+	tasks, _ = listTasks(t, r, "?due=today&tz=Pacific/Pago_Pago")
+	assert.Empty(t, tasks)
+}
+
+func TestGetTaskHandler_InvalidDueParams(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	tests := []struct {
+		query string
+		field string
+	}{
+		{"?due=tomorrow", "due"},
+		{"?due=today&tz=Mars/Olympus_Mons", "tz"},
+		{"?due=today&tz=UTC&due_before=2026-01-01", "due"},
+		{"?due_after=soon", "due_after"},
+		{"?sort=start_at", "sort"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, w := listTasks(t, r, tt.query)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response map[string]any
+			json.Unmarshal(w.Body.Bytes(), &response)
+			details, _ := response["details"].(map[string]any)
+			assert.Contains(t, details, tt.field)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	task "tasker/internal/Task"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeConditionalRequest(r *gin.Engine, method string, path string, ifMatch string, body []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+var versionedTask = map[string]any{"title": "Versioned", "description": "Original", "status": "TODO", "priority": "Medium"}
+
+// assertPreconditionFailed checks for a 412 that carries the server's current copy
+func assertPreconditionFailed(t *testing.T, w *httptest.ResponseRecorder, currentVersion int64) {
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	var response struct {
+		Error   string    `json:"error"`
+		Current task.Task `json:"current"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	assert.Equal(t, "task has been modified", response.Error)
+	assert.Equal(t, currentVersion, response.Current.Version)
+	assert.Equal(t, taskETag(&response.Current), w.Header().Get("ETag"))
+}
+
+func TestGetTaskByIDHandler_ReturnsETag(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	body, _ := json.Marshal(versionedTask)
+	w := makePostRequest(r, body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	var created task.Task
+	json.Unmarshal(w.Body.Bytes(), &created)
+	w = makeConditionalRequest(r, "GET", "/api/task/"+created.ID, "", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), `"version":1`)
+}
+
+func TestGetTaskHandler_ListETagChangesOnWrite(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, versionedTask)
+
+	before := makeConditionalRequest(r, "GET", "/api/task", "", nil).Header().Get("ETag")
+	assert.NotEmpty(t, before)
+	assert.Equal(t, before, makeConditionalRequest(r, "GET", "/api/task", "", nil).Header().Get("ETag"))
+
+	makePatchRequest(r, created.ID, `{"status": "Done"}`)
+
+	after := makeConditionalRequest(r, "GET", "/api/task", "", nil).Header().Get("ETag")
+	assert.NotEqual(t, before, after)
+}
+
+func TestPutTaskHandler_IfMatch(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, versionedTask)
+	body := marshalTaskBody("First tab", "", "In Progress", "Medium")
+
+	w := makeConditionalRequest(r, "PUT", "/api/task/"+created.ID, `"1"`, body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	// The second tab still holds version 1
+	w = makeConditionalRequest(r, "PUT", "/api/task/"+created.ID, `"1"`, marshalTaskBody("Second tab", "", "Done", "Medium"))
+	assertPreconditionFailed(t, w, 2)
+
+	stored, _ := mockRepo.GetTaskByID(testUserID, created.ID)
+	assert.Equal(t, "First tab", stored.Title)
+}
+
+func TestPutTaskHandler_WithoutIfMatchIsUnconditional(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, versionedTask)
+	makePatchRequest(r, created.ID, `{"status": "Done"}`)
+
+	// A stale version in the body is ignored; only If-Match is a precondition
+	body, _ := json.Marshal(map[string]any{"title": "Replaced", "status": "TODO", "priority": "Low", "version": 1})
+	w := makeConditionalRequest(r, "PUT", "/api/task/"+created.ID, "", body)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+}
+
+func TestPatchTaskHandler_IfMatch(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, versionedTask)
+
+	w := makeConditionalRequest(r, "PATCH", "/api/task/"+created.ID, `"1"`, []byte(`{"status": "In Progress"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = makeConditionalRequest(r, "PATCH", "/api/task/"+created.ID, `"1"`, []byte(`{"status": "Done"}`))
+	assertPreconditionFailed(t, w, 2)
+
+	w = makeConditionalRequest(r, "PATCH", "/api/task/"+created.ID, "*", []byte(`{"status": "Done"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestDeleteTaskHandler_IfMatch(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, versionedTask)
+	makePatchRequest(r, created.ID, `{"status": "Done"}`)
+
+	w := makeConditionalRequest(r, "DELETE", "/api/task/"+created.ID, `"1"`, nil)
+	assertPreconditionFailed(t, w, 2)
+
+	_, err := mockRepo.GetTaskByID(testUserID, created.ID)
+	assert.NoError(t, err)
+
+	w = makeConditionalRequest(r, "DELETE", "/api/task/"+created.ID, `"2"`, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestIfMatch_MalformedHeaderNeverMatches(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, versionedTask)
+
+	for _, header := range []string{"1", `W/"1"`, `"abc"`, `"0"`} {
+		w := makeConditionalRequest(r, "PATCH", "/api/task/"+created.ID, header, []byte(`{"status": "Done"}`))
+		assertPreconditionFailed(t, w, 1)
+	}
+}
+
+func TestIfMatch_UnknownTaskIsNotFound(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	w := makeConditionalRequest(r, "DELETE", "/api/task/TASK-404", `"1"`, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	task "tasker/internal/Task"
+	"tasker/internal/repository"
+	"tasker/internal/template"
+
+	"github.com/gin-gonic/gin"
+)
+
+// instantiateRequest is the POST /api/templates/:id/instantiate body: values
+// for the template's placeholders and the fields a template doesn't carry
+type instantiateRequest struct {
+	Variables map[string]string `json:"variables"`
+	ProjectID *int64            `json:"project_id"`
+	StartAt   *time.Time        `json:"start_at"`
+	DueAt     *time.Time        `json:"due_at"`
+	Tags      []string          `json:"tags"`
+	Prefix    string            `json:"prefix"`
+}
+
+// validateTemplate checks a template on its own. Its status can only be checked
+// against a board once a task is made from it.
+func validateTemplate(t template.Template) map[string]string {
+	errors := make(map[string]string)
+
+	if name := strings.TrimSpace(t.Name); name == "" {
+		errors["name"] = "name is required"
+	} else if utf8.RuneCountInString(name) > 100 {
+		errors["name"] = "name must be at most 100 characters"
+	}
+
+	if strings.TrimSpace(t.Title) == "" {
+		errors["title"] = "title is required"
+	}
+
+	if t.Priority != "" && !slices.Contains(task.Priorities, t.Priority) {
+		errors["priority"] = "priority must be one of: " + strings.Join(task.Priorities, ", ")
+	}
+
+	if utf8.RuneCountInString(t.Status) > 50 {
+		errors["status"] = "status must be at most 50 characters"
+	}
+
+	if problem := template.ValidateChecklist(t.Checklist); problem != "" {
+		errors["checklist"] = problem
+	}
+
+	return errors
+}
+
+func parseTemplateID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+		return 0, false
+	}
+	return id, true
+}
+
+// bindTemplate reads and validates a template body, responding when it can't be used
+func bindTemplate(c *gin.Context) (template.Template, bool) {
+	var t template.Template
+	if err := c.ShouldBindJSON(&t); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return t, false
+	}
+
+	if validationErrors := validateTemplate(t); len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return t, false
+	}
+
+	t.Name = strings.TrimSpace(t.Name)
+	t.Title = strings.TrimSpace(t.Title)
+	return t, true
+}
+
+// GetTemplatesHandler returns all templates, ordered by name
+func GetTemplatesHandler(c *gin.Context) {
+	templates, err := repository.Templates.GetAllTemplates(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetTemplateHandler handles GET /api/templates/:id requests
+func GetTemplateHandler(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	t, err := repository.Templates.GetTemplateByID(currentUserID(c), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "template not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// PostTemplateHandler creates a new template
+func PostTemplateHandler(c *gin.Context) {
+	newTemplate, ok := bindTemplate(c)
+	if !ok {
+		return
+	}
+
+	createdTemplate, err := repository.Templates.CreateTemplate(currentUserID(c), newTemplate)
+	if err != nil {
+		if strings.Contains(err.Error(), "template already exists") {
+			c.JSON(http.StatusConflict, gin.H{"error": "template already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save template"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdTemplate)
+}
+
+// PutTemplateHandler handles PUT /api/templates/:id requests by replacing the
+// template. Tasks already made from it are left alone.
+func PutTemplateHandler(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	t, ok := bindTemplate(c)
+	if !ok {
+		return
+	}
+
+	updatedTemplate, err := repository.Templates.UpdateTemplate(currentUserID(c), id, t)
+	if err != nil {
+		if strings.Contains(err.Error(), "template not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+			return
+		}
+		if strings.Contains(err.Error(), "template already exists") {
+			c.JSON(http.StatusConflict, gin.H{"error": "template already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedTemplate)
+}
+
+// DeleteTemplateHandler handles DELETE /api/templates/:id requests
+func DeleteTemplateHandler(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	if err := repository.Templates.DeleteTemplate(currentUserID(c), id); err != nil {
+		if strings.Contains(err.Error(), "template not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete template"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// InstantiateTemplateHandler handles POST /api/templates/:id/instantiate
+// requests by creating a task from the template. {{date}} and {{week}} are the
+// current day and ISO week in ?tz= or the user's time zone. The task then goes
+// through the same checks as POST /api/task.
+func InstantiateTemplateHandler(c *gin.Context) {
+	id, ok := parseTemplateID(c)
+	if !ok {
+		return
+	}
+
+	var req instantiateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	t, err := repository.Templates.GetTemplateByID(currentUserID(c), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "template not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get template"})
+		return
+	}
+
+	validationErrors := make(map[string]string)
+	if missing := t.Missing(req.Variables); len(missing) > 0 {
+		validationErrors["variables"] = "missing values for: " + strings.Join(missing, ", ")
+	}
+	loc, err := requestLocation(c)
+	if err != nil {
+		validationErrors["tz"] = "tz must be an IANA time zone such as Europe/Berlin"
+	}
+	if len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	now := time.Now().In(loc)
+	createTask(c, createTaskRequest{
+		Task: task.Task{
+			Title:       strings.TrimSpace(template.Render(t.Title, req.Variables, now)),
+			Description: t.RenderDescription(req.Variables, now),
+			Status:      t.Status,
+			Priority:    t.Priority,
+			ProjectID:   req.ProjectID,
+			StartAt:     req.StartAt,
+			DueAt:       req.DueAt,
+			Tags:        req.Tags,
+		},
+		Prefix: req.Prefix,
+	})
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/template"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTemplate(t *testing.T, r *gin.Engine, body map[string]any) template.Template {
+	raw, _ := json.Marshal(body)
+	w := makeJSONRequest(r, "POST", "/api/templates", raw)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created template.Template
+	json.Unmarshal(w.Body.Bytes(), &created)
+	return created
+}
+
+func instantiate(r *gin.Engine, id int64, body map[string]any) (*task.Task, map[string]any, int) {
+	raw, _ := json.Marshal(body)
+	w := makeJSONRequest(r, "POST", fmt.Sprintf("/api/templates/%d/instantiate?tz=UTC", id), raw)
+
+	if w.Code != http.StatusCreated {
+		var resp map[string]any
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return nil, resp, w.Code
+	}
+	var created task.Task
+	json.Unmarshal(w.Body.Bytes(), &created)
+	return &created, nil, w.Code
+}
+
+func TestPostTemplateHandler_Success(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTemplate(t, r, map[string]any{
+		"name":      " Weekly review ",
+		"title":     "Weekly review {{week}}",
+		"checklist": "- [ ] Inbox zero\n- [ ] Plan next week",
+		"priority":  "High",
+	})
+
+	assert.Equal(t, "Weekly review", created.Name)
+	assert.Equal(t, "High", created.Priority)
+	assert.Empty(t, created.Status)
+
+	w := makeJSONRequest(r, "GET", fmt.Sprintf("/api/templates/%d", created.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = makeJSONRequest(r, "GET", "/api/templates", nil)
+	var templates []template.Template
+	json.Unmarshal(w.Body.Bytes(), &templates)
+	assert.Len(t, templates, 1)
+}
+
+func TestPostTemplateHandler_Validation(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	w := makeJSONRequest(r, "POST", "/api/templates", []byte(`{"name":" ","title":"","priority":"Urgent","checklist":"- [ ] Passport\nCharger"}`))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	details := resp["details"].(map[string]any)
+	assert.Contains(t, details, "name")
+	assert.Contains(t, details, "title")
+	assert.Contains(t, details, "priority")
+	assert.Equal(t, `line 2 is not a checklist item such as "- [ ] item"`, details["checklist"])
+}
+
+func TestPostTemplateHandler_DuplicateIgnoresCase(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	createTemplate(t, r, map[string]any{"name": "Trip", "title": "Pack"})
+	w := makeJSONRequest(r, "POST", "/api/templates", []byte(`{"name":"TRIP","title":"Pack"}`))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestPutTemplateHandler_ReplacesTemplate(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTemplate(t, r, map[string]any{"name": "Trip", "title": "Pack", "checklist": "- [ ] Passport", "priority": "Low"})
+
+	w := makeJSONRequest(r, "PUT", fmt.Sprintf("/api/templates/%d", created.ID), []byte(`{"name":"Trip","title":"Pack for {{destination}}"}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var updated template.Template
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	assert.Equal(t, "Pack for {{destination}}", updated.Title)
+	assert.Empty(t, updated.Checklist)
+	assert.Empty(t, updated.Priority)
+	assert.Equal(t, created.CreatedAt.Unix(), updated.CreatedAt.Unix())
+
+	w = makeJSONRequest(r, "PUT", "/api/templates/99", []byte(`{"name":"Trip","title":"Pack"}`))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeleteTemplateHandler(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTemplate(t, r, map[string]any{"name": "Trip", "title": "Pack"})
+
+	w := makeJSONRequest(r, "DELETE", fmt.Sprintf("/api/templates/%d", created.ID), nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = makeJSONRequest(r, "GET", fmt.Sprintf("/api/templates/%d", created.ID), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = makeJSONRequest(r, "DELETE", "/api/templates/abc", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestInstantiateTemplateHandler_CreatesTask(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTemplate(t, r, map[string]any{
+		"name":        "Trip",
+		"title":       "Pack for {{destination}}",
+		"description": "Leaving {{date}}",
+		"checklist":   "- [x] Passport\n- [ ] Adapter for {{destination}}",
+		"priority":    "High",
+		"status":      "In Progress",
+	})
+
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	tk, _, code := instantiate(r, created.ID, map[string]any{
+		"variables": map[string]string{"destination": "Lisbon"},
+		"due_at":    due,
+		"tags":      []string{"travel"},
+		"prefix":    "trip",
+	})
+
+	assert.Equal(t, http.StatusCreated, code)
+	today := time.Now().UTC().Format("2006-01-02")
+	assert.Equal(t, "Pack for Lisbon", tk.Title)
+	assert.Equal(t, "Leaving "+today+"\n\n- [ ] Passport\n- [ ] Adapter for Lisbon", tk.Description)
+	assert.Equal(t, "High", tk.Priority)
+	assert.Equal(t, "In Progress", tk.Status)
+	assert.True(t, due.Equal(*tk.DueAt))
+	assert.Equal(t, []string{"travel"}, []string(tk.Tags))
+	assert.Equal(t, "TRIP-001", tk.ID)
+
+	// The template is not used up
+	_, _, code = instantiate(r, created.ID, map[string]any{"variables": map[string]string{"destination": "Rome"}})
+	assert.Equal(t, http.StatusCreated, code)
+}
+
+func TestInstantiateTemplateHandler_UsesTaskDefaults(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTemplate(t, r, map[string]any{"name": "Review", "title": "Weekly review {{week}}"})
+
+	tk, _, code := instantiate(r, created.ID, map[string]any{})
+
+	assert.Equal(t, http.StatusCreated, code)
+	year, week := time.Now().UTC().ISOWeek()
+	assert.Equal(t, fmt.Sprintf("Weekly review %d-W%02d", year, week), tk.Title)
+	assert.Equal(t, "Medium", tk.Priority)
+	assert.Equal(t, "TODO", tk.Status)
+	assert.Empty(t, tk.Description)
+}
+
+func TestInstantiateTemplateHandler_Validation(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTemplate(t, r, map[string]any{"name": "Trip", "title": "{{destination}}", "description": "{{flight}}", "status": "PACKING"})
+
+	_, resp, code := instantiate(r, created.ID, map[string]any{})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "missing values for: destination, flight", resp["details"].(map[string]any)["variables"])
+
+	// The rendered task is checked like any new task, status against the board
+	_, resp, code = instantiate(r, created.ID, map[string]any{
+		"variables":  map[string]string{"destination": " ", "flight": "TP123"},
+		"project_id": 42,
+	})
+	assert.Equal(t, http.StatusBadRequest, code)
+	details := resp["details"].(map[string]any)
+	assert.Contains(t, details, "title")
+	assert.Contains(t, details, "status")
+	assert.Contains(t, details, "project_id")
+	assert.Empty(t, mockRepo.tasks)
+
+	_, _, code = instantiate(r, 99, map[string]any{})
+	assert.Equal(t, http.StatusNotFound, code)
+}
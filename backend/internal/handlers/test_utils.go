@@ -2,131 +2,1614 @@ package handlers
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"errors"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"sync"
+	"testing"
 	"time"
 
 	task "tasker/internal/Task"
+	"tasker/internal/auth"
+	"tasker/internal/history"
+	"tasker/internal/rank"
+	"tasker/internal/recurrence"
 	"tasker/internal/repository"
+	"tasker/internal/workflow"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
 )
 
-// MockTaskRepository is an in-memory implementation for testing
+// MockTaskRepository is an in-memory implementation for testing. Its
+// unexported helpers expect mu to be held by the method calling them.
 type MockTaskRepository struct {
-	tasks map[string]task.Task
-	mu    sync.RWMutex
+	tasks        map[string]task.Task
+	trash        map[string]task.Task
+	sequences    map[string]int
+	aliases      map[string]string
+	dependencies []taskDependency
+	events       []history.Event
+	mutation     int64
+	series       map[int64]mockSeries
+	nextSeriesID int64
+	// doneAt stands in for the real repository's done_at column
+	doneAt    map[string]time.Time
+	tags      *MockTagRepository
+	workflows *MockWorkflowRepository
+	mu        sync.RWMutex
+}
+
+// mockSeries is a recurring task's schedule, see the real repository's taskSeries
+type mockSeries struct {
+	userID          int64
+	rule            string
+	afterCompletion bool
+	anchor          time.Time
+	nextAt          *time.Time
+	loc             *time.Location
+}
+
+// taskDependency is one edge of the mock's dependency graph
+type taskDependency struct {
+	blocker, blocked string
 }
 
 func NewMockTaskRepository() *MockTaskRepository {
 	return &MockTaskRepository{
-		tasks: make(map[string]task.Task),
+		tasks:     make(map[string]task.Task),
+		trash:     make(map[string]task.Task),
+		sequences: make(map[string]int),
+		aliases:   make(map[string]string),
+		series:    make(map[int64]mockSeries),
+		doneAt:    make(map[string]time.Time),
+	}
+}
+
+// markDone keeps doneAt in step with a write that took before to after at
+// time at
+func (m *MockTaskRepository) markDone(before *task.Task, after task.Task, at time.Time) {
+	switch {
+	case after.StatusCategory != workflow.CategoryDone:
+		delete(m.doneAt, after.ID)
+	case before == nil || before.StatusCategory != workflow.CategoryDone:
+		m.doneAt[after.ID] = at
+	}
+}
+
+// resolve maps an old key to the task's current key
+func (m *MockTaskRepository) resolve(id string) string {
+	if current, ok := m.aliases[id]; ok {
+		return current
+	}
+	return id
+}
+
+// lookup returns a task owned by userID by its current or old key
+func (m *MockTaskRepository) lookup(userID int64, id string) (task.Task, bool) {
+	t, ok := m.tasks[m.resolve(id)]
+	if !ok || t.UserID != userID {
+		return task.Task{}, false
+	}
+	return t, true
+}
+
+// canonicalTags resolves names to stored tags, creating missing ones like the real repository.
+// It takes the tag lock, so callers must not hold the task lock.
+func (m *MockTaskRepository) canonicalTags(userID int64, names []string) []string {
+	if m.tags == nil {
+		names = slices.Clone(names)
+		sortTagNames(names)
+		return names
+	}
+	return m.tags.ensureTags(userID, names)
+}
+
+// hasTags reports whether t carries any (or, with all, every) of names, ignoring case
+func hasTags(t task.Task, names []string, all bool) bool {
+	matched := 0
+	for _, name := range names {
+		if slices.ContainsFunc(t.Tags, func(tag string) bool { return strings.EqualFold(tag, name) }) {
+			matched++
+		}
+	}
+	if all {
+		return matched == len(names)
+	}
+	return matched > 0
+}
+
+// withComputed fills in the subtask rollup and dependency lists the way the task
+// query does
+func (m *MockTaskRepository) withComputed(t task.Task) task.Task {
+	done, total := 0, 0
+	for _, child := range m.tasks {
+		if child.ParentID != nil && *child.ParentID == t.ID {
+			total++
+			if child.StatusCategory == workflow.CategoryDone {
+				done++
+			}
+		}
+	}
+	t.Progress = task.NewProgress(done, total)
+
+	t.BlockedBy, t.Blocks = pq.StringArray{}, pq.StringArray{}
+	for _, d := range m.dependencies {
+		// Edges to tasks in the trash are kept but don't count
+		if _, ok := m.tasks[d.blocker]; ok && d.blocked == t.ID {
+			t.BlockedBy = append(t.BlockedBy, d.blocker)
+		}
+		if _, ok := m.tasks[d.blocked]; ok && d.blocker == t.ID {
+			t.Blocks = append(t.Blocks, d.blocked)
+		}
+	}
+	slices.Sort(t.BlockedBy)
+	slices.Sort(t.Blocks)
+
+	// Occurrences store their series and date; the rest comes from the series
+	if t.Recurrence != nil {
+		if s, ok := m.series[t.Recurrence.SeriesID]; ok {
+			t.Recurrence = &task.Recurrence{
+				SeriesID:        t.Recurrence.SeriesID,
+				Rule:            s.rule,
+				AfterCompletion: s.afterCompletion,
+				OccursAt:        t.Recurrence.OccursAt,
+				NextAt:          s.nextAt,
+				Timezone:        s.loc.String(),
+			}
+		} else {
+			t.Recurrence = nil
+		}
 	}
+	return t
 }
 
-func (m *MockTaskRepository) GetAllTasks() ([]task.Task, error) {
+func (m *MockTaskRepository) GetAllTasks(userID int64) ([]task.Task, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	result := make([]task.Task, 0, len(m.tasks))
 	for _, t := range m.tasks {
-		result = append(result, t)
+		if t.UserID == userID {
+			result = append(result, m.withComputed(t))
+		}
 	}
 	return result, nil
 }
 
-func (m *MockTaskRepository) GetTaskByID(id string) (*task.Task, error) {
+// matchesQuery is a rough stand-in for full-text search: every word must appear
+// in the title or description
+func matchesQuery(t task.Task, query string) bool {
+	text := strings.ToLower(t.Title + " " + t.Description)
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareTasks orders two tasks the way ListTasks does for sort
+func compareTasks(a, b task.Task, sort string) int {
+	var c int
+	switch sort {
+	case "updated_at":
+		c = a.UpdatedAt.Compare(b.UpdatedAt)
+	case "due_at":
+		// Undated tasks sort last, like 'infinity' in SQL
+		switch {
+		case a.DueAt == nil && b.DueAt == nil:
+		case a.DueAt == nil:
+			c = 1
+		case b.DueAt == nil:
+			c = -1
+		default:
+			c = a.DueAt.Compare(*b.DueAt)
+		}
+	case "archived_at":
+		switch {
+		case a.ArchivedAt == nil && b.ArchivedAt == nil:
+		case a.ArchivedAt == nil:
+			c = 1
+		case b.ArchivedAt == nil:
+			c = -1
+		default:
+			c = a.ArchivedAt.Compare(*b.ArchivedAt)
+		}
+	case "title":
+		c = strings.Compare(a.Title, b.Title)
+	case "status":
+		c = cmp.Compare(repository.TaskRank(workflow.Categories, a.StatusCategory), repository.TaskRank(workflow.Categories, b.StatusCategory))
+	case "priority":
+		c = cmp.Compare(repository.TaskRank(task.Priorities, a.Priority), repository.TaskRank(task.Priorities, b.Priority))
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+	return c
+}
+
+func (m *MockTaskRepository) ListTasks(userID int64, opts repository.ListOptions) ([]task.Task, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if opts.Sort == "" {
+		opts.Sort = repository.DefaultTaskSort
+	}
+
+	inRange := func(v time.Time, after, before *time.Time) bool {
+		return (after == nil || !v.Before(*after)) && (before == nil || v.Before(*before))
+	}
+
+	result := make([]task.Task, 0)
+	for _, t := range m.tasks {
+		switch {
+		case t.UserID != userID,
+			opts.ParentID != nil && (t.ParentID == nil || *t.ParentID != *opts.ParentID),
+			opts.ProjectID != nil && (t.ProjectID == nil || *t.ProjectID != *opts.ProjectID),
+			len(opts.Statuses) > 0 && !slices.Contains(opts.Statuses, t.Status),
+			len(opts.Categories) > 0 && !slices.Contains(opts.Categories, t.StatusCategory),
+			slices.Contains(opts.ExcludeCategories, t.StatusCategory),
+			len(opts.Priorities) > 0 && !slices.Contains(opts.Priorities, t.Priority),
+			!inRange(t.CreatedAt, opts.CreatedAfter, opts.CreatedBefore),
+			!inRange(t.UpdatedAt, opts.UpdatedAfter, opts.UpdatedBefore),
+			(opts.DueAfter != nil || opts.DueBefore != nil) && (t.DueAt == nil || !inRange(*t.DueAt, opts.DueAfter, opts.DueBefore)),
+			opts.NoDueDate && t.DueAt != nil,
+			(opts.Archived || opts.ArchivedAfter != nil || opts.ArchivedBefore != nil) && (t.ArchivedAt == nil || !inRange(*t.ArchivedAt, opts.ArchivedAfter, opts.ArchivedBefore)),
+			len(opts.Tags) > 0 && !hasTags(t, opts.Tags, opts.AllTags),
+			opts.Query != "" && !matchesQuery(t, opts.Query):
+			continue
+		}
+		result = append(result, m.withComputed(t))
+	}
+
+	slices.SortFunc(result, func(a, b task.Task) int {
+		if opts.Descending {
+			return compareTasks(b, a, opts.Sort)
+		}
+		return compareTasks(a, b, opts.Sort)
+	})
+
+	// The mock resumes after the cursor's task rather than comparing sort values
+	if opts.After != nil {
+		for i, t := range result {
+			if t.ID == opts.After.ID {
+				result = result[i+1:]
+				break
+			}
+		}
+	}
+
+	var next string
+	if opts.Limit > 0 && len(result) > opts.Limit {
+		result = result[:opts.Limit]
+		next = repository.NewTaskCursor(result[len(result)-1], opts).Encode()
+	}
+
+	return result, next, nil
+}
+
+func (m *MockTaskRepository) GetTaskByID(userID int64, id string) (*task.Task, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if t, ok := m.tasks[id]; ok {
+	if t, ok := m.lookup(userID, id); ok {
+		t = m.withComputed(t)
 		return &t, nil
 	}
 	return nil, errors.New("task not found: " + id)
 }
 
-func (m *MockTaskRepository) CreateTask(t task.Task) (*task.Task, error) {
+func (m *MockTaskRepository) CreateTask(userID int64, t task.Task, prefix string, limit *repository.WIPLimit) (*task.Task, error) {
+	t.Tags = m.canonicalTags(userID, t.Tags)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if err := m.checkWIPLimit(userID, limit, "", 1); err != nil {
+		return nil, err
+	}
+
+	if t.ParentID != nil {
+		parentID, err := m.checkParent(userID, "", *t.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		t.ParentID = &parentID
+	}
+
+	m.begin()
+	return m.insert(userID, t, prefix), nil
+}
+
+// insert creates t under the next key for prefix at the top of its column
+func (m *MockTaskRepository) insert(userID int64, t task.Task, prefix string) *task.Task {
+	t.ID = m.nextTaskKey(prefix)
+	t.UserID = userID
+	t.Rank = m.placeInColumn(m.column(userID, t.Status, ""), 0)
+	t.Version = 1
 	now := time.Now()
 	t.CreatedAt = now
 	t.UpdatedAt = now
 
 	m.tasks[t.ID] = t
-	return &t, nil
+	m.markDone(nil, t, now)
+	m.record(userID, history.ActionCreate, nil, &t)
+	t = m.withComputed(t)
+	return &t
 }
 
-func (m *MockTaskRepository) DeleteTask(id string) error {
+func (m *MockTaskRepository) DeleteTask(userID int64, id string, version int64, mode repository.TaskDeleteMode) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, ok := m.tasks[id]; !ok {
+	m.begin()
+	return m.deleteTask(userID, id, version, mode)
+}
+
+// deleteTask moves a task to the trash like the real repository; callers must
+// hold the lock
+func (m *MockTaskRepository) deleteTask(userID int64, id string, version int64, mode repository.TaskDeleteMode) error {
+	existing, ok := m.lookup(userID, id)
+	if !ok {
 		return errors.New("task not found: " + id)
 	}
-	delete(m.tasks, id)
+	if version != 0 && version != existing.Version {
+		return errors.New("task version conflict: " + id)
+	}
+
+	subtree := m.subtree(existing.ID)
+	if len(subtree) > 1 && mode != repository.TaskDeleteCascade {
+		return errors.New("task has subtasks: " + id)
+	}
+
+	m.trashTasks(userID, subtree)
 	return nil
 }
 
-func (m *MockTaskRepository) UpdateTask(id string, t task.Task) (*task.Task, error) {
+// subtree lists the key of a live task followed by those of its live
+// subtasks
+func (m *MockTaskRepository) subtree(id string) []string {
+	subtree := []string{id}
+	for i := 0; i < len(subtree); i++ {
+		for childID, child := range m.tasks {
+			if child.ParentID != nil && *child.ParentID == subtree[i] {
+				subtree = append(subtree, childID)
+			}
+		}
+	}
+	return subtree
+}
+
+// trashTasks moves tasks to the trash together, recording a delete for each
+func (m *MockTaskRepository) trashTasks(userID int64, ids []string) {
+	now := time.Now()
+	for _, deleted := range ids {
+		before := m.tasks[deleted]
+		m.record(userID, history.ActionDelete, &before, nil)
+		m.moveToTrash(deleted, now)
+	}
+}
+
+func (m *MockTaskRepository) UpdateTask(userID int64, id string, t task.Task, limit *repository.WIPLimit) (*task.Task, error) {
+	t.Tags = m.canonicalTags(userID, t.Tags)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	existing, exists := m.tasks[id]
+	m.begin()
+	return m.update(userID, id, t, limit)
+}
+
+// update overwrites a task's editable fields like the real repository. t's
+// tags must already be canonical.
+func (m *MockTaskRepository) update(userID int64, id string, t task.Task, limit *repository.WIPLimit) (*task.Task, error) {
+	existing, exists := m.lookup(userID, id)
 	if !exists {
 		return nil, errors.New("task not found: " + id)
 	}
-
-	// Update only non-empty fields (except Status/Priority which can be empty)
-	if t.Title != "" {
-		existing.Title = t.Title
+	if t.Version != 0 && t.Version != existing.Version {
+		return nil, errors.New("task version conflict: " + id)
+	}
+	if err := m.checkBlockers(existing, t.StatusCategory); err != nil {
+		return nil, err
 	}
-	if t.Description != "" {
-		existing.Description = t.Description
+	if err := m.checkWIPLimit(userID, limit, existing.ID, 1); err != nil {
+		return nil, err
 	}
-	if t.Status != "" {
-		existing.Status = t.Status
+	if t.ParentID != nil && (existing.ParentID == nil || *existing.ParentID != *t.ParentID) {
+		parentID, err := m.checkParent(userID, existing.ID, *t.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		t.ParentID = &parentID
 	}
-	if t.Priority != "" {
-		existing.Priority = t.Priority
+
+	before := existing
+	if existing.Status != t.Status {
+		existing.Rank = m.placeInColumn(m.column(userID, t.Status, existing.ID), 0)
+	}
+	existing.Title = t.Title
+	existing.Description = t.Description
+	existing.Status = t.Status
+	existing.StatusCategory = t.StatusCategory
+	existing.Priority = t.Priority
+	existing.ProjectID = t.ProjectID
+	existing.StartAt = t.StartAt
+	existing.DueAt = t.DueAt
+	existing.EstimateHours = t.EstimateHours
+	existing.ParentID = t.ParentID
+	existing.Tags = t.Tags
+	if existing.StatusCategory != workflow.CategoryDone {
+		existing.ArchivedAt = nil
 	}
+	existing.Version++
 
 	// Update timestamp
 	existing.UpdatedAt = time.Now()
 
-	m.tasks[id] = existing
+	m.tasks[existing.ID] = existing
+	m.markDone(&before, existing, existing.UpdatedAt)
+	m.record(userID, history.ActionUpdate, &before, &existing)
+	if before.StatusCategory != workflow.CategoryDone && existing.StatusCategory == workflow.CategoryDone {
+		m.continueSeries(userID, existing)
+	}
+	existing = m.withComputed(existing)
 	return &existing, nil
 }
 
+// moveToTrash takes a task off every listing while keeping its aliases and
+// dependencies, like setting deleted_at
+func (m *MockTaskRepository) moveToTrash(id string, at time.Time) {
+	t := m.tasks[id]
+	t.DeletedAt = &at
+	m.trash[id] = t
+	delete(m.tasks, id)
+}
+
+// nextTaskKey bumps the prefix's sequence
+func (m *MockTaskRepository) nextTaskKey(prefix string) string {
+	m.sequences[prefix]++
+	return task.FormatKey(prefix, m.sequences[prefix])
+}
+
+func (m *MockTaskRepository) RekeyTask(userID int64, id string, prefix string, version int64) (*task.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.lookup(userID, id)
+	if !ok {
+		return nil, errors.New("task not found: " + id)
+	}
+	if version != 0 && version != existing.Version {
+		return nil, errors.New("task version conflict: " + id)
+	}
+	oldID := existing.ID
+	if strings.HasPrefix(oldID, prefix+"-") {
+		return &existing, nil
+	}
+
+	newID := m.nextTaskKey(prefix)
+	existing.ID = newID
+	existing.UpdatedAt = time.Now()
+	existing.Version++
+
+	delete(m.tasks, oldID)
+	m.tasks[newID] = existing
+	for alias, current := range m.aliases {
+		if current == oldID {
+			m.aliases[alias] = newID
+		}
+	}
+	m.aliases[oldID] = newID
+	for i, e := range m.events {
+		if e.UserID == userID && e.TaskID == oldID {
+			m.events[i].TaskID = newID
+		}
+	}
+
+	// Subtasks and dependencies follow the task to the new key
+	for childID, child := range m.tasks {
+		if child.ParentID != nil && *child.ParentID == oldID {
+			child.ParentID = &newID
+			m.tasks[childID] = child
+		}
+	}
+	for i, d := range m.dependencies {
+		if d.blocker == oldID {
+			m.dependencies[i].blocker = newID
+		}
+		if d.blocked == oldID {
+			m.dependencies[i].blocked = newID
+		}
+	}
+
+	m.begin()
+	before, _ := json.Marshal(oldID)
+	after, _ := json.Marshal(newID)
+	m.recordEvent(userID, newID, history.ActionRekey, history.Changes{"id": {Before: before, After: after}}, existing.Version)
+
+	existing = m.withComputed(existing)
+	return &existing, nil
+}
+
+// checkWIPLimit refuses a write like the real repository when the column of
+// limit would hold more cards than the limit once adding more join it, not
+// counting skipID's
+func (m *MockTaskRepository) checkWIPLimit(userID int64, limit *repository.WIPLimit, skipID string, adding int) error {
+	if limit == nil {
+		return nil
+	}
+
+	count := 0
+	for _, t := range m.tasks {
+		if t.ID != skipID && t.Status == limit.Status && t.ArchivedAt == nil && m.workflows.onBoard(userID, limit.Board, t) {
+			count++
+		}
+	}
+	if count+adding > limit.Limit {
+		return &repository.WIPLimitError{WIPLimit: *limit, Count: count}
+	}
+	return nil
+}
+
+// checkParent refuses parents like the real repository and returns the
+// parent's current key
+func (m *MockTaskRepository) checkParent(userID int64, id string, parentID string) (string, error) {
+	parent, ok := m.lookup(userID, parentID)
+	if !ok {
+		return "", errors.New("parent not found: " + parentID)
+	}
+
+	ancestors := []string{parent.ID}
+	for t := parent; t.ParentID != nil; {
+		ancestors = append(ancestors, *t.ParentID)
+		t = m.tasks[*t.ParentID]
+	}
+
+	var height func(id string) int
+	height = func(id string) int {
+		deepest := 0
+		for childID, child := range m.tasks {
+			if child.ParentID != nil && *child.ParentID == id {
+				deepest = max(deepest, height(childID))
+			}
+		}
+		return deepest + 1
+	}
+
+	levels := 1
+	if id != "" {
+		if slices.Contains(ancestors, id) {
+			return "", errors.New("parent cycle: " + id + " under " + parentID)
+		}
+		levels = height(id)
+	}
+	if len(ancestors)+levels > repository.MaxTaskDepth {
+		return "", errors.New("parent too deep: " + parentID)
+	}
+	return parent.ID, nil
+}
+
 func (m *MockTaskRepository) Clear() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.tasks = make(map[string]task.Task)
+	m.trash = make(map[string]task.Task)
+	m.sequences = make(map[string]int)
+	m.aliases = make(map[string]string)
+	m.dependencies = nil
+	m.events = nil
+	m.series = make(map[int64]mockSeries)
+}
+
+// bump records a write to the tasks with the given keys
+func (m *MockTaskRepository) bump(ids ...string) {
+	for _, id := range ids {
+		t := m.tasks[id]
+		t.Version++
+		m.tasks[id] = t
+	}
+}
+
+func (m *MockTaskRepository) AddDependency(userID int64, id string, blockerID string) (*task.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	blocked, ok := m.lookup(userID, id)
+	if !ok {
+		return nil, errors.New("task not found: " + id)
+	}
+	blocker, ok := m.lookup(userID, blockerID)
+	if !ok {
+		return nil, errors.New("blocker not found: " + blockerID)
+	}
+
+	// Walk everything the blocked task already holds up; reaching the blocker means a cycle
+	downstream := []string{blocked.ID}
+	for i := 0; i < len(downstream); i++ {
+		if downstream[i] == blocker.ID {
+			return nil, errors.New("dependency cycle: " + blocked.ID + " blocks " + blocker.ID)
+		}
+		for _, d := range m.dependencies {
+			if d.blocker == downstream[i] && !slices.Contains(downstream, d.blocked) {
+				downstream = append(downstream, d.blocked)
+			}
+		}
+	}
+
+	edge := taskDependency{blocker: blocker.ID, blocked: blocked.ID}
+	if !slices.Contains(m.dependencies, edge) {
+		m.dependencies = append(m.dependencies, edge)
+		m.bump(blocked.ID, blocker.ID)
+	}
+
+	updated := m.withComputed(m.tasks[blocked.ID])
+	return &updated, nil
+}
+
+func (m *MockTaskRepository) RemoveDependency(userID int64, id string, blockerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	blocked, ok := m.lookup(userID, id)
+	if !ok {
+		return errors.New("task not found: " + id)
+	}
+	blocker, ok := m.lookup(userID, blockerID)
+	edge := taskDependency{blocker: blocker.ID, blocked: blocked.ID}
+	if !ok || !slices.Contains(m.dependencies, edge) {
+		return errors.New("dependency not found: " + blockerID)
+	}
+
+	m.dependencies = slices.DeleteFunc(m.dependencies, func(d taskDependency) bool { return d == edge })
+	m.bump(blocked.ID, blocker.ID)
+	return nil
+}
+
+func (m *MockTaskRepository) GetOpenBlockers(userID int64, id string) ([]task.Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	blocked, ok := m.lookup(userID, id)
+	if !ok {
+		return []task.Task{}, nil
+	}
+	return m.openBlockers(blocked.ID), nil
+}
+
+// openBlockers returns the blockers of id that aren't done
+func (m *MockTaskRepository) openBlockers(id string) []task.Task {
+	blockers := []task.Task{}
+	for _, d := range m.dependencies {
+		if b, ok := m.tasks[d.blocker]; ok && d.blocked == id && b.StatusCategory != workflow.CategoryDone {
+			blockers = append(blockers, m.withComputed(b))
+		}
+	}
+	slices.SortFunc(blockers, func(a, b task.Task) int { return strings.Compare(a.ID, b.ID) })
+	return blockers
+}
+
+// checkBlockers refuses to put before into category done while it has open
+// blockers, like the real repository
+func (m *MockTaskRepository) checkBlockers(before task.Task, category string) error {
+	if category != workflow.CategoryDone || before.StatusCategory == workflow.CategoryDone {
+		return nil
+	}
+	if blockers := m.openBlockers(before.ID); len(blockers) > 0 {
+		return &repository.BlockedError{TaskID: before.ID, Blockers: blockers}
+	}
+	return nil
+}
+
+// column returns the user's cards in status in rank order, except skipID
+func (m *MockTaskRepository) column(userID int64, status string, skipID string) []task.Task {
+	column := []task.Task{}
+	for _, t := range m.tasks {
+		if t.UserID == userID && t.Status == status && t.ID != skipID && t.ArchivedAt == nil {
+			column = append(column, t)
+		}
+	}
+	slices.SortFunc(column, func(a, b task.Task) int {
+		return cmp.Or(strings.Compare(a.Rank, b.Rank), strings.Compare(a.ID, b.ID))
+	})
+	return column
+}
+
+// placeInColumn returns the rank for index pos of column, spreading the column
+// out when the gap is used up like the real repository
+func (m *MockTaskRepository) placeInColumn(column []task.Task, pos int) string {
+	var lower, upper string
+	if pos > 0 {
+		lower = column[pos-1].Rank
+	}
+	if pos < len(column) {
+		upper = column[pos].Rank
+	}
+	if key, ok := rank.Between(lower, upper); ok {
+		return key
+	}
+
+	keys := rank.Spread(len(column) + 1)
+	for i, t := range column {
+		t.Rank = keys[i]
+		if i >= pos {
+			t.Rank = keys[i+1]
+		}
+		m.tasks[t.ID] = t
+	}
+	return keys[pos]
+}
+
+func (m *MockTaskRepository) MoveTask(userID int64, id string, move repository.TaskMove) (*task.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.lookup(userID, id)
+	if !ok {
+		return nil, errors.New("task not found: " + id)
+	}
+	if move.Version != 0 && move.Version != t.Version {
+		return nil, errors.New("task version conflict: " + id)
+	}
+	if err := m.checkBlockers(t, move.Category); err != nil {
+		return nil, err
+	}
+	if err := m.checkWIPLimit(userID, move.Limit, t.ID, 1); err != nil {
+		return nil, err
+	}
+
+	column := m.column(userID, move.Status, t.ID)
+	at := func(id string) int {
+		return slices.IndexFunc(column, func(c task.Task) bool { return c.ID == m.resolve(id) })
+	}
+	pos := len(column)
+	if move.AfterID != "" {
+		if pos = at(move.AfterID) + 1; pos == 0 {
+			return nil, errors.New("after task not in column: " + move.AfterID)
+		}
+	}
+	if move.BeforeID != "" {
+		i := at(move.BeforeID)
+		switch {
+		case i < 0:
+			return nil, errors.New("before task not in column: " + move.BeforeID)
+		case move.AfterID == "":
+			pos = i
+		case i < pos:
+			return nil, errors.New("move neighbours out of order: " + move.AfterID + ", " + move.BeforeID)
+		}
+	}
+
+	before := t
+	t.Rank = m.placeInColumn(column, pos)
+	t.Status = move.Status
+	t.StatusCategory = move.Category
+	t.ArchivedAt = nil
+	t.UpdatedAt = time.Now()
+	t.Version++
+	m.tasks[t.ID] = t
+	m.markDone(&before, t, t.UpdatedAt)
+	m.begin()
+	m.record(userID, history.ActionMove, &before, &t)
+	if before.StatusCategory != workflow.CategoryDone && t.StatusCategory == workflow.CategoryDone {
+		m.continueSeries(userID, t)
+	}
+
+	t = m.withComputed(t)
+	return &t, nil
+}
+
+// begin starts a new mutation, standing in for the real repository's
+// transaction: events recorded until the next call are undone together.
+func (m *MockTaskRepository) begin() {
+	m.mutation++
+}
+
+// recordEvent appends to a task's history
+func (m *MockTaskRepository) recordEvent(userID int64, taskID string, action string, changes history.Changes, version int64) {
+	m.events = append(m.events, history.Event{
+		ID:         int64(len(m.events) + 1),
+		UserID:     userID,
+		TaskID:     taskID,
+		ActorID:    userID,
+		Action:     action,
+		Changes:    changes,
+		Version:    version,
+		MutationID: m.mutation,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// record appends the fields that differ between before and after to the
+// task's history like the real repository
+func (m *MockTaskRepository) record(userID int64, action string, before, after *task.Task) {
+	changes := history.Diff(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	current := after
+	if current == nil {
+		current = before
+	}
+	m.recordEvent(userID, current.ID, action, changes, current.Version)
+}
+
+func (m *MockTaskRepository) GetTaskHistory(userID int64, id string) ([]history.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key := m.resolve(id)
+	_, live := m.lookup(userID, id)
+	trashed, inTrash := m.trash[key]
+	exists := live || inTrash && trashed.UserID == userID
+
+	events := []history.Event{}
+	for _, e := range slices.Backward(m.events) {
+		if e.UserID == userID && e.TaskID == key {
+			events = append(events, e)
+		}
+	}
+	if len(events) == 0 && !exists {
+		return nil, errors.New("task not found: " + id)
+	}
+	return events, nil
+}
+
+func (m *MockTaskRepository) RestoreTask(userID int64, t task.Task, action string, limit *repository.WIPLimit) (*task.Task, error) {
+	t.Tags = m.canonicalTags(userID, t.Tags)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.begin()
+	if existing, ok := m.lookup(userID, t.ID); ok && action == history.ActionRevert {
+		if err := m.checkBlockers(existing, t.StatusCategory); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.checkWIPLimit(userID, limit, t.ID, 1); err != nil {
+		return nil, err
+	}
+	return m.restore(userID, t, action)
+}
+
+// restore writes t over the task with key t.ID, taking it out of the trash if
+// it's there, like the real repository
+func (m *MockTaskRepository) restore(userID int64, t task.Task, action string) (*task.Task, error) {
+	existing, live := m.tasks[t.ID]
+	if live && existing.UserID != userID {
+		return nil, errors.New("task not found: " + t.ID)
+	}
+
+	var before *task.Task
+	previous := existing
+	switch trashed, inTrash := m.trash[t.ID]; {
+	case live:
+		if t.Version != 0 && t.Version != existing.Version {
+			return nil, errors.New("task version conflict: " + t.ID)
+		}
+		before = &existing
+		if t.Rank == "" {
+			t.Rank = existing.Rank
+		}
+		t.Recurrence = existing.Recurrence
+		t.Version = existing.Version + 1
+		t.CreatedAt = existing.CreatedAt
+	case inTrash && trashed.UserID == userID:
+		// Coming back from the trash reads like a create in the history
+		previous = trashed
+		if t.Rank == "" {
+			t.Rank = trashed.Rank
+		}
+		t.Recurrence = trashed.Recurrence
+		t.Version = trashed.Version + 1
+		t.CreatedAt = trashed.CreatedAt
+		t.DeletedAt = nil
+	default:
+		return nil, errors.New("task not found: " + t.ID)
+	}
+
+	if t.ParentID != nil && (before == nil || before.ParentID == nil || *before.ParentID != *t.ParentID) {
+		parentID, err := m.checkParent(userID, t.ID, *t.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		t.ParentID = &parentID
+	}
+	if before == nil {
+		delete(m.trash, t.ID)
+	}
+
+	if t.StatusCategory != workflow.CategoryDone {
+		t.ArchivedAt = nil
+	}
+	t.UserID = userID
+	t.UpdatedAt = time.Now()
+	m.tasks[t.ID] = t
+	m.markDone(&previous, t, t.UpdatedAt)
+	m.record(userID, action, before, &t)
+
+	t = m.withComputed(t)
+	return &t, nil
+}
+
+func (m *MockTaskRepository) GetUndoableMutation(userID int64, window time.Duration) ([]history.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	since := time.Now().Add(-window)
+	for _, last := range slices.Backward(m.events) {
+		if last.UserID != userID || last.Undone || !history.Undoable(last.Action) || !last.CreatedAt.After(since) {
+			continue
+		}
+		var events []history.Event
+		for _, e := range m.events {
+			if e.UserID == userID && e.MutationID == last.MutationID {
+				events = append(events, e)
+			}
+		}
+		return events, nil
+	}
+	return nil, errors.New("nothing to undo")
+}
+
+func (m *MockTaskRepository) UndoMutation(userID int64, mutationID int64, tasks []task.Task) ([]task.Task, error) {
+	for i := range tasks {
+		tasks[i].Tags = m.canonicalTags(userID, tasks[i].Tags)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	undone := 0
+	for i, e := range m.events {
+		if e.UserID == userID && e.MutationID == mutationID && !e.Undone {
+			m.events[i].Undone = true
+			undone++
+		}
+	}
+	if undone == 0 {
+		return nil, errors.New("nothing to undo")
+	}
+
+	m.begin()
+	restored := []task.Task{}
+	for _, t := range parentsFirst(tasks) {
+		r, err := m.restore(userID, t, history.ActionUndo)
+		if err != nil {
+			return nil, err
+		}
+		restored = append(restored, *r)
+	}
+	return restored, nil
+}
+
+// parentsFirst orders tasks so each comes after its parent when both are in
+// the list, like the real repository
+func parentsFirst(tasks []task.Task) []task.Task {
+	pending := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		pending[t.ID] = true
+	}
+
+	ordered := make([]task.Task, 0, len(tasks))
+	for len(ordered) < len(tasks) {
+		for _, t := range tasks {
+			if pending[t.ID] && (t.ParentID == nil || !pending[*t.ParentID]) {
+				ordered = append(ordered, t)
+				delete(pending, t.ID)
+			}
+		}
+	}
+	return ordered
+}
+
+func (m *MockTaskRepository) GetTrash(userID int64) ([]task.Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tasks := []task.Task{}
+	for _, t := range m.trash {
+		if t.UserID == userID {
+			tasks = append(tasks, m.withComputed(t))
+		}
+	}
+	slices.SortFunc(tasks, func(a, b task.Task) int {
+		if c := b.DeletedAt.Compare(*a.DeletedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return tasks, nil
+}
+
+// trashedSubtree lists the task in the trash with key id and the subtasks
+// trashed along with it
+func (m *MockTaskRepository) trashedSubtree(userID int64, id string) []task.Task {
+	root, ok := m.trash[m.resolve(id)]
+	if !ok || root.UserID != userID {
+		return nil
+	}
+
+	subtree := []task.Task{root}
+	for i := 0; i < len(subtree); i++ {
+		var children []task.Task
+		for _, child := range m.trash {
+			if child.ParentID != nil && *child.ParentID == subtree[i].ID && child.DeletedAt.Equal(*root.DeletedAt) {
+				children = append(children, child)
+			}
+		}
+		slices.SortFunc(children, func(a, b task.Task) int { return strings.Compare(a.ID, b.ID) })
+		subtree = append(subtree, children...)
+	}
+	return subtree
+}
+
+func (m *MockTaskRepository) GetTrashedSubtree(userID int64, id string) ([]task.Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	subtree := m.trashedSubtree(userID, id)
+	if subtree == nil {
+		return nil, errors.New("task not found: " + id)
+	}
+	for i, t := range subtree {
+		subtree[i] = m.withComputed(t)
+	}
+	return subtree, nil
+}
+
+func (m *MockTaskRepository) RestoreFromTrash(userID int64, tasks []task.Task) ([]task.Task, error) {
+	for i := range tasks {
+		tasks[i].Tags = m.canonicalTags(userID, tasks[i].Tags)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range tasks {
+		if trashed, ok := m.trash[t.ID]; !ok || trashed.UserID != userID {
+			return nil, errors.New("task not found: " + t.ID)
+		}
+	}
+
+	m.begin()
+	restored := []task.Task{}
+	for _, t := range tasks {
+		t.Version = 0
+		r, err := m.restore(userID, t, history.ActionRestore)
+		if err != nil {
+			return nil, err
+		}
+		restored = append(restored, *r)
+	}
+	return restored, nil
+}
+
+// purge deletes a task in the trash for good, history included; callers must
+// hold the lock
+func (m *MockTaskRepository) purge(id string) {
+	delete(m.trash, id)
+	for alias, current := range m.aliases {
+		if current == id {
+			delete(m.aliases, alias)
+		}
+	}
+	m.dependencies = slices.DeleteFunc(m.dependencies, func(d taskDependency) bool {
+		return d.blocker == id || d.blocked == id
+	})
+	m.events = slices.DeleteFunc(m.events, func(e history.Event) bool { return e.TaskID == id })
+	// Subtasks outside the trash are promoted, like ON DELETE SET NULL
+	for childID, child := range m.tasks {
+		if child.ParentID != nil && *child.ParentID == id {
+			child.ParentID = nil
+			m.tasks[childID] = child
+		}
+	}
+}
+
+func (m *MockTaskRepository) PurgeTask(userID int64, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	root, ok := m.trash[m.resolve(id)]
+	if !ok || root.UserID != userID {
+		return errors.New("task not found: " + id)
+	}
+
+	subtree := []string{root.ID}
+	for i := 0; i < len(subtree); i++ {
+		for childID, child := range m.trash {
+			if child.ParentID != nil && *child.ParentID == subtree[i] {
+				subtree = append(subtree, childID)
+			}
+		}
+	}
+	for _, purged := range subtree {
+		m.purge(purged)
+	}
+	return nil
+}
+
+func (m *MockTaskRepository) PurgeTrash(retention time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int64
+	cutoff := time.Now().Add(-retention)
+	for id, t := range m.trash {
+		if t.DeletedAt.Before(cutoff) {
+			m.purge(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *MockTaskRepository) ArchiveTask(userID int64, id string, version int64, archived bool, limit *repository.WIPLimit) (*task.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.lookup(userID, id)
+	if !ok {
+		return nil, errors.New("task not found: " + id)
+	}
+	if version != 0 && version != t.Version {
+		return nil, errors.New("task version conflict: " + id)
+	}
+	if (t.ArchivedAt != nil) == archived {
+		t = m.withComputed(t)
+		return &t, nil
+	}
+
+	if archived {
+		m.begin()
+		return m.archive(userID, t)
+	}
+	if err := m.checkWIPLimit(userID, limit, t.ID, 1); err != nil {
+		return nil, err
+	}
+	m.begin()
+	before := t
+	t.Rank = m.placeInColumn(m.column(userID, t.Status, t.ID), 0)
+	t.ArchivedAt = nil
+	t.UpdatedAt = time.Now()
+	t.Version++
+	m.tasks[t.ID] = t
+	m.doneAt[t.ID] = t.UpdatedAt
+	m.record(userID, history.ActionUnarchive, &before, &t)
+
+	t = m.withComputed(t)
+	return &t, nil
+}
+
+// archive takes a done task off the board
+func (m *MockTaskRepository) archive(userID int64, t task.Task) (*task.Task, error) {
+	if t.StatusCategory != workflow.CategoryDone {
+		return nil, errors.New("task not done: " + t.ID)
+	}
+
+	before := t
+	now := time.Now()
+	t.ArchivedAt = &now
+	t.UpdatedAt = now
+	t.Version++
+	m.tasks[t.ID] = t
+	m.record(userID, history.ActionArchive, &before, &t)
+
+	t = m.withComputed(t)
+	return &t, nil
+}
+
+func (m *MockTaskRepository) ArchiveDoneTasks(age time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.begin()
+	var archived int64
+	cutoff := time.Now().Add(-age)
+	for _, t := range m.tasks {
+		if doneAt, ok := m.doneAt[t.ID]; ok && t.StatusCategory == workflow.CategoryDone && t.ArchivedAt == nil && doneAt.Before(cutoff) {
+			if _, err := m.archive(t.UserID, t); err != nil {
+				return 0, err
+			}
+			archived++
+		}
+	}
+	return archived, nil
+}
+
+func (m *MockTaskRepository) SetRecurrence(userID int64, id string, version int64, rule *recurrence.Rule, afterCompletion bool, loc *time.Location) (*task.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.lookup(userID, id)
+	if !ok {
+		return nil, errors.New("task not found: " + id)
+	}
+	if version != 0 && version != t.Version {
+		return nil, errors.New("task version conflict: " + id)
+	}
+
+	// Every occurrence of the series, trashed ones included, changes with it
+	befores := map[string]task.Task{t.ID: m.withComputed(t)}
+	if t.Recurrence != nil {
+		for _, tasks := range []map[string]task.Task{m.tasks, m.trash} {
+			for key, o := range tasks {
+				if o.Recurrence != nil && o.Recurrence.SeriesID == t.Recurrence.SeriesID {
+					befores[key] = m.withComputed(o)
+				}
+			}
+		}
+	}
+
+	if rule == nil {
+		if t.Recurrence == nil {
+			t = m.withComputed(t)
+			return &t, nil
+		}
+		// The series ends and its occurrences become plain tasks
+		for _, tasks := range []map[string]task.Task{m.tasks, m.trash} {
+			for key, o := range tasks {
+				if _, ok := befores[key]; ok {
+					o.Recurrence = nil
+					o.Version++
+					tasks[key] = o
+				}
+			}
+		}
+		delete(m.series, t.Recurrence.SeriesID)
+	} else {
+		from := time.Now().In(loc)
+		if t.DueAt != nil {
+			from = t.DueAt.In(loc)
+		} else if !afterCompletion {
+			return nil, errors.New("task has no due date: " + id)
+		}
+
+		latest := from
+		for key, o := range befores {
+			if key != t.ID && o.Recurrence.OccursAt != nil && o.Recurrence.OccursAt.After(latest) {
+				latest = *o.Recurrence.OccursAt
+			}
+		}
+		next, ok := rule.Next(from, latest)
+		if !ok {
+			return nil, errors.New("rule never recurs: " + rule.String())
+		}
+		s := mockSeries{userID: userID, rule: rule.String(), afterCompletion: afterCompletion, anchor: from, nextAt: &next, loc: loc}
+		if afterCompletion {
+			s.nextAt = nil
+		}
+
+		var seriesID int64
+		if t.Recurrence == nil {
+			m.nextSeriesID++
+			seriesID = m.nextSeriesID
+		} else {
+			seriesID = t.Recurrence.SeriesID
+			for _, tasks := range []map[string]task.Task{m.tasks, m.trash} {
+				for key, o := range tasks {
+					if _, ok := befores[key]; ok && key != t.ID {
+						o.Version++
+						tasks[key] = o
+					}
+				}
+			}
+		}
+		m.series[seriesID] = s
+
+		t.Recurrence = &task.Recurrence{SeriesID: seriesID, OccursAt: t.DueAt}
+		t.Version++
+		m.tasks[t.ID] = t
+	}
+
+	m.begin()
+	for _, key := range slices.Sorted(maps.Keys(befores)) {
+		before := befores[key]
+		after, ok := m.tasks[key]
+		if !ok {
+			after = m.trash[key]
+		}
+		after = m.withComputed(after)
+		m.record(userID, history.ActionRecurrence, &before, &after)
+	}
+
+	t = m.withComputed(m.tasks[t.ID])
+	return &t, nil
+}
+
+// continueSeries returns the occurrence that follows t like the real
+// repository, creating it when needed
+func (m *MockTaskRepository) continueSeries(userID int64, t task.Task) *task.Task {
+	if t.Recurrence == nil {
+		return nil
+	}
+	s, ok := m.series[t.Recurrence.SeriesID]
+	if !ok {
+		return nil
+	}
+
+	occursAt := func(o task.Task) time.Time {
+		if o.Recurrence.OccursAt == nil {
+			return time.Time{}
+		}
+		return *o.Recurrence.OccursAt
+	}
+	for _, o := range m.tasks {
+		if o.ID != t.ID && o.Recurrence != nil && o.Recurrence.SeriesID == t.Recurrence.SeriesID &&
+			o.StatusCategory != workflow.CategoryDone && !occursAt(o).Before(occursAt(t)) {
+			o = m.withComputed(o)
+			return &o
+		}
+	}
+
+	rule, err := recurrence.Parse(s.rule)
+	if err != nil {
+		return nil
+	}
+	var at time.Time
+	if s.afterCompletion {
+		if at, ok = rule.After(time.Now().In(s.loc), t.DueAt); !ok {
+			return nil
+		}
+	} else {
+		if s.nextAt == nil {
+			return nil
+		}
+		at = *s.nextAt
+		s.nextAt = nil
+		if next, ok := rule.Next(s.anchor, at); ok {
+			s.nextAt = &next
+		}
+		m.series[t.Recurrence.SeriesID] = s
+	}
+	return m.spawnOccurrence(userID, t, t.Recurrence.SeriesID, at)
+}
+
+// spawnOccurrence creates the occurrence of a series due at from the one
+// before, like the real repository
+func (m *MockTaskRepository) spawnOccurrence(userID int64, from task.Task, seriesID int64, at time.Time) *task.Task {
+	wf := workflow.Default()
+	if m.workflows != nil {
+		wf = m.workflows.boardWorkflow(userID, from.ProjectID)
+	}
+	status := wf.InitialStatus()
+
+	t := task.Task{
+		Title:          from.Title,
+		Description:    from.Description,
+		Status:         status,
+		StatusCategory: wf.Category(status),
+		Priority:       from.Priority,
+		ProjectID:      from.ProjectID,
+		DueAt:          &at,
+		EstimateHours:  from.EstimateHours,
+		ParentID:       from.ParentID,
+		Tags:           from.Tags,
+		Recurrence:     &task.Recurrence{SeriesID: seriesID, OccursAt: &at},
+	}
+	if from.StartAt != nil && from.DueAt != nil {
+		start := at.Add(-from.DueAt.Sub(*from.StartAt))
+		t.StartAt = &start
+	}
+	return m.insert(userID, t, task.KeyPrefix(from.ID))
+}
+
+func (m *MockTaskRepository) SkipOccurrence(userID int64, id string) (*task.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.lookup(userID, id)
+	if !ok {
+		return nil, errors.New("task not found: " + id)
+	}
+	if t.Recurrence == nil {
+		return nil, errors.New("task not recurring: " + id)
+	}
+	if t.StatusCategory == workflow.CategoryDone {
+		return nil, errors.New("task already done: " + id)
+	}
+
+	m.begin()
+	next := m.continueSeries(userID, t)
+	m.trashTasks(userID, m.subtree(t.ID))
+	return next, nil
+}
+
+func (m *MockTaskRepository) CreateDueOccurrences() (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.begin()
+	var created int64
+	now := time.Now()
+	for seriesID, s := range m.series {
+		if s.nextAt == nil || s.nextAt.After(now) {
+			continue
+		}
+		rule, err := recurrence.Parse(s.rule)
+		if err != nil {
+			return 0, err
+		}
+
+		at := *s.nextAt
+		next, ok := rule.Next(s.anchor, at)
+		for ok && !next.After(now) {
+			at = next
+			next, ok = rule.Next(s.anchor, at)
+		}
+
+		var latest *task.Task
+		for _, o := range m.tasks {
+			if o.Recurrence == nil || o.Recurrence.SeriesID != seriesID {
+				continue
+			}
+			if latest == nil || o.Recurrence.OccursAt != nil && (latest.Recurrence.OccursAt == nil || o.Recurrence.OccursAt.After(*latest.Recurrence.OccursAt)) {
+				latest = &o
+			}
+		}
+		if latest == nil {
+			delete(m.series, seriesID)
+			continue
+		}
+
+		m.spawnOccurrence(s.userID, *latest, seriesID, at)
+		s.nextAt = nil
+		if ok {
+			s.nextAt = &next
+		}
+		m.series[seriesID] = s
+		created++
+	}
+	return created, nil
+}
+
+func (m *MockTaskRepository) ApplyTaskChanges(userID int64, changes []repository.TaskChange, dryRun bool, limits []repository.WIPLimit) ([]*task.Task, error) {
+	for i := range changes {
+		changes[i].Task.Tags = m.canonicalTags(userID, changes[i].Task.Tags)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Keep what a rollback goes back to
+	tasks, trash, sequences, events, series := maps.Clone(m.tasks), maps.Clone(m.trash), maps.Clone(m.sequences), slices.Clone(m.events), maps.Clone(m.series)
+	rollback := func() {
+		m.tasks, m.trash, m.sequences, m.events, m.series = tasks, trash, sequences, events, series
+	}
+
+	m.begin()
+	changed := make([]*task.Task, len(changes))
+	for i, change := range changes {
+		var err error
+		if change.Delete {
+			err = m.deleteTask(userID, change.ID, change.Task.Version, change.DeleteMode)
+		} else {
+			changed[i], err = m.update(userID, change.ID, change.Task, nil)
+		}
+		if err != nil {
+			rollback()
+			return nil, &repository.TaskChangeError{Index: i, Err: err}
+		}
+	}
+	for i := range limits {
+		if err := m.checkWIPLimit(userID, &limits[i], "", 0); err != nil {
+			rollback()
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		rollback()
+	}
+	return changed, nil
 }
 
 var mockRepo *MockTaskRepository
+var mockProjectRepo *MockProjectRepository
+var mockUserRepo *MockUserRepository
+var mockAPITokenRepo *MockAPITokenRepository
+var mockTagRepo *MockTagRepository
+var mockWorkflowRepo *MockWorkflowRepository
+var mockTemplateRepo *MockTemplateRepository
+var mockIdempotencyRepo *MockIdempotencyKeyRepository
+
+// testUserID is the caller that setupTestRouter authenticates every request as
+const testUserID int64 = 1
 
 func setupTest() {
 	gin.SetMode(gin.TestMode)
-	nextID = 1
+	auth.Init([]byte("test-secret"), time.Hour)
 	mockRepo = NewMockTaskRepository()
+	mockProjectRepo = NewMockProjectRepository(mockRepo)
+	mockUserRepo = NewMockUserRepository()
+	mockAPITokenRepo = NewMockAPITokenRepository()
+	mockTagRepo = NewMockTagRepository(mockRepo)
+	mockWorkflowRepo = NewMockWorkflowRepository(mockRepo)
+	mockTemplateRepo = NewMockTemplateRepository()
+	mockIdempotencyRepo = NewMockIdempotencyKeyRepository()
 	repository.Tasks = mockRepo
+	repository.Projects = mockProjectRepo
+	repository.Users = mockUserRepo
+	repository.APITokens = mockAPITokenRepo
+	repository.Tags = mockTagRepo
+	repository.Workflows = mockWorkflowRepo
+	repository.Templates = mockTemplateRepo
+	repository.IdempotencyKeys = mockIdempotencyRepo
 }
 
 func tearDownTest() {
 	// Mock repository doesn't need cleanup
 }
 
+// withTestUser stands in for RequireAuth so handler tests don't need tokens
+func withTestUser(c *gin.Context) {
+	c.Set(userIDKey, testUserID)
+	c.Next()
+}
+
 func setupTestRouter() *gin.Engine {
 	r := gin.Default()
+	r.Use(withTestUser, Idempotency())
 
 	r.GET("/api/task", GetTaskHandler)
+	r.GET("/api/task/:id", GetTaskByIDHandler)
 	r.POST("/api/task", PostTaskHandler)
+	r.POST("/api/task/bulk", BulkTaskHandler)
+	r.POST("/api/task/:id/rekey", RekeyTaskHandler)
+	r.POST("/api/task/:id/move", MoveTaskHandler)
+	r.GET("/api/task/:id/history", GetTaskHistoryHandler)
+	r.POST("/api/task/:id/revert", RevertTaskHandler)
+	r.POST("/api/task/:id/archive", ArchiveTaskHandler)
+	r.POST("/api/task/:id/unarchive", UnarchiveTaskHandler)
 	r.PUT("/api/task/:id", PutTaskHandler)
+	r.PATCH("/api/task/:id", PatchTaskHandler)
 	r.DELETE("/api/task/:id", DeleteTaskHandler)
+	r.PUT("/api/task/:id/recurrence", PutRecurrenceHandler)
+	r.DELETE("/api/task/:id/recurrence", DeleteRecurrenceHandler)
+	r.POST("/api/task/:id/skip", SkipOccurrenceHandler)
+	r.GET("/api/task/:id/subtasks", GetSubtasksHandler)
+	r.POST("/api/task/:id/subtasks", PostSubtaskHandler)
+	r.POST("/api/task/:id/dependencies", PostDependencyHandler)
+	r.DELETE("/api/task/:id/dependencies/:blocker_id", DeleteDependencyHandler)
+	r.GET("/api/graph", GetGraphHandler)
+	r.GET("/api/workflow", GetWorkflowHandler)
+	r.PUT("/api/workflow", PutWorkflowHandler)
+	r.GET("/api/board", GetBoardHandler)
+	r.POST("/api/undo", UndoHandler)
+	r.GET("/api/trash", GetTrashHandler)
+	r.POST("/api/trash/:id/restore", RestoreTrashHandler)
+	r.DELETE("/api/trash/:id", DeleteTrashHandler)
+	r.GET("/api/archive", GetArchiveHandler)
+
+	r.GET("/api/projects", GetProjectsHandler)
+	r.GET("/api/projects/:id", GetProjectHandler)
+	r.POST("/api/projects", PostProjectHandler)
+	r.PUT("/api/projects/:id", PutProjectHandler)
+	r.DELETE("/api/projects/:id", DeleteProjectHandler)
+
+	r.GET("/api/tags", GetTagsHandler)
+	r.GET("/api/tags/:id", GetTagHandler)
+	r.POST("/api/tags", PostTagHandler)
+	r.PUT("/api/tags/:id", PutTagHandler)
+	r.DELETE("/api/tags/:id", DeleteTagHandler)
+
+	r.GET("/api/templates", GetTemplatesHandler)
+	r.GET("/api/templates/:id", GetTemplateHandler)
+	r.POST("/api/templates", PostTemplateHandler)
+	r.PUT("/api/templates/:id", PutTemplateHandler)
+	r.DELETE("/api/templates/:id", DeleteTemplateHandler)
+	r.POST("/api/templates/:id/instantiate", InstantiateTemplateHandler)
 
 	return r
 }
@@ -150,6 +1633,19 @@ func marshalTaskBody(title string, description string, status string, priority s
 	return jsonBody
 }
 
+// createTestTask creates a task from its JSON fields and returns it as created
+func createTestTask(t *testing.T, r *gin.Engine, fields map[string]any) task.Task {
+	body, err := json.Marshal(fields)
+	assert.NoError(t, err)
+
+	w := makePostRequest(r, body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created task.Task
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	return created
+}
+
 // makePostRequest creates a POST request for testing
 func makePostRequest(r *gin.Engine, body []byte) *httptest.ResponseRecorder {
 	req, _ := http.NewRequest("POST", "/api/task", bytes.NewBuffer(body))
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"tasker/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrashHandler handles GET /api/trash requests, listing deleted tasks that
+// haven't been purged yet, most recently deleted first
+func GetTrashHandler(c *gin.Context) {
+	tasks, err := repository.Tasks.GetTrash(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// RestoreTrashHandler handles POST /api/trash/:id/restore requests. Subtasks
+// deleted together with the task come back with it; ones deleted on their own
+// before stay in the trash. Like undo, restoring puts tasks back where they
+// were, so WIP limits don't apply, but their column, project and parent have
+// to still be there.
+func RestoreTrashHandler(c *gin.Context) {
+	subtree, err := repository.Tasks.GetTrashedSubtree(currentUserID(c), c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get trash"})
+		return
+	}
+
+	restoring := make(map[string]bool, len(subtree))
+	for _, t := range subtree {
+		restoring[t.ID] = true
+	}
+
+	details := gin.H{}
+	for i := range subtree {
+		validationErrors := make(map[string]string)
+		if _, ok := validateRestore(c, &subtree[i], restoring, validationErrors); !ok {
+			return
+		}
+		if len(validationErrors) > 0 {
+			details[subtree[i].ID] = validationErrors
+		}
+	}
+	if len(details) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "task can't be restored", "details": details})
+		return
+	}
+
+	restored, err := repository.Tasks.RestoreFromTrash(currentUserID(c), subtree)
+	if err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		if message, ok := parentError(err); ok {
+			c.JSON(http.StatusConflict, gin.H{"error": "task can't be restored", "details": gin.H{"parent_id": message}})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore task"})
+		return
+	}
+
+	c.JSON(http.StatusOK, restored)
+}
+
+// DeleteTrashHandler handles DELETE /api/trash/:id requests by deleting a task
+// in the trash for good, together with its subtasks and its history
+func DeleteTrashHandler(c *gin.Context) {
+	if err := repository.Tasks.PurgeTask(currentUserID(c), c.Param("id")); err != nil {
+		if strings.Contains(err.Error(), "task not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete task"})
+		return
+	}
+
+	c.Status(http.StatusNoContent) // 204 No Content
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTrash(t *testing.T, r *gin.Engine) []task.Task {
+	w := makeJSONRequest(r, "GET", "/api/trash", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var tasks []task.Task
+	json.Unmarshal(w.Body.Bytes(), &tasks)
+	return tasks
+}
+
+func restoreFromTrash(r *gin.Engine, id string) *httptest.ResponseRecorder {
+	return makeJSONRequest(r, "POST", "/api/trash/"+id+"/restore", nil)
+}
+
+func TestDeleteTaskHandler_MovesTaskToTrash(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	kept := createTestTask(t, r, map[string]any{"title": "Kept", "status": "TODO"})
+	deleted := createTestTask(t, r, map[string]any{"title": "Deleted", "status": "TODO"})
+	assert.Equal(t, http.StatusNoContent, makeDeleteRequest(r, deleted.ID).Code)
+
+	w := makeJSONRequest(r, "GET", "/api/task/"+deleted.ID, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, []string{"Kept"}, columnTitles(t, r, "TODO"))
+
+	trash := getTrash(t, r)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, deleted.ID, trash[0].ID)
+	assert.NotNil(t, trash[0].DeletedAt)
+	assert.Len(t, getHistory(t, r, deleted.ID), 2)
+
+	// Only trashed tasks can be restored or purged
+	assert.Equal(t, http.StatusNotFound, restoreFromTrash(r, kept.ID).Code)
+	assert.Equal(t, http.StatusNotFound, makeJSONRequest(r, "DELETE", "/api/trash/"+kept.ID, nil).Code)
+}
+
+func TestRestoreTrashHandler_BringsBackSubtasksDeletedTogether(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, map[string]any{"title": "Parent", "status": "TODO"})
+	child := createSubtask(t, r, parent.ID, "Child")
+	earlier := createSubtask(t, r, parent.ID, "Deleted earlier")
+	assert.Equal(t, http.StatusNoContent, makeDeleteRequest(r, earlier.ID).Code)
+	time.Sleep(time.Millisecond)
+
+	w := makeJSONRequest(r, "DELETE", "/api/task/"+parent.ID+"?subtasks=cascade", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Len(t, getTrash(t, r), 3)
+
+	w = restoreFromTrash(r, parent.ID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var restored []task.Task
+	json.Unmarshal(w.Body.Bytes(), &restored)
+	assert.Len(t, restored, 2)
+	assert.Nil(t, restored[0].DeletedAt)
+
+	got := getTask(t, r, child.ID)
+	assert.Equal(t, &parent.ID, got.ParentID)
+	assert.Equal(t, child.Version+1, got.Version)
+	assert.Equal(t, history.ActionRestore, getHistory(t, r, parent.ID)[0].Action)
+
+	trash := getTrash(t, r)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, earlier.ID, trash[0].ID)
+}
+
+func TestRestoreTrashHandler_RefusesWhileParentIsInTrash(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, map[string]any{"title": "Parent", "status": "TODO"})
+	child := createSubtask(t, r, parent.ID, "Child")
+	assert.Equal(t, http.StatusNoContent, makeDeleteRequest(r, child.ID).Code)
+	assert.Equal(t, http.StatusNoContent, makeDeleteRequest(r, parent.ID).Code)
+
+	w := restoreFromTrash(r, child.ID)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Contains(t, resp["details"], child.ID)
+	assert.Len(t, getTrash(t, r), 2)
+
+	assert.Equal(t, http.StatusOK, restoreFromTrash(r, parent.ID).Code)
+	assert.Equal(t, http.StatusOK, restoreFromTrash(r, child.ID).Code)
+	assert.Empty(t, getTrash(t, r))
+}
+
+func TestDeleteTrashHandler_DeletesForGood(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, map[string]any{"title": "Parent", "status": "TODO"})
+	child := createSubtask(t, r, parent.ID, "Child")
+	w := makeJSONRequest(r, "DELETE", "/api/task/"+parent.ID+"?subtasks=cascade", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = makeJSONRequest(r, "DELETE", "/api/trash/"+parent.ID, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, getTrash(t, r))
+
+	for _, id := range []string{parent.ID, child.ID} {
+		w = makeJSONRequest(r, "GET", "/api/task/"+id+"/history", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	}
+	assert.Equal(t, http.StatusNotFound, restoreFromTrash(r, parent.ID).Code)
+	assert.Equal(t, http.StatusNotFound, makeJSONRequest(r, "DELETE", "/api/trash/"+parent.ID, nil).Code)
+
+	// With its history gone, the delete can't be undone either
+	w = makeJSONRequest(r, "POST", "/api/undo", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestTrash_TasksInItDontCount(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	blocker := createTestTask(t, r, map[string]any{"title": "Blocker", "tags": []string{"home"}})
+	blocked := createTestTask(t, r, map[string]any{"title": "Blocked", "tags": []string{"home"}})
+	assert.Equal(t, http.StatusOK, addDependency(r, blocked.ID, blocker.ID).Code)
+
+	assert.Equal(t, http.StatusNoContent, makeDeleteRequest(r, blocker.ID).Code)
+	assert.Empty(t, getTask(t, r, blocked.ID).BlockedBy)
+	assert.Equal(t, 1, getTags(t, r)[0].TaskCount)
+
+	// The dependency comes back with the task
+	assert.Equal(t, http.StatusOK, restoreFromTrash(r, blocker.ID).Code)
+	assert.Equal(t, []string{blocker.ID}, []string(getTask(t, r, blocked.ID).BlockedBy))
+	assert.Equal(t, 2, getTags(t, r)[0].TaskCount)
+}
+
+func TestPurgeTrash_EmptiesExpiredTasks(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	old := createTestTask(t, r, map[string]any{"title": "Old", "status": "TODO"})
+	recent := createTestTask(t, r, map[string]any{"title": "Recent", "status": "TODO"})
+	assert.Equal(t, http.StatusNoContent, makeDeleteRequest(r, old.ID).Code)
+	assert.Equal(t, http.StatusNoContent, makeDeleteRequest(r, recent.ID).Code)
+
+	longAgo := time.Now().Add(-31 * 24 * time.Hour)
+	trashed := mockRepo.trash[old.ID]
+	trashed.DeletedAt = &longAgo
+	mockRepo.trash[old.ID] = trashed
+
+	purged, err := mockRepo.PurgeTrash(30 * 24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	trash := getTrash(t, r)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, recent.ID, trash[0].ID)
+}
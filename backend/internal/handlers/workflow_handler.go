@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"tasker/internal/repository"
+	"tasker/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWorkflowColumns keeps boards to a width that still fits on a screen
+const maxWorkflowColumns = 20
+
+// loadWorkflow returns the workflow for the board of projectID, responding with
+// a 500 when it can't be read
+func loadWorkflow(c *gin.Context, projectID *int64) (*workflow.Workflow, bool) {
+	wf, err := repository.Workflows.GetWorkflow(currentUserID(c), projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get workflow"})
+		return nil, false
+	}
+	return wf, true
+}
+
+// validateStatus adds a status error when status isn't a column of wf
+func validateStatus(wf *workflow.Workflow, status string, errors map[string]string) {
+	if wf.Category(status) == "" {
+		errors["status"] = "status must be one of: " + strings.Join(wf.Statuses(), ", ")
+	}
+}
+
+// validateTransition adds a status error when wf doesn't allow moving from one
+// status to the other. Statuses from another board are not checked.
+func validateTransition(wf *workflow.Workflow, from, to string, errors map[string]string) {
+	if errors["status"] == "" && wf.Category(from) != "" && !wf.CanTransition(from, to) {
+		errors["status"] = fmt.Sprintf("cannot move from %s to %s", from, to)
+	}
+}
+
+func validateWorkflow(w workflow.Workflow) map[string]string {
+	errors := make(map[string]string)
+
+	if len(w.Columns) == 0 || len(w.Columns) > maxWorkflowColumns {
+		errors["columns"] = fmt.Sprintf("a workflow needs between 1 and %d columns", maxWorkflowColumns)
+		return errors
+	}
+
+	seen := make([]string, 0, len(w.Columns))
+	for _, col := range w.Columns {
+		switch {
+		case col.Name == "" || utf8.RuneCountInString(col.Name) > 50:
+			errors["columns"] = "column names must be 1-50 characters"
+		case col.Name != strings.TrimSpace(col.Name):
+			errors["columns"] = "column names cannot start or end with spaces"
+		case slices.Contains(seen, col.Name):
+			errors["columns"] = "column names must be unique: " + col.Name
+		case !slices.Contains(workflow.Categories, col.Category):
+			errors["columns"] = "column categories must be one of: " + strings.Join(workflow.Categories, ", ")
+		case col.WIPLimit != nil && *col.WIPLimit < 1:
+			errors["columns"] = "column WIP limits must be at least 1"
+		}
+		seen = append(seen, col.Name)
+	}
+	if !slices.ContainsFunc(w.Columns, func(col workflow.Column) bool { return col.Category == workflow.CategoryDone }) {
+		errors["columns"] = "a workflow needs at least one done column"
+	}
+
+	for from, targets := range w.Transitions {
+		if !slices.Contains(seen, from) {
+			errors["transitions"] = "transitions refer to an unknown column: " + from
+		}
+		for _, to := range targets {
+			if !slices.Contains(seen, to) {
+				errors["transitions"] = "transitions refer to an unknown column: " + to
+			}
+		}
+	}
+
+	return errors
+}
+
+// parseWorkflowProject reads the optional ?project= board selector. It responds
+// and returns false when the project is malformed or doesn't exist.
+func parseWorkflowProject(c *gin.Context) (*int64, bool) {
+	param := c.Query("project")
+	if param == "" {
+		return nil, true
+	}
+
+	projectID, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": gin.H{"project": "invalid project id"}})
+		return nil, false
+	}
+	if _, err := repository.Projects.GetProjectByID(currentUserID(c), projectID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return nil, false
+	}
+	return &projectID, true
+}
+
+// GetWorkflowHandler handles GET /api/workflow requests. It returns the columns
+// of the default board, or of a project's board with ?project=. A project_id of
+// null in the response means the board uses the default workflow.
+func GetWorkflowHandler(c *gin.Context) {
+	projectID, ok := parseWorkflowProject(c)
+	if !ok {
+		return
+	}
+
+	wf, ok := loadWorkflow(c, projectID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, wf)
+}
+
+// PutWorkflowHandler handles PUT /api/workflow requests, replacing the columns
+// and transitions of the default board or, with ?project=, of a project's board
+func PutWorkflowHandler(c *gin.Context) {
+	projectID, ok := parseWorkflowProject(c)
+	if !ok {
+		return
+	}
+
+	var wf workflow.Workflow
+	if err := c.ShouldBindJSON(&wf); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+		return
+	}
+
+	if validationErrors := validateWorkflow(wf); len(validationErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "validation failed", "details": validationErrors})
+		return
+	}
+
+	wf.ProjectID = projectID
+	saved, err := repository.Workflows.SaveWorkflow(currentUserID(c), wf)
+	if err != nil {
+		var inUse *repository.StatusInUseError
+		if errors.As(err, &inUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": "tasks are still in statuses the workflow drops", "statuses": inUse.Statuses})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save workflow"})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
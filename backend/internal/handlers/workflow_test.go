@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tasker/internal/history"
+	"tasker/internal/workflow"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// reviewWorkflow is a board with the Waiting and Review columns, where work has
+// to pass review before it is done
+const reviewWorkflow = `{
+	"columns": [
+		{"name": "Backlog", "category": "todo"},
+		{"name": "In Progress", "category": "active"},
+		{"name": "Waiting", "category": "active"},
+		{"name": "Review", "category": "active"},
+		{"name": "Shipped", "category": "done"}
+	],
+	"transitions": {
+		"Backlog": ["In Progress"],
+		"In Progress": ["Waiting", "Review"],
+		"Waiting": ["In Progress"],
+		"Review": ["In Progress", "Shipped"],
+		"Shipped": []
+	}
+}`
+
+func putWorkflow(r *gin.Engine, query string, body string) *httptest.ResponseRecorder {
+	return makeJSONRequest(r, "PUT", "/api/workflow"+query, []byte(body))
+}
+
+func getWorkflow(t *testing.T, r *gin.Engine, query string) workflow.Workflow {
+	w := makeJSONRequest(r, "GET", "/api/workflow"+query, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var wf workflow.Workflow
+	json.Unmarshal(w.Body.Bytes(), &wf)
+	return wf
+}
+
+func TestGetWorkflowHandler_Default(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	wf := getWorkflow(t, r, "")
+	assert.Equal(t, []string{"TODO", "In Progress", "Done"}, wf.Statuses())
+	assert.Equal(t, workflow.CategoryDone, wf.Category("Done"))
+	assert.Nil(t, wf.ProjectID)
+
+	created := createTestTask(t, r, map[string]any{"title": "Default board", "status": ""})
+	assert.Equal(t, "TODO", created.Status)
+	assert.Equal(t, workflow.CategoryTodo, created.StatusCategory)
+}
+
+func TestPutWorkflowHandler_CustomColumns(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	w := putWorkflow(r, "", reviewWorkflow)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	wf := getWorkflow(t, r, "")
+	assert.Equal(t, []string{"Backlog", "In Progress", "Waiting", "Review", "Shipped"}, wf.Statuses())
+
+	// New tasks start in the first column
+	created := createTestTask(t, r, map[string]any{"title": "Custom board", "status": ""})
+	assert.Equal(t, "Backlog", created.Status)
+	assert.Equal(t, workflow.CategoryTodo, created.StatusCategory)
+
+	waiting := createTestTask(t, r, map[string]any{"title": "Waiting on vendor", "status": "Waiting"})
+	assert.Equal(t, workflow.CategoryActive, waiting.StatusCategory)
+
+	// The old hard-coded statuses are gone from this board
+	w = makePostRequest(r, marshalTaskBody("Old status", "", "TODO", ""))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	details := resp["details"].(map[string]any)
+	assert.Equal(t, "status must be one of: Backlog, In Progress, Waiting, Review, Shipped", details["status"])
+}
+
+func TestWorkflow_EnforcesTransitions(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	assert.Equal(t, http.StatusOK, putWorkflow(r, "", reviewWorkflow).Code)
+	created := createTestTask(t, r, map[string]any{"title": "Needs review", "status": "In Progress"})
+
+	w := makePatchRequest(r, created.ID, `{"status":"Shipped"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	details := resp["details"].(map[string]any)
+	assert.Equal(t, "cannot move from In Progress to Shipped", details["status"])
+
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, created.ID, `{"status":"Review"}`).Code)
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, created.ID, `{"status":"Shipped"}`).Code)
+
+	// Shipped is final, for PUT as much as for PATCH
+	body := []byte(`{"title":"Needs review","status":"Review","priority":"Medium"}`)
+	assert.Equal(t, http.StatusBadRequest, makePutRequest(r, created.ID, body).Code)
+
+	// Staying put is always allowed
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, created.ID, `{"status":"Shipped","title":"Reviewed"}`).Code)
+
+	// Unknown statuses are reported as such rather than as a transition
+	w = makePatchRequest(r, created.ID, `{"status":"Blocked"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	details = resp["details"].(map[string]any)
+	assert.Contains(t, details["status"], "status must be one of")
+}
+
+func TestPutWorkflowHandler_RefusesDroppingStatusesInUse(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Started", "status": "In Progress"})
+	createTestTask(t, r, map[string]any{"title": "Finished", "status": "Done"})
+
+	w := putWorkflow(r, "", reviewWorkflow)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, []any{"Done"}, resp["statuses"])
+
+	// Nothing changed
+	assert.Equal(t, []string{"TODO", "In Progress", "Done"}, getWorkflow(t, r, "").Statuses())
+	assert.Equal(t, created.Version, getTask(t, r, created.ID).Version)
+}
+
+func TestPutWorkflowHandler_RecategorisesTasks(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	parent := createTestTask(t, r, map[string]any{"title": "Release", "status": ""})
+	review := createSubtask(t, r, parent.ID, "Review copy")
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, review.ID, `{"status":"In Progress"}`).Code)
+	before := getTask(t, r, review.ID)
+
+	// Treating In Progress as finished work completes the subtask
+	w := putWorkflow(r, "", `{"columns":[
+		{"name":"TODO","category":"todo"},
+		{"name":"In Progress","category":"done"},
+		{"name":"Done","category":"done"}
+	]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	after := getTask(t, r, review.ID)
+	assert.Equal(t, workflow.CategoryDone, after.StatusCategory)
+	assert.Greater(t, after.Version, before.Version)
+	assert.Equal(t, 100, getTask(t, r, parent.ID).Progress.Percent)
+}
+
+func TestPutWorkflowHandler_RecordsRecategorisationInHistory(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	created := createTestTask(t, r, map[string]any{"title": "Review copy", "status": "In Progress"})
+	other := createTestTask(t, r, map[string]any{"title": "Left alone", "status": "TODO"})
+
+	w := putWorkflow(r, "", `{"columns":[
+		{"name":"TODO","category":"todo"},
+		{"name":"In Progress","category":"done"},
+		{"name":"Done","category":"done"}
+	]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	after := getTask(t, r, created.ID)
+	events := getHistory(t, r, created.ID)
+	if assert.Equal(t, 2, len(events)) {
+		assert.Equal(t, history.ActionWorkflow, events[0].Action)
+		assert.Equal(t, after.Version, events[0].Version)
+		before, now := change(events[0], "status_category")
+		assert.Equal(t, `"active"`, before)
+		assert.Equal(t, `"done"`, now)
+	}
+	assert.Equal(t, 1, len(getHistory(t, r, other.ID)))
+
+	// The category comes from the workflow, so going back doesn't undo it
+	w = revertTask(r, created.ID, created.Version)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, workflow.CategoryDone, getTask(t, r, created.ID).StatusCategory)
+}
+
+func TestWorkflow_DoneColumnsCompleteTasks(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	assert.Equal(t, http.StatusOK, putWorkflow(r, "", reviewWorkflow).Code)
+	blocker := createTestTask(t, r, map[string]any{"title": "Review", "status": "Review"})
+	blocked := createTestTask(t, r, map[string]any{"title": "Announce", "status": "Review"})
+	assert.Equal(t, http.StatusOK, addDependency(r, blocked.ID, blocker.ID).Code)
+
+	// Shipped counts as done, so the blocker has to get there first
+	assert.Equal(t, http.StatusConflict, makePatchRequest(r, blocked.ID, `{"status":"Shipped"}`).Code)
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, blocker.ID, `{"status":"Shipped"}`).Code)
+	assert.Equal(t, http.StatusOK, makePatchRequest(r, blocked.ID, `{"status":"Shipped"}`).Code)
+
+	done, _ := listTasks(t, r, "?category=done")
+	assert.ElementsMatch(t, []string{"Review", "Announce"}, taskTitles(done))
+	active, _ := listTasks(t, r, "?category=active")
+	assert.Empty(t, active)
+}
+
+func TestWorkflow_PerProjectBoards(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+	proj := createTestProject(t, r, "Launch")
+	query := fmt.Sprintf("?project=%d", proj.ID)
+
+	// A project without its own workflow uses the default board
+	assert.Nil(t, getWorkflow(t, r, query).ProjectID)
+
+	assert.Equal(t, http.StatusOK, putWorkflow(r, query, reviewWorkflow).Code)
+	wf := getWorkflow(t, r, query)
+	assert.Equal(t, proj.ID, *wf.ProjectID)
+	assert.Equal(t, "Backlog", wf.InitialStatus())
+	assert.Equal(t, "TODO", getWorkflow(t, r, "").InitialStatus())
+
+	inProject := createTestTask(t, r, map[string]any{"title": "Launch plan", "project_id": proj.ID})
+	assert.Equal(t, "Backlog", inProject.Status)
+	assert.Equal(t, "TODO", createTestTask(t, r, map[string]any{"title": "Groceries", "status": ""}).Status)
+
+	// Moving a task onto the project board needs a status that board has
+	loose := createTestTask(t, r, map[string]any{"title": "Loose end", "status": "Done"})
+	w := makePatchRequest(r, loose.ID, fmt.Sprintf(`{"project_id":%d}`, proj.ID))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = makePatchRequest(r, loose.ID, fmt.Sprintf(`{"project_id":%d,"status":"Shipped"}`, proj.ID))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = putWorkflow(r, "?project=999", reviewWorkflow)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPutWorkflowHandler_ValidationErrors(t *testing.T) {
+	setupTest()
+	defer tearDownTest()
+
+	r := setupTestRouter()
+
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"no columns", `{"columns":[]}`, "columns"},
+		{"no done column", `{"columns":[{"name":"Open","category":"todo"}]}`, "columns"},
+		{"unknown category", `{"columns":[{"name":"Open","category":"blocked"},{"name":"Done","category":"done"}]}`, "columns"},
+		{"duplicate name", `{"columns":[{"name":"Done","category":"todo"},{"name":"Done","category":"done"}]}`, "columns"},
+		{"blank name", `{"columns":[{"name":" ","category":"todo"},{"name":"Done","category":"done"}]}`, "columns"},
+		{"unknown transition", `{"columns":[{"name":"Done","category":"done"}],"transitions":{"Done":["Open"]}}`, "transitions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := putWorkflow(r, "", tt.body)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var resp map[string]any
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			details := resp["details"].(map[string]any)
+			assert.Contains(t, details, tt.field)
+		})
+	}
+
+	w := putWorkflow(r, "?project=abc", reviewWorkflow)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
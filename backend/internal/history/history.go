@@ -0,0 +1,193 @@
+// Package history records what happened to tasks: one event per write, with the
+// fields it changed
+package history
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	task "tasker/internal/Task"
+)
+
+// Event actions
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionMove   = "move"
+	ActionDelete = "delete"
+	// ActionRevert restores a task to an earlier version on request
+	ActionRevert = "revert"
+	// ActionUndo reverses an earlier mutation; undoing it again is not possible
+	ActionUndo = "undo"
+	// ActionRestore takes a task out of the trash
+	ActionRestore = "restore"
+	// ActionArchive and ActionUnarchive take a done task off the board and put it back
+	ActionArchive   = "archive"
+	ActionUnarchive = "unarchive"
+	// ActionRecurrence sets or ends the recurrence of a task's series. Revert
+	// and undo leave recurrence alone, so it isn't undone.
+	ActionRecurrence = "recurrence"
+	// ActionWorkflow is a workflow change putting the task's column in another
+	// category. The category follows the workflow, so revert and undo leave it
+	// alone.
+	ActionWorkflow = "workflow"
+	// ActionRekey gives the task a new key. The old one keeps resolving, so
+	// revert and undo leave the key alone.
+	ActionRekey = "rekey"
+)
+
+// Change is a field's value before and after an event, as JSON. A field that
+// didn't exist yet, or no longer does, is null.
+type Change struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// Changes maps field names to how they changed. It is stored as a JSON object.
+type Changes map[string]Change
+
+// Event is one write to a task. Version is the task's version after the write,
+// or the last one it had for a delete. Events written together, such as the
+// deletes of a cascade, share a MutationID and are undone together.
+type Event struct {
+	ID         int64     `json:"id" db:"id"`
+	UserID     int64     `json:"-" db:"user_id"`
+	TaskID     string    `json:"task_id" db:"task_id"`
+	ActorID    int64     `json:"actor_id" db:"actor_id"`
+	Action     string    `json:"action" db:"action"`
+	Changes    Changes   `json:"changes" db:"changes"`
+	Version    int64     `json:"version" db:"version"`
+	MutationID int64     `json:"-" db:"mutation_id"`
+	Undone     bool      `json:"undone" db:"undone"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// Undoable reports whether undo may reverse events with this action. Creates
+// are left alone, and an undo is not undone again.
+func Undoable(action string) bool {
+	switch action {
+	case ActionUpdate, ActionMove, ActionDelete, ActionRevert:
+		return true
+	}
+	return false
+}
+
+// recurrence is what history keeps of a task's recurrence. When the series'
+// next occurrence comes due moves on by itself, so it is left out.
+type recurrence struct {
+	SeriesID        int64      `json:"series_id"`
+	Rule            string     `json:"rule"`
+	AfterCompletion bool       `json:"after_completion"`
+	Timezone        string     `json:"timezone"`
+	OccursAt        *time.Time `json:"occurs_at"`
+}
+
+// Fields returns the fields history keeps track of, as JSON. Derived values
+// such as progress or the dependency lists are left out.
+func Fields(t *task.Task) map[string]json.RawMessage {
+	var recurring *recurrence
+	if r := t.Recurrence; r != nil {
+		recurring = &recurrence{r.SeriesID, r.Rule, r.AfterCompletion, r.Timezone, r.OccursAt}
+	}
+
+	values := map[string]any{
+		"title":          t.Title,
+		"description":    t.Description,
+		"status":         t.Status,
+		"rank":           t.Rank,
+		"priority":       t.Priority,
+		"project_id":     t.ProjectID,
+		"parent_id":      t.ParentID,
+		"start_at":       t.StartAt,
+		"due_at":         t.DueAt,
+		"estimate_hours": t.EstimateHours,
+		"tags":           t.Tags,
+		"archived_at":    t.ArchivedAt,
+		"recurrence":     recurring,
+	}
+
+	fields := make(map[string]json.RawMessage, len(values))
+	for name, v := range values {
+		raw, _ := json.Marshal(v)
+		fields[name] = raw
+	}
+	return fields
+}
+
+// Diff returns the fields that differ between before and after. A nil before
+// is a task being created and a nil after one being deleted.
+func Diff(before, after *task.Task) Changes {
+	null := json.RawMessage("null")
+	var from, to map[string]json.RawMessage
+	if before != nil {
+		from = Fields(before)
+	}
+	if after != nil {
+		to = Fields(after)
+	}
+
+	names := from
+	if names == nil {
+		names = to
+	}
+
+	changes := Changes{}
+	for name := range names {
+		b, a := from[name], to[name]
+		if b == nil {
+			b = null
+		}
+		if a == nil {
+			a = null
+		}
+		if !bytes.Equal(b, a) {
+			changes[name] = Change{Before: b, After: a}
+		}
+	}
+	return changes
+}
+
+// Rewind works a task's fields back to what they were at version, given its
+// events newest first. fields is nil for a deleted task. Each event newer than
+// version is undone by putting back its before values; a delete's before
+// values are the whole task as it was, which is where a restored task picks
+// up. It reports false when the events don't reach back as far as version.
+func Rewind(fields map[string]json.RawMessage, events []Event, version int64) (map[string]json.RawMessage, bool) {
+	rewound := make(map[string]json.RawMessage, len(fields))
+	for name, value := range fields {
+		rewound[name] = value
+	}
+
+	for i, e := range events {
+		if e.Version <= version && e.Action != ActionDelete {
+			return rewound, true
+		}
+		for name, c := range e.Changes {
+			rewound[name] = c.Before
+		}
+		// Without an older event, what the task looked like is only known
+		// back to the version this one started from
+		if i == len(events)-1 {
+			return rewound, e.Version-1 <= version
+		}
+	}
+	return rewound, false
+}
+
+func (c Changes) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *Changes) Scan(src any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("cannot scan %T into history.Changes", src)
+	}
+}
@@ -0,0 +1,79 @@
+// Package idempotency lets clients retry writes safely. The first response to
+// a request carrying an Idempotency-Key is kept and replayed to every retry
+// with the same key.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Header carries the key chosen by the client
+const Header = "Idempotency-Key"
+
+// ReplayedHeader marks a response that was replayed rather than produced anew
+const ReplayedHeader = "Idempotent-Replayed"
+
+// TTL is how long a response is kept for retries
+const TTL = 24 * time.Hour
+
+// LockTimeout is how long a key stays reserved for a request that hasn't
+// answered. A reservation older than this belongs to a request that died with
+// the server, and the key can be used again.
+const LockTimeout = time.Minute
+
+// MaxKeyLength bounds the keys clients may send; a UUID is plenty
+const MaxKeyLength = 255
+
+// ReplayedHeaders are the response headers kept alongside the body
+var ReplayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Record is a key as used by one caller. StatusCode is nil while the first
+// request with the key is still running.
+type Record struct {
+	UserID int64  `db:"user_id"`
+	Key    string `db:"idempotency_key"`
+	// Fingerprint identifies the request the key was first used for
+	Fingerprint string    `db:"fingerprint"`
+	StatusCode  *int      `db:"status_code"`
+	Headers     Headers   `db:"headers"`
+	Body        []byte    `db:"body"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// Headers are the kept response headers, stored as JSON
+type Headers map[string]string
+
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	return json.Marshal(h)
+}
+
+func (h *Headers) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	default:
+		return fmt.Errorf("cannot scan %T into idempotency.Headers", src)
+	}
+}
+
+// Fingerprint hashes what makes two requests the same: method, path with
+// query, and body
+func Fingerprint(method, uri string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", method, uri)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,265 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// advisoryLockKey serialises migration steps across replicas that boot at the same time
+const advisoryLockKey = 7345501
+
+var fileNamePattern = regexp.MustCompile(`^(\d{6})_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change with its up and down SQL
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied
+type Status struct {
+	Version   int        `db:"version"`
+	Name      string     `db:"name"`
+	AppliedAt *time.Time `db:"applied_at"`
+}
+
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+// Load discovers every NNNNNN_name.up.sql / .down.sql pair in fsys, sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %06d has conflicting names: %s and %s", version, m.Name, match[2])
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %06d_%s must have both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+func New(db *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest returns the highest known migration version, or 0 when there are none
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Current returns the highest applied migration version, or 0 when nothing is applied
+func (m *Migrator) Current() (int, error) {
+	var version int
+	if err := m.db.Get(&version, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Status lists every known migration along with when it was applied
+func (m *Migrator) Status() ([]Status, error) {
+	var applied []Status
+	if err := m.db.Select(&applied, `SELECT version, name, applied_at FROM schema_migrations ORDER BY version`); err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	appliedAt := make(map[int]*time.Time, len(applied))
+	for _, s := range applied {
+		appliedAt[s.Version] = s.AppliedAt
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		statuses = append(statuses, Status{Version: mig.Version, Name: mig.Name, AppliedAt: appliedAt[mig.Version]})
+	}
+
+	return statuses, nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down rolls back the most recently applied migration
+func (m *Migrator) Down() error {
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return nil
+	}
+
+	target := 0
+	for _, mig := range m.migrations {
+		if mig.Version < current {
+			target = mig.Version
+		}
+	}
+
+	return m.To(target)
+}
+
+// To migrates up or down until version is the latest applied migration
+func (m *Migrator) To(version int) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("unknown migration version: %d", version)
+	}
+
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+
+	if version >= current {
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			if err := m.apply(mig, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if mig.Version <= version {
+			break
+		}
+		if err := m.apply(mig, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) known(version int) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// apply runs one migration step and records it in schema_migrations within a single transaction
+func (m *Migrator) apply(mig Migration, up bool) error {
+	tx, err := m.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, advisoryLockKey); err != nil {
+		return fmt.Errorf("failed to lock schema_migrations: %w", err)
+	}
+
+	// Another replica may have run this step while we waited for the lock
+	var applied bool
+	err = tx.Get(&applied, `SELECT true FROM schema_migrations WHERE version = $1`, mig.Version)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	if applied == up {
+		return nil
+	}
+
+	direction, script := "up", mig.Up
+	if !up {
+		direction, script = "down", mig.Down
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		return fmt.Errorf("migration %06d_%s %s failed: %w", mig.Version, mig.Name, direction, err)
+	}
+
+	if up {
+		_, err = tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name)
+	} else {
+		_, err = tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %06d: %w", mig.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %06d: %w", mig.Version, err)
+	}
+
+	log.Printf("Migration %s: %06d_%s", direction, mig.Version, mig.Name)
+	return nil
+}
@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"tasker/migrations"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad_SortsPairsByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_add_projects.up.sql":   {Data: []byte("CREATE TABLE projects ();")},
+		"000002_add_projects.down.sql": {Data: []byte("DROP TABLE projects;")},
+		"000001_create_tasks.up.sql":   {Data: []byte("CREATE TABLE tasks ();")},
+		"000001_create_tasks.down.sql": {Data: []byte("DROP TABLE tasks;")},
+		"embed.go":                     {Data: []byte("package migrations")},
+	}
+
+	loaded, err := Load(fsys)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, len(loaded))
+	assert.Equal(t, 1, loaded[0].Version)
+	assert.Equal(t, "create_tasks", loaded[0].Name)
+	assert.Equal(t, "CREATE TABLE tasks ();", loaded[0].Up)
+	assert.Equal(t, "DROP TABLE tasks;", loaded[0].Down)
+	assert.Equal(t, 2, loaded[1].Version)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "Missing down file",
+			fsys: fstest.MapFS{
+				"000001_create_tasks.up.sql": {Data: []byte("CREATE TABLE tasks ();")},
+			},
+		},
+		{
+			name: "Invalid file name",
+			fsys: fstest.MapFS{
+				"create_tasks.sql": {Data: []byte("CREATE TABLE tasks ();")},
+			},
+		},
+		{
+			name: "Conflicting names for one version",
+			fsys: fstest.MapFS{
+				"000001_create_tasks.up.sql":   {Data: []byte("CREATE TABLE tasks ();")},
+				"000001_create_todos.down.sql": {Data: []byte("DROP TABLE todos;")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoad_EmbeddedMigrations(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	assert.NoError(t, err)
+
+	assert.NotEmpty(t, loaded)
+	for i, m := range loaded {
+		assert.Equal(t, i+1, m.Version, "migration versions must be contiguous")
+	}
+}
@@ -0,0 +1,13 @@
+package project
+
+import "time"
+
+type Project struct {
+	ID          int64     `json:"id" db:"id"`
+	UserID      int64     `json:"-" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	ColorHex    string    `json:"color_hex" db:"color_hex"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,102 @@
+// Package rank generates keys for manually ordered lists (fractional indexing).
+// Keys compare byte by byte, and a key between any two others can be found
+// without touching them. Keys never end in the lowest digit, so no two keys
+// stand for the same position.
+package rank
+
+import "strings"
+
+// digits are the key digits in byte order
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// MaxLength bounds key length. Splitting the same gap over and over makes keys
+// grow; once one would pass this, the list needs spreading out again.
+const MaxLength = 24
+
+// Between returns a key that sorts after a and before b. An empty a stands for
+// the start of the list and an empty b for its end. It returns false when there
+// is no room left between the two, or when a doesn't sort before b.
+func Between(a, b string) (string, bool) {
+	if b != "" && a >= b {
+		return "", false
+	}
+
+	key, ok := midpoint(a, b, b == "")
+	if !ok || len(key) > MaxLength {
+		return "", false
+	}
+	return key, true
+}
+
+// midpoint finds a key between a and b, or between a and the end when open
+func midpoint(a, b string, open bool) (string, bool) {
+	if !open {
+		// Keep the digits both keys share; a shorter a counts as padded with zeros
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n == len(b) {
+			return "", false
+		}
+		if n > 0 {
+			rest, ok := midpoint(tail(a, n), b[n:], false)
+			return b[:n] + rest, ok
+		}
+	}
+
+	lo, hi := 0, base
+	if a != "" {
+		lo = strings.IndexByte(digits, a[0])
+	}
+	if !open {
+		hi = strings.IndexByte(digits, b[0])
+	}
+
+	if hi-lo > 1 {
+		return string(digits[(lo+hi+1)/2]), true
+	}
+	// The first digits are neighbours: cut b short, or extend a
+	if !open && len(b) > 1 {
+		return b[:1], true
+	}
+	rest, ok := midpoint(tail(a, 1), "", true)
+	return string(digits[lo]) + rest, ok
+}
+
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return digits[0]
+}
+
+func tail(key string, i int) string {
+	if i < len(key) {
+		return key[i:]
+	}
+	return ""
+}
+
+// Spread returns n keys in order, evenly spaced with room between each pair
+func Spread(n int) []string {
+	width, space := 1, base
+	for space < 2*(n+1) {
+		width++
+		space *= base
+	}
+
+	keys := make([]string, n)
+	for i := range keys {
+		v := (i + 1) * space / (n + 1)
+		key := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			key[j] = digits[v%base]
+			v /= base
+		}
+		keys[i] = strings.TrimRight(string(key), digits[:1])
+	}
+	return keys
+}
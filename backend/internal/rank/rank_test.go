@@ -0,0 +1,80 @@
+package rank
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"", ""},
+		{"", "V"},
+		{"V", ""},
+		{"V", "W"},
+		{"V", "V1"},
+		{"0001", "0002"},
+		{"", "0001"},
+		{"zzz", ""},
+		{"A", "z"},
+		{"0000000001", "000000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			key, ok := Between(tt.a, tt.b)
+			assert.True(t, ok)
+			assert.Greater(t, key, tt.a)
+			if tt.b != "" {
+				assert.Less(t, key, tt.b)
+			}
+			assert.NotEqual(t, digits[0], key[len(key)-1], "key %q ends in the lowest digit", key)
+		})
+	}
+}
+
+func TestBetween_NoRoom(t *testing.T) {
+	_, ok := Between("W", "V")
+	assert.False(t, ok)
+	_, ok = Between("V", "V")
+	assert.False(t, ok)
+}
+
+func TestBetween_RepeatedSplitsHitMaxLength(t *testing.T) {
+	// Always inserting at the top halves the same gap each time
+	first := "V"
+	for range 200 {
+		key, ok := Between("", first)
+		if !ok {
+			return
+		}
+		assert.Less(t, key, first)
+		first = key
+	}
+	t.Fatal("keys never reached MaxLength")
+}
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 30, 61, 62, 1000, 100000} {
+		keys := Spread(n)
+		assert.Len(t, keys, n)
+		assert.True(t, slices.IsSorted(keys), "keys for %d are out of order", n)
+		assert.Len(t, slices.Compact(slices.Clone(keys)), n)
+
+		// Every gap, including both ends, has room for another key
+		for i := 0; i <= n; i++ {
+			var lower, upper string
+			if i > 0 {
+				lower = keys[i-1]
+			}
+			if i < n {
+				upper = keys[i]
+			}
+			_, ok := Between(lower, upper)
+			assert.True(t, ok, "no room between %q and %q", lower, upper)
+		}
+	}
+}
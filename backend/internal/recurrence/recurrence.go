@@ -0,0 +1,234 @@
+// Package recurrence schedules recurring tasks. Rules are written in a subset
+// of the RFC 5545 RRULE syntax: FREQ=DAILY, WEEKLY or MONTHLY with INTERVAL,
+// BYDAY for weekly rules and BYMONTHDAY for monthly ones.
+package recurrence
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+// MaxInterval bounds INTERVAL; a rule that rarely recurs is better off as a
+// task with a due date
+const MaxInterval = 366
+
+// searchLimit bounds how many periods Next looks through. Rules like the
+// 31st of every 12th month starting in February never match at all.
+const searchLimit = 1000
+
+// weekdays are the BYDAY codes, indexed by time.Weekday
+var weekdays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Rule is a parsed recurrence rule. Occurrences are counted from an anchor,
+// the first occurrence, and keep its time of day.
+type Rule struct {
+	Freq     string
+	Interval int
+	// ByDay lists the weekdays a weekly rule falls on, Monday first. Empty
+	// means the anchor's weekday.
+	ByDay []time.Weekday
+	// ByMonthDay is the day of the month a monthly rule falls on, negative
+	// counting from the end of the month (-1 is the last day). Zero means the
+	// anchor's day. Months without that day are skipped.
+	ByMonthDay int
+}
+
+// Parse reads a rule such as FREQ=WEEKLY;BYDAY=MO,TH. A leading RRULE: is
+// allowed.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("rule is empty")
+	}
+
+	r := &Rule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%q is not of the form NAME=VALUE", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s is given twice", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			if value != Daily && value != Weekly && value != Monthly {
+				return nil, fmt.Errorf("FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+			r.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > MaxInterval {
+				return nil, fmt.Errorf("INTERVAL must be a whole number from 1 to %d", MaxInterval)
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day := slices.Index(weekdays, code)
+				if day < 0 {
+					return nil, fmt.Errorf("BYDAY takes weekdays from MO to SU, not %q", code)
+				}
+				if slices.Contains(r.ByDay, time.Weekday(day)) {
+					return nil, fmt.Errorf("BYDAY lists %s twice", code)
+				}
+				r.ByDay = append(r.ByDay, time.Weekday(day))
+			}
+			slices.SortFunc(r.ByDay, func(a, b time.Weekday) int { return mondayFirst(a) - mondayFirst(b) })
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(value)
+			if err != nil || n == 0 || n < -31 || n > 31 {
+				return nil, fmt.Errorf("BYMONTHDAY must be a day from 1 to 31, or -1 to -31 from the end of the month")
+			}
+			r.ByMonthDay = n
+		default:
+			return nil, fmt.Errorf("%s is not supported", name)
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return nil, fmt.Errorf("FREQ is required")
+	case len(r.ByDay) > 0 && r.Freq != Weekly:
+		return nil, fmt.Errorf("BYDAY only goes with FREQ=WEEKLY")
+	case r.ByMonthDay != 0 && r.Freq != Monthly:
+		return nil, fmt.Errorf("BYMONTHDAY only goes with FREQ=MONTHLY")
+	}
+	return r, nil
+}
+
+// String writes the rule back out the way Parse reads it, without the RRULE: prefix
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = weekdays[day]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.ByMonthDay != 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of the series starting at anchor that
+// comes strictly after after. It returns false when there is none. Days and
+// the time of day are worked out in anchor's location, so an anchor in the
+// user's time zone keeps occurrences on the user's weekdays across DST.
+func (r Rule) Next(anchor, after time.Time) (time.Time, bool) {
+	interval := max(r.Interval, 1)
+	after = after.In(anchor.Location())
+
+	// Start a period before the one after falls in, rather than walking
+	// from the anchor
+	var period int
+	switch r.Freq {
+	case Daily:
+		period = daysBetween(anchor, after)
+	case Weekly:
+		period = daysBetween(weekStart(anchor), after) / 7
+	case Monthly:
+		period = (after.Year()-anchor.Year())*12 + int(after.Month()-anchor.Month())
+	default:
+		return time.Time{}, false
+	}
+	period = max(period/interval-1, 0) * interval
+
+	for i := 0; i < searchLimit; i, period = i+1, period+interval {
+		for _, at := range r.occurrences(anchor, period) {
+			if !at.Before(anchor) && at.After(after) {
+				return at, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// After returns when the next occurrence is due for a series counted from
+// completion, given when the last one was done, in completed's location. The
+// time of day is kept from due when the last occurrence had a due date.
+func (r Rule) After(completed time.Time, due *time.Time) (time.Time, bool) {
+	anchor := completed
+	if due != nil {
+		clock := due.In(completed.Location())
+		anchor = time.Date(completed.Year(), completed.Month(), completed.Day(),
+			clock.Hour(), clock.Minute(), clock.Second(), 0, completed.Location())
+	}
+	return r.Next(anchor, anchor)
+}
+
+// occurrences lists the times the rule falls on in the period-th day, week or
+// month after the anchor's, in order
+func (r Rule) occurrences(anchor time.Time, period int) []time.Time {
+	y, m, d := anchor.Date()
+	on := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, anchor.Hour(), anchor.Minute(), anchor.Second(), anchor.Nanosecond(), anchor.Location())
+	}
+
+	switch r.Freq {
+	case Daily:
+		return []time.Time{on(y, m, d+period)}
+	case Weekly:
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{anchor.Weekday()}
+		}
+		start := weekStart(anchor)
+		times := make([]time.Time, len(days))
+		for i, day := range days {
+			times[i] = on(start.Year(), start.Month(), start.Day()+7*period+mondayFirst(day))
+		}
+		return times
+	case Monthly:
+		first := time.Date(y, m+time.Month(period), 1, 0, 0, 0, 0, anchor.Location())
+		length := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, anchor.Location()).Day()
+		day := d
+		if r.ByMonthDay > 0 {
+			day = r.ByMonthDay
+		} else if r.ByMonthDay < 0 {
+			day = length + 1 + r.ByMonthDay
+		}
+		if day < 1 || day > length {
+			return nil
+		}
+		return []time.Time{on(first.Year(), first.Month(), day)}
+	}
+	return nil
+}
+
+// mondayFirst numbers weekdays from Monday, as RRULE's default week start does
+func mondayFirst(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// weekStart returns midnight of the Monday of t's week
+func weekStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()-mondayFirst(t.Weekday()), 0, 0, 0, 0, t.Location())
+}
+
+// daysBetween counts calendar days from a's date to b's, negative when b is earlier
+func daysBetween(a, b time.Time) int {
+	b = b.In(a.Location())
+	from := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -0,0 +1,135 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:FREQ=DAILY;INTERVAL=3", "FREQ=DAILY;INTERVAL=3"},
+		{"freq=weekly;byday=th,mo", "FREQ=WEEKLY;BYDAY=MO,TH"},
+		{"FREQ=WEEKLY;BYDAY=SU,MO;INTERVAL=2", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{"FREQ=MONTHLY;INTERVAL=1", "FREQ=MONTHLY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			r, err := Parse(tt.in)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.out, r.String())
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=x",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYDAY=MO,MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;COUNT=3",
+		"FREQ",
+	} {
+		t.Run(in, func(t *testing.T) {
+			_, err := Parse(in)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   string
+		anchor string
+		after  string
+		want   string
+	}{
+		{"daily", "FREQ=DAILY", "2026-03-02 09:00", "2026-03-02 09:00", "2026-03-03 09:00"},
+		{"daily before anchor", "FREQ=DAILY", "2026-03-02 09:00", "2026-01-01 00:00", "2026-03-02 09:00"},
+		{"every 3 days", "FREQ=DAILY;INTERVAL=3", "2026-03-02 09:00", "2026-03-10 12:00", "2026-03-11 09:00"},
+		{"far after anchor", "FREQ=DAILY;INTERVAL=2", "2020-01-01 08:00", "2026-03-02 08:00", "2026-03-04 08:00"},
+		{"weekly on anchor day", "FREQ=WEEKLY", "2026-03-04 10:00", "2026-03-04 10:00", "2026-03-11 10:00"},
+		{"weekdays", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", "2026-03-06 09:00", "2026-03-06 09:00", "2026-03-09 09:00"},
+		{"same week", "FREQ=WEEKLY;BYDAY=MO,TH", "2026-03-02 09:00", "2026-03-02 09:00", "2026-03-05 09:00"},
+		{"not before anchor", "FREQ=WEEKLY;BYDAY=MO,TH", "2026-03-04 09:00", "2026-03-01 00:00", "2026-03-05 09:00"},
+		{"every other week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "2026-03-02 09:00", "2026-03-02 09:00", "2026-03-16 09:00"},
+		{"sunday ends the week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU", "2026-03-02 09:00", "2026-03-02 09:00", "2026-03-08 09:00"},
+		{"monthly on anchor day", "FREQ=MONTHLY", "2026-01-15 00:00", "2026-01-15 00:00", "2026-02-15 00:00"},
+		{"rent on the first", "FREQ=MONTHLY;BYMONTHDAY=1", "2026-01-01 00:00", "2026-03-20 00:00", "2026-04-01 00:00"},
+		{"skips short months", "FREQ=MONTHLY", "2026-01-31 09:00", "2026-01-31 09:00", "2026-03-31 09:00"},
+		{"last day of month", "FREQ=MONTHLY;BYMONTHDAY=-1", "2026-01-31 09:00", "2026-01-31 09:00", "2026-02-28 09:00"},
+		{"quarterly", "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=10", "2026-01-10 09:00", "2026-05-01 00:00", "2026-07-10 09:00"},
+		{"across years", "FREQ=MONTHLY", "2025-11-05 09:00", "2025-12-05 09:00", "2026-01-05 09:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.rule)
+			assert.NoError(t, err)
+			next, ok := r.Next(date(tt.anchor), date(tt.after))
+			assert.True(t, ok)
+			assert.Equal(t, date(tt.want), next)
+		})
+	}
+}
+
+func TestNext_NeverMatches(t *testing.T) {
+	r, err := Parse("FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=30")
+	assert.NoError(t, err)
+
+	_, ok := r.Next(date("2026-02-01 09:00"), date("2026-02-01 09:00"))
+	assert.False(t, ok)
+}
+
+func TestNext_InTimeZone(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	assert.NoError(t, err)
+	r, err := Parse("FREQ=WEEKLY;BYDAY=MO")
+	assert.NoError(t, err)
+
+	// Monday evening in Los Angeles is already Tuesday in UTC, and DST starts
+	// on March 8th
+	monday := time.Date(2026, 3, 2, 20, 0, 0, 0, la)
+	next, ok := r.Next(monday, monday.UTC())
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 3, 9, 20, 0, 0, 0, la), next)
+	assert.Equal(t, time.Monday, next.Weekday())
+	assert.Equal(t, time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestAfter_KeepsTimeOfDayOfDueDate(t *testing.T) {
+	r, err := Parse("FREQ=DAILY;INTERVAL=3")
+	assert.NoError(t, err)
+
+	due := date("2026-03-01 09:00")
+	next, ok := r.After(date("2026-03-04 17:30"), &due)
+	assert.True(t, ok)
+	assert.Equal(t, date("2026-03-07 09:00"), next)
+
+	next, ok = r.After(date("2026-03-04 17:30"), nil)
+	assert.True(t, ok)
+	assert.Equal(t, date("2026-03-07 17:30"), next)
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tasker/internal/apitoken"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const apiTokenColumns = `id, user_id, name, token_prefix, scopes, created_at, last_used_at, expires_at`
+
+// APITokenRepositoryInterface defines the contract for personal API tokens
+type APITokenRepositoryInterface interface {
+	// CreateToken stores a token under its hash; the plaintext is never persisted
+	CreateToken(userID int64, t apitoken.APIToken, hash string) (*apitoken.APIToken, error)
+	// ListTokens returns the user's tokens that have not been revoked
+	ListTokens(userID int64) ([]apitoken.APIToken, error)
+	// GetActiveTokenByHash finds an unrevoked, unexpired token by the hash of its secret
+	GetActiveTokenByHash(hash string) (*apitoken.APIToken, error)
+	RevokeToken(userID int64, id int64) error
+	MarkTokenUsed(id int64) error
+}
+
+type APITokenRepository struct {
+	db *sqlx.DB
+}
+
+var APITokens APITokenRepositoryInterface
+
+func NewAPITokenRepository(db *sqlx.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+func (r *APITokenRepository) CreateToken(userID int64, t apitoken.APIToken, hash string) (*apitoken.APIToken, error) {
+	query := `
+		INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + apiTokenColumns
+
+	var created apitoken.APIToken
+	err := r.db.QueryRowx(query, userID, t.Name, hash, t.Prefix, t.Scopes, time.Now(), t.ExpiresAt).StructScan(&created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api token: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *APITokenRepository) ListTokens(userID int64) ([]apitoken.APIToken, error) {
+	tokens := []apitoken.APIToken{}
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at DESC`
+
+	if err := r.db.Select(&tokens, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get api tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (r *APITokenRepository) GetActiveTokenByHash(hash string) (*apitoken.APIToken, error) {
+	var t apitoken.APIToken
+	query := `
+		SELECT ` + apiTokenColumns + ` FROM api_tokens
+		WHERE token_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+	`
+
+	if err := r.db.Get(&t, query, hash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("api token not found")
+		}
+		return nil, fmt.Errorf("failed to get api token: %w", err)
+	}
+
+	return &t, nil
+}
+
+func (r *APITokenRepository) RevokeToken(userID int64, id int64) error {
+	query := `UPDATE api_tokens SET revoked_at = NOW() WHERE user_id = $1 AND id = $2 AND revoked_at IS NULL`
+	result, err := r.db.Exec(query, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("api token not found: %d", id)
+	}
+
+	return nil
+}
+
+func (r *APITokenRepository) MarkTokenUsed(id int64) error {
+	if _, err := r.db.Exec(`UPDATE api_tokens SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to update api token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tasker/internal/idempotency"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const idempotencyColumns = `user_id, idempotency_key, fingerprint, status_code, headers, body, created_at`
+
+// IdempotencyKeyRepositoryInterface defines the contract for stored responses
+// to requests that carried an Idempotency-Key. Keys are scoped to userID.
+type IdempotencyKeyRepositoryInterface interface {
+	// ReserveKey claims key for a request with fingerprint and returns nil. When
+	// the key was already used within ttl it returns that use instead, and
+	// claims nothing. Uses older than ttl are forgotten, and so are reservations
+	// without a response that are older than lockTimeout.
+	ReserveKey(userID int64, key string, fingerprint string, ttl time.Duration, lockTimeout time.Duration) (*idempotency.Record, error)
+	// CompleteKey stores the response to the request that reserved key
+	CompleteKey(userID int64, key string, statusCode int, headers idempotency.Headers, body []byte) error
+	// ReleaseKey gives up a reservation that has no response, so the request
+	// can be retried with the same key
+	ReleaseKey(userID int64, key string) error
+	// PurgeKeys forgets every user's keys used longer ago than ttl, and
+	// returns how many went
+	PurgeKeys(ttl time.Duration) (int64, error)
+}
+
+type IdempotencyKeyRepository struct {
+	db *sqlx.DB
+}
+
+var IdempotencyKeys IdempotencyKeyRepositoryInterface
+
+func NewIdempotencyKeyRepository(db *sqlx.DB) *IdempotencyKeyRepository {
+	return &IdempotencyKeyRepository{db: db}
+}
+
+func (r *IdempotencyKeyRepository) ReserveKey(userID int64, key string, fingerprint string, ttl time.Duration, lockTimeout time.Duration) (*idempotency.Record, error) {
+	// The primary key lets only one of several concurrent retries claim the key
+	query := `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, fingerprint, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, headers = NULL, body = NULL, created_at = EXCLUDED.created_at
+		WHERE idempotency_keys.created_at < NOW() - make_interval(secs => $4)
+			OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < NOW() - make_interval(secs => $5))
+		RETURNING true`
+
+	var reserved bool
+	err := r.db.Get(&reserved, query, userID, key, fingerprint, ttl.Seconds(), lockTimeout.Seconds())
+	if err == nil {
+		return nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	var existing idempotency.Record
+	query = `SELECT ` + idempotencyColumns + ` FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`
+	if err := r.db.Get(&existing, query, userID, key); err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &existing, nil
+}
+
+func (r *IdempotencyKeyRepository) CompleteKey(userID int64, key string, statusCode int, headers idempotency.Headers, body []byte) error {
+	query := `
+		UPDATE idempotency_keys SET status_code = $1, headers = $2, body = $3
+		WHERE user_id = $4 AND idempotency_key = $5`
+	if _, err := r.db.Exec(query, statusCode, headers, body, userID, key); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyKeyRepository) ReleaseKey(userID int64, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2 AND status_code IS NULL`
+	if _, err := r.db.Exec(query, userID, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyKeyRepository) PurgeKeys(ttl time.Duration) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE created_at < NOW() - make_interval(secs => $1)`, ttl.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+	"tasker/internal/project"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ProjectDeleteMode controls what happens to a project's tasks when the project is deleted
+type ProjectDeleteMode string
+
+const (
+	// ProjectDeleteCascade moves the project's tasks to the trash along with it
+	ProjectDeleteCascade ProjectDeleteMode = "cascade"
+	// ProjectDeleteOrphan keeps the tasks but detaches them from any project
+	ProjectDeleteOrphan ProjectDeleteMode = "orphan"
+	// ProjectDeleteMove reassigns the tasks to another project
+	ProjectDeleteMove ProjectDeleteMode = "move"
+)
+
+const projectColumns = `id, user_id, name, description, color_hex, created_at, updated_at`
+
+// ProjectRepositoryInterface defines the contract for project data operations.
+// Every method is scoped to the projects owned by userID.
+type ProjectRepositoryInterface interface {
+	GetAllProjects(userID int64) ([]project.Project, error)
+	GetProjectByID(userID int64, id int64) (*project.Project, error)
+	CreateProject(userID int64, p project.Project) (*project.Project, error)
+	UpdateProject(userID int64, id int64, p project.Project) (*project.Project, error)
+	// DeleteProject removes a project and handles its tasks according to mode.
+	// moveTo is only used (and required) for ProjectDeleteMove.
+	DeleteProject(userID int64, id int64, mode ProjectDeleteMode, moveTo *int64) error
+}
+
+type ProjectRepository struct {
+	db *sqlx.DB
+}
+
+var Projects ProjectRepositoryInterface
+
+func NewProjectRepository(db *sqlx.DB) *ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+func (r *ProjectRepository) GetAllProjects(userID int64) ([]project.Project, error) {
+	projects := []project.Project{}
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE user_id = $1 ORDER BY name`
+
+	if err := r.db.Select(&projects, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+
+	return projects, nil
+}
+
+func (r *ProjectRepository) GetProjectByID(userID int64, id int64) (*project.Project, error) {
+	var p project.Project
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE user_id = $1 AND id = $2`
+
+	if err := r.db.Get(&p, query, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("project not found: %d", id)
+		}
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	return &p, nil
+}
+
+func (r *ProjectRepository) CreateProject(userID int64, p project.Project) (*project.Project, error) {
+	now := time.Now()
+
+	query := `
+		INSERT INTO projects (user_id, name, description, color_hex, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + projectColumns
+
+	var created project.Project
+	err := r.db.QueryRowx(query, userID, p.Name, p.Description, p.ColorHex, now, now).StructScan(&created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *ProjectRepository) UpdateProject(userID int64, id int64, p project.Project) (*project.Project, error) {
+	query := `
+		UPDATE projects
+		SET name = COALESCE(NULLIF($1, ''), name),
+		    description = COALESCE(NULLIF($2, ''), description),
+		    color_hex = COALESCE(NULLIF($3, ''), color_hex),
+		    updated_at = $4
+		WHERE user_id = $5 AND id = $6
+		RETURNING ` + projectColumns
+
+	var updated project.Project
+	err := r.db.QueryRowx(query, p.Name, p.Description, p.ColorHex, time.Now(), userID, id).StructScan(&updated)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("project not found: %d", id)
+		}
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return &updated, nil
+}
+
+func (r *ProjectRepository) DeleteProject(userID int64, id int64, mode ProjectDeleteMode, moveTo *int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the project row so no task can be added to it while we reassign
+	var exists bool
+	if err := tx.Get(&exists, `SELECT true FROM projects WHERE user_id = $1 AND id = $2 FOR UPDATE`, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("project not found: %d", id)
+		}
+		return fmt.Errorf("failed to get project: %w", err)
+	}
+
+	switch mode {
+	case ProjectDeleteCascade:
+		// Keep what each task looked like for its history
+		var deleted []task.Task
+		query := `SELECT ` + taskColumns + ` FROM tasks WHERE project_id = $1 AND deleted_at IS NULL FOR UPDATE`
+		if err := tx.Select(&deleted, query, id); err != nil {
+			return fmt.Errorf("failed to get project tasks: %w", err)
+		}
+		query = `UPDATE tasks SET deleted_at = NOW() WHERE project_id = $1 AND deleted_at IS NULL RETURNING id`
+		var trashed []string
+		if err := tx.Select(&trashed, query, id); err != nil {
+			return fmt.Errorf("failed to delete project tasks: %w", err)
+		}
+		// Subtasks outside the project are promoted rather than left under a
+		// parent in the trash
+		query = `UPDATE tasks SET parent_id = NULL WHERE user_id = $1 AND parent_id = ANY($2) AND deleted_at IS NULL`
+		if _, err := tx.Exec(query, userID, pq.Array(trashed)); err != nil {
+			return fmt.Errorf("failed to promote subtasks: %w", err)
+		}
+		for _, t := range deleted {
+			if err := recordDiff(tx, userID, history.ActionDelete, &t, nil); err != nil {
+				return err
+			}
+		}
+	case ProjectDeleteOrphan:
+		if err := reassignProjectTasks(tx, userID, id, nil); err != nil {
+			return fmt.Errorf("failed to detach project tasks: %w", err)
+		}
+	case ProjectDeleteMove:
+		if moveTo == nil || *moveTo == id {
+			return fmt.Errorf("invalid move target for project %d", id)
+		}
+		if err := tx.Get(&exists, `SELECT true FROM projects WHERE user_id = $1 AND id = $2`, userID, *moveTo); err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("project not found: %d", *moveTo)
+			}
+			return fmt.Errorf("failed to get project: %w", err)
+		}
+		if err := reassignProjectTasks(tx, userID, id, moveTo); err != nil {
+			return fmt.Errorf("failed to move project tasks: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid delete mode: %s", mode)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM projects WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// reassignProjectTasks moves the tasks of project id to project to, or out of
+// any project when to is nil, and records the change in their history. Tasks in
+// the trash lose the project through ON DELETE SET NULL.
+func reassignProjectTasks(tx *sqlx.Tx, userID int64, id int64, to *int64) error {
+	var moved []struct {
+		ID      string `db:"id"`
+		Version int64  `db:"version"`
+	}
+	query := `UPDATE tasks SET project_id = $1, updated_at = NOW(), version = version + 1 WHERE project_id = $2 AND deleted_at IS NULL RETURNING id, version`
+	if err := tx.Select(&moved, query, to, id); err != nil {
+		return err
+	}
+
+	before, _ := json.Marshal(id)
+	after, _ := json.Marshal(to)
+	changes := history.Changes{"project_id": {Before: before, After: after}}
+	for _, t := range moved {
+		if err := recordEvent(tx, userID, t.ID, history.ActionUpdate, changes, t.Version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,21 +2,158 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	task "tasker/internal/Task"
+	"tasker/internal/history"
+	"tasker/internal/recurrence"
+	"tasker/internal/workflow"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
-// TaskRepositoryInterface defines the contract for task data operations
+// taskColumns lists the columns selected for every task query. Tasks in the
+// trash don't count towards another task's dependencies or progress.
+const taskColumns = `id, user_id, title, description, status, status_category, rank, priority, project_id, start_at, due_at, estimate_hours, parent_id, version, created_at, updated_at, deleted_at, archived_at,
+	COALESCE((SELECT array_agg(tg.name ORDER BY LOWER(tg.name)) FROM task_tags tt JOIN tags tg ON tg.id = tt.tag_id
+		WHERE tt.user_id = tasks.user_id AND tt.task_id = tasks.id), '{}') AS tags,
+	COALESCE((SELECT array_agg(d.blocker_id ORDER BY d.blocker_id) FROM task_dependencies d
+		JOIN tasks b ON b.user_id = d.user_id AND b.id = d.blocker_id AND b.deleted_at IS NULL
+		WHERE d.user_id = tasks.user_id AND d.blocked_id = tasks.id), '{}') AS blocked_by,
+	COALESCE((SELECT array_agg(d.blocked_id ORDER BY d.blocked_id) FROM task_dependencies d
+		JOIN tasks b ON b.user_id = d.user_id AND b.id = d.blocked_id AND b.deleted_at IS NULL
+		WHERE d.user_id = tasks.user_id AND d.blocker_id = tasks.id), '{}') AS blocks,
+	(SELECT json_build_object('done', COUNT(*) FILTER (WHERE c.status_category = 'done'), 'total', COUNT(*))
+		FROM tasks c WHERE c.user_id = tasks.user_id AND c.parent_id = tasks.id AND c.deleted_at IS NULL HAVING COUNT(*) > 0) AS progress,
+	(SELECT json_build_object('series_id', s.id, 'rule', s.rule, 'after_completion', s.after_completion,
+		'occurs_at', tasks.occurs_at AT TIME ZONE 'UTC', 'next_at', s.next_at AT TIME ZONE 'UTC', 'timezone', s.timezone)
+		FROM task_series s WHERE s.id = tasks.series_id) AS recurrence`
+
+// taskTreeWalkLimit bounds the recursive subtask queries. Nesting is capped far
+// below this, so hitting it means the tree is broken rather than deep.
+const taskTreeWalkLimit = 100
+
+// MaxTaskDepth caps how deep subtasks nest: a top-level task counts as level 1
+const MaxTaskDepth = 5
+
+// TaskDeleteMode controls what happens to a task's subtasks when the task is deleted
+type TaskDeleteMode string
+
+const (
+	// TaskDeleteReject refuses to delete a task that still has subtasks
+	TaskDeleteReject TaskDeleteMode = "reject"
+	// TaskDeleteCascade deletes the task together with all of its subtasks
+	TaskDeleteCascade TaskDeleteMode = "cascade"
+)
+
+// taskKeyMatch matches a task of the user in userParam by its current key, or by an
+// old key left behind by a re-key, in keyParam. Keys are only unique per user.
+// Sequences never hand out a number twice, so an alias can't shadow a live key.
+func taskKeyMatch(userParam, keyParam string) string {
+	return `id = COALESCE((SELECT task_id FROM task_key_aliases WHERE user_id = ` + userParam + ` AND alias = ` + keyParam + `), ` + keyParam + `)`
+}
+
+// doneAtUpdate sets done_at, when the task last went into a done column, for an
+// UPDATE that leaves it in category at time at. Writes that keep a task done
+// keep its done_at.
+func doneAtUpdate(category, at string) string {
+	return `done_at = CASE WHEN ` + category + ` <> 'done' THEN NULL WHEN status_category = 'done' THEN done_at ELSE ` + at + ` END`
+}
+
+// TaskRepositoryInterface defines the contract for task data operations.
+// Every method is scoped to the tasks owned by userID.
 type TaskRepositoryInterface interface {
-	GetAllTasks() ([]task.Task, error)
-	GetTaskByID(id string) (*task.Task, error)
-	CreateTask(t task.Task) (*task.Task, error)
-	UpdateTask(id string, t task.Task) (*task.Task, error)
-	DeleteTask(id string) error
+	GetAllTasks(userID int64) ([]task.Task, error)
+	// ListTasks returns one page of matching tasks and the cursor for the next
+	// page, which is empty on the last page
+	ListTasks(userID int64, opts ListOptions) ([]task.Task, string, error)
+	GetTaskByID(userID int64, id string) (*task.Task, error)
+	// CreateTask assigns the task the next key under prefix and inserts it along with its tags.
+	// A parent that would nest it past MaxTaskDepth is refused, and so is a full
+	// column when limit is given.
+	CreateTask(userID int64, t task.Task, prefix string, limit *WIPLimit) (*task.Task, error)
+	// UpdateTask overwrites the task's editable fields and tags with t's. When t.Version is
+	// non-zero the write only happens if the stored version still matches. A
+	// recurring task that becomes done gets its next occurrence. A new parent
+	// must not be the task itself or one of its subtasks, nor nest it past
+	// MaxTaskDepth. A limit is checked as in CreateTask.
+	UpdateTask(userID int64, id string, t task.Task, limit *WIPLimit) (*task.Task, error)
+	// DeleteTask moves a task to the trash, and its subtasks with TaskDeleteCascade.
+	// A non-zero version must match the stored one.
+	DeleteTask(userID int64, id string, version int64, mode TaskDeleteMode) error
+	// RekeyTask gives a task a new key under prefix and keeps the old key as an
+	// alias. A non-zero version must match the stored one.
+	RekeyTask(userID int64, id string, prefix string, version int64) (*task.Task, error)
+	// AddDependency records that blockerID blocks the task and returns the blocked task.
+	// Edges that would close a cycle are refused; adding an existing edge is a no-op.
+	AddDependency(userID int64, id string, blockerID string) (*task.Task, error)
+	RemoveDependency(userID int64, id string, blockerID string) error
+	// GetOpenBlockers returns the task's blockers that are not done yet
+	GetOpenBlockers(userID int64, id string) ([]task.Task, error)
+	// MoveTask sets the task's status and its place in that column in one
+	// write, continuing its series like UpdateTask when it becomes done.
+	// move.Limit is checked as in CreateTask.
+	MoveTask(userID int64, id string, move TaskMove) (*task.Task, error)
+	// GetTaskHistory returns the task's events, newest first. Deleted tasks keep
+	// their history.
+	GetTaskHistory(userID int64, id string) ([]history.Event, error)
+	// RestoreTask writes t's fields and rank over the task with key t.ID,
+	// recording the write under action. A task in the trash is taken out of it.
+	// A non-zero t.Version must match the stored one. The parent and limit are
+	// checked as in UpdateTask.
+	RestoreTask(userID int64, t task.Task, action string, limit *WIPLimit) (*task.Task, error)
+	// GetUndoableMutation returns the events of the caller's latest mutation
+	// within window that undo may reverse and hasn't yet
+	GetUndoableMutation(userID int64, window time.Duration) ([]history.Event, error)
+	// UndoMutation marks a mutation's events undone and restores tasks in one
+	// transaction, recording an undo event for each
+	UndoMutation(userID int64, mutationID int64, tasks []task.Task) ([]task.Task, error)
+	// GetTrash returns the tasks in the trash, most recently deleted first
+	GetTrash(userID int64) ([]task.Task, error)
+	// GetTrashedSubtree returns a task in the trash followed by the subtasks
+	// that went to the trash along with it
+	GetTrashedSubtree(userID int64, id string) ([]task.Task, error)
+	// RestoreFromTrash takes tasks out of the trash with their fields as given,
+	// all or none of them
+	RestoreFromTrash(userID int64, tasks []task.Task) ([]task.Task, error)
+	// PurgeTask removes a task in the trash for good, together with its
+	// subtasks in the trash and the history of each
+	PurgeTask(userID int64, id string) error
+	// PurgeTrash removes every user's tasks that have been in the trash for
+	// longer than retention, and returns how many went
+	PurgeTrash(retention time.Duration) (int64, error)
+	// ArchiveTask takes a done task off the board, or with archived false puts
+	// it back at the top of its column. A non-zero version must match the
+	// stored one; a task already that way is returned unchanged. Putting it back
+	// checks limit as in CreateTask.
+	ArchiveTask(userID int64, id string, version int64, archived bool, limit *WIPLimit) (*task.Task, error)
+	// ArchiveDoneTasks archives every user's tasks that have been in a done
+	// column for longer than age, and returns how many it archived
+	ArchiveDoneTasks(age time.Duration) (int64, error)
+	// SetRecurrence makes the task recur by rule. A task outside a series
+	// starts one, scheduled from its due date unless afterCompletion; an
+	// occurrence changes its series' rule from its own date on. A nil rule
+	// ends the task's series and leaves its occurrences as plain tasks. The
+	// rule's days and time of day are kept in loc. A non-zero version must
+	// match the task's current one. Every occurrence that changes gets an event.
+	SetRecurrence(userID int64, id string, version int64, rule *recurrence.Rule, afterCompletion bool, loc *time.Location) (*task.Task, error)
+	// SkipOccurrence moves an open occurrence to the trash without ending its
+	// series, and returns the occurrence after it, nil when there is none
+	SkipOccurrence(userID int64, id string) (*task.Task, error)
+	// CreateDueOccurrences creates the next occurrence of every user's series
+	// that has come due, and returns how many it created
+	CreateDueOccurrences() (int64, error)
+	// ApplyTaskChanges makes the changes in order in one transaction and
+	// returns each changed task, nil for deletes. When a change fails none are
+	// kept and the error is a *TaskChangeError. With dryRun the changes are
+	// rolled back after the last one. Each of limits is checked once every
+	// change is made, and a column over it fails with a bare *WIPLimitError.
+	ApplyTaskChanges(userID int64, changes []TaskChange, dryRun bool, limits []WIPLimit) ([]*task.Task, error)
 }
 
 type TaskRepository struct {
@@ -29,11 +166,11 @@ func NewTaskRepository(db *sqlx.DB) *TaskRepository {
 	return &TaskRepository{db: db}
 }
 
-func (r *TaskRepository) GetAllTasks() ([]task.Task, error) {
+func (r *TaskRepository) GetAllTasks(userID int64) ([]task.Task, error) {
 	var tasks []task.Task
-	query := `SELECT id, title, description, status, priority, created_at, updated_at FROM tasks ORDER BY created_at DESC`
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC`
 
-	err := r.db.Select(&tasks, query)
+	err := r.db.Select(&tasks, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tasks: %w", err)
 	}
@@ -41,10 +178,15 @@ func (r *TaskRepository) GetAllTasks() ([]task.Task, error) {
 	return tasks, nil
 }
 
-func (r *TaskRepository) GetTaskByID(id string) (*task.Task, error) {
+func (r *TaskRepository) GetTaskByID(userID int64, id string) (*task.Task, error) {
+	return getUserTask(r.db, userID, id)
+}
+
+// getUserTask reads a live task of the user by its current or old key through q
+func getUserTask(q sqlx.Queryer, userID int64, id string) (*task.Task, error) {
 	var t task.Task
-	query := `SELECT id, title, description, status, priority, created_at, updated_at FROM tasks WHERE id = $1`
-	err := r.db.Get(&t, query, id)
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NULL`
+	err := sqlx.Get(q, &t, query, userID, id)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -56,90 +198,467 @@ func (r *TaskRepository) GetTaskByID(id string) (*task.Task, error) {
 	return &t, nil
 }
 
-func (r *TaskRepository) CreateTask(t task.Task) (*task.Task, error) {
+func (r *TaskRepository) CreateTask(userID int64, t task.Task, prefix string, limit *WIPLimit) (*task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if t.ParentID != nil {
+		if err := lockTaskGraph(tx, userID); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkWIPLimit(tx, userID, limit, "", 1); err != nil {
+		return nil, err
+	}
+
+	createdTask, err := insertTask(tx, userID, t, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return createdTask, nil
+}
+
+// insertTask creates t under the next key for prefix at the top of its column,
+// along with its tags and its create event. With t.Recurrence set the task is
+// an occurrence of that series.
+func insertTask(tx *sqlx.Tx, userID int64, t task.Task, prefix string) (*task.Task, error) {
 	now := time.Now()
+	t.UserID = userID
 	t.CreatedAt = now
 	t.UpdatedAt = now
 
+	// An occurrence sits under the parent of the one before it, at the same
+	// depth, so only other tasks need the check
+	if t.ParentID != nil && t.Recurrence == nil {
+		parentID, err := checkParent(tx, userID, "", *t.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		t.ParentID = &parentID
+	}
+
+	// Allocating inside the transaction means a failed insert doesn't burn a key
+	var err error
+	t.ID, err = nextTaskKey(tx, userID, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// New cards go to the top of their column
+	t.Rank, err = topOfColumn(tx, userID, t.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	var seriesID *int64
+	var occursAt *time.Time
+	if t.Recurrence != nil {
+		seriesID = &t.Recurrence.SeriesID
+		// occurs_at holds UTC like the series' columns
+		if t.Recurrence.OccursAt != nil {
+			at := t.Recurrence.OccursAt.UTC()
+			occursAt = &at
+		}
+	}
+
 	query := `
-		INSERT INTO tasks (id, title, description, status, priority, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, title, description, status, priority, created_at, updated_at
+		INSERT INTO tasks (id, user_id, title, description, status, status_category, rank, priority, project_id, start_at, due_at, estimate_hours, parent_id, series_id, occurs_at, created_at, updated_at, done_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 	`
-
-	var createdTask task.Task
-	err := r.db.QueryRow(
+	var doneAt *time.Time
+	if t.StatusCategory == workflow.CategoryDone {
+		doneAt = &now
+	}
+	_, err = tx.Exec(
 		query,
-		t.ID, t.Title, t.Description, t.Status, t.Priority, t.CreatedAt, t.UpdatedAt,
-	).Scan(
-		&createdTask.ID,
-		&createdTask.Title,
-		&createdTask.Description,
-		&createdTask.Status,
-		&createdTask.Priority,
-		&createdTask.CreatedAt,
-		&createdTask.UpdatedAt,
+		t.ID, t.UserID, t.Title, t.Description, t.Status, t.StatusCategory, t.Rank, t.Priority, t.ProjectID, t.StartAt, t.DueAt, t.EstimateHours, t.ParentID, seriesID, occursAt, t.CreatedAt, t.UpdatedAt, doneAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create task: %w", err)
 	}
 
-	return &createdTask, nil
+	if err := setTaskTags(tx, userID, t.ID, t.Tags); err != nil {
+		return nil, err
+	}
+
+	createdTask, err := getTask(tx, userID, t.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordDiff(tx, userID, history.ActionCreate, nil, createdTask); err != nil {
+		return nil, err
+	}
+
+	return createdTask, nil
 }
 
-func (r *TaskRepository) DeleteTask(id string) error {
-	query := `DELETE FROM tasks WHERE id = $1`
-	result, err := r.db.Exec(query, id)
+func (r *TaskRepository) DeleteTask(userID int64, id string, version int64, mode TaskDeleteMode) error {
+	tx, err := r.db.Beginx()
 	if err != nil {
-		return fmt.Errorf("failed to delete task: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	if err := deleteTask(tx, userID, id, version, mode); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// deleteTask moves a task to the trash inside tx, along with its subtasks
+// when mode is TaskDeleteCascade
+func deleteTask(tx *sqlx.Tx, userID int64, id string, version int64, mode TaskDeleteMode) error {
+	subtree, err := deletableSubtree(tx, userID, id, version, mode)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return err
+	}
+
+	if len(subtree) == 0 {
+		existing, err := getUserTask(tx, userID, id)
+		if err != nil {
+			return err
+		}
+		if version != 0 && existing.Version != version {
+			return fmt.Errorf("task version conflict: %s", id)
+		}
+		return fmt.Errorf("task has subtasks: %s", id)
+	}
+
+	return trashTasks(tx, userID, subtree)
+}
+
+// deletableSubtree returns the keys of the task and its subtasks that deleting
+// it with mode takes to the trash, or none when it can't be deleted.
+// Without cascade the task must have no subtasks; with it, the whole subtree
+// goes. Subtasks already in the trash are left as they are.
+func deletableSubtree(tx *sqlx.Tx, userID int64, id string, version int64, mode TaskDeleteMode) ([]string, error) {
+	query := `
+		WITH RECURSIVE target AS (
+			SELECT id FROM tasks
+			WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NULL AND ($3 = 0 OR version = $3)
+				AND ($4 OR NOT EXISTS (SELECT 1 FROM tasks c WHERE c.user_id = tasks.user_id AND c.parent_id = tasks.id AND c.deleted_at IS NULL))
+		), subtree(id, depth) AS (
+			SELECT id, 1 FROM target
+			UNION ALL
+			SELECT t.id, s.depth + 1 FROM tasks t JOIN subtree s ON t.parent_id = s.id
+			WHERE t.user_id = $1 AND t.deleted_at IS NULL AND s.depth < $5
+		)
+		SELECT id FROM subtree`
+	var subtree []string
+	if err := tx.Select(&subtree, query, userID, id, version, mode == TaskDeleteCascade, taskTreeWalkLimit); err != nil {
+		return nil, fmt.Errorf("failed to delete task: %w", err)
+	}
+	return subtree, nil
+}
+
+// trashTasks moves the tasks with keys ids to the trash, recording a delete
+// for each
+func trashTasks(tx *sqlx.Tx, userID int64, ids []string) error {
+	// Keep what each task looked like for its history
+	var deleted []task.Task
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND id = ANY($2) FOR UPDATE`
+	if err := tx.Select(&deleted, query, userID, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to get tasks: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("task not found: %s", id)
+	// NOW() is the same for the whole transaction, which is how a restore
+	// finds the subtasks that went to the trash along with the task
+	if _, err := tx.Exec(`UPDATE tasks SET deleted_at = NOW() WHERE user_id = $1 AND id = ANY($2)`, userID, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
 	}
 
+	for _, t := range deleted {
+		if err := recordDiff(tx, userID, history.ActionDelete, &t, nil); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (r *TaskRepository) UpdateTask(id string, t task.Task) (*task.Task, error) {
+func (r *TaskRepository) UpdateTask(userID int64, id string, t task.Task, limit *WIPLimit) (*task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	updatedTask, err := updateTask(tx, userID, id, t, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updatedTask, nil
+}
+
+// updateTask overwrites the task's editable fields and tags with t's inside tx,
+// as UpdateTask describes
+func updateTask(tx *sqlx.Tx, userID int64, id string, t task.Task, limit *WIPLimit) (*task.Task, error) {
 	t.UpdatedAt = time.Now()
 
-	query := `
+	// Whether the write completes or reopens the task, or moves it in the
+	// tree, is only known once it is read
+	if err := lockTaskGraph(tx, userID); err != nil {
+		return nil, err
+	}
+
+	var before task.Task
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.Get(&before, query, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("task not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if t.Version != 0 && t.Version != before.Version {
+		return nil, fmt.Errorf("task version conflict: %s", id)
+	}
+	if err := checkBlockers(tx, userID, &before, t.StatusCategory); err != nil {
+		return nil, err
+	}
+	if err := checkWIPLimit(tx, userID, limit, before.ID, 1); err != nil {
+		return nil, err
+	}
+
+	if t.ParentID != nil && (before.ParentID == nil || *before.ParentID != *t.ParentID) {
+		parentID, err := checkParent(tx, userID, before.ID, *t.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		t.ParentID = &parentID
+	}
+
+	// A task that changes column goes to the top of the new one. One that
+	// leaves the done category is taken out of the archive as well.
+	var newRank *string
+	if before.Status != t.Status {
+		top, err := topOfColumn(tx, userID, t.Status)
+		if err != nil {
+			return nil, err
+		}
+		newRank = &top
+	}
+
+	query = `
 		UPDATE tasks
-		SET title = COALESCE(NULLIF($1, ''), title),
-		    description = COALESCE(NULLIF($2, ''), description),
-		    status = COALESCE(NULLIF($3, ''), status),
-		    priority = COALESCE(NULLIF($4, ''), priority),
-		    updated_at = $5
-		WHERE id = $6
-		RETURNING id, title, description, status, priority, created_at, updated_at
-	`
+		SET title = $1,
+		    description = $2,
+		    status = $3,
+		    status_category = $4,
+		    rank = COALESCE($14, rank),
+		    priority = $5,
+		    project_id = $6,
+		    start_at = $7,
+		    due_at = $8,
+		    estimate_hours = $9,
+		    parent_id = $10,
+		    updated_at = $11,
+		    archived_at = CASE WHEN $4 = 'done' THEN archived_at END,
+		    ` + doneAtUpdate("$4", "$11") + `,
+		    version = version + 1
+		WHERE user_id = $12 AND id = $13`
 
-	var updatedTask task.Task
-	err := r.db.QueryRow(
+	_, err := tx.Exec(
 		query,
-		t.Title, t.Description, t.Status, t.Priority, t.UpdatedAt, id,
-	).Scan(
-		&updatedTask.ID,
-		&updatedTask.Title,
-		&updatedTask.Description,
-		&updatedTask.Status,
-		&updatedTask.Priority,
-		&updatedTask.CreatedAt,
-		&updatedTask.UpdatedAt,
+		t.Title, t.Description, t.Status, t.StatusCategory, t.Priority, t.ProjectID, t.StartAt, t.DueAt, t.EstimateHours, t.ParentID, t.UpdatedAt, userID, before.ID, newRank,
 	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	if err := setTaskTags(tx, userID, before.ID, t.Tags); err != nil {
+		return nil, err
+	}
+
+	updatedTask, err := getTask(tx, userID, before.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordDiff(tx, userID, history.ActionUpdate, &before, updatedTask); err != nil {
+		return nil, err
+	}
+
+	if before.StatusCategory != workflow.CategoryDone && updatedTask.StatusCategory == workflow.CategoryDone {
+		if _, err := continueSeries(tx, userID, updatedTask); err != nil {
+			return nil, err
+		}
+	}
+
+	return updatedTask, nil
+}
+
+// getTask reads the user's task by its current key through q, so inside a
+// transaction it sees that transaction's own writes
+func getTask(q sqlx.Queryer, userID int64, id string) (*task.Task, error) {
+	var t task.Task
+	if err := sqlx.Get(q, &t, `SELECT `+taskColumns+` FROM tasks WHERE user_id = $1 AND id = $2`, userID, id); err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	return &t, nil
+}
+
+// nextTaskKey bumps the user's sequence for prefix in a single statement. The row
+// lock it takes is held until the surrounding transaction ends, so concurrent
+// creates (even from separate replicas) always receive distinct numbers.
+func nextTaskKey(q sqlx.Queryer, userID int64, prefix string) (string, error) {
+	query := `
+		INSERT INTO task_key_sequences (user_id, prefix, next_value)
+		VALUES ($1, $2, 2)
+		ON CONFLICT (user_id, prefix) DO UPDATE SET next_value = task_key_sequences.next_value + 1
+		RETURNING next_value - 1
+	`
+
+	var n int
+	if err := q.QueryRowx(query, userID, prefix).Scan(&n); err != nil {
+		return "", fmt.Errorf("failed to allocate task key: %w", err)
+	}
+
+	return task.FormatKey(prefix, n), nil
+}
 
+func (r *TaskRepository) RekeyTask(userID int64, id string, prefix string, version int64) (*task.Task, error) {
+	tx, err := r.db.Beginx()
 	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current task.Task
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.Get(&current, query, userID, id); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("task not found: %s", id)
 		}
-		return nil, fmt.Errorf("failed to update task: %w", err)
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if version != 0 && version != current.Version {
+		return nil, fmt.Errorf("task version conflict: %s", id)
+	}
+
+	// Already under this prefix, nothing to do
+	if strings.HasPrefix(current.ID, prefix+"-") {
+		return &current, nil
+	}
+
+	newID, err := nextTaskKey(tx, userID, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// Existing aliases and tags follow the task through ON UPDATE CASCADE
+	query = `UPDATE tasks SET id = $1, updated_at = $2, version = version + 1 WHERE user_id = $3 AND id = $4`
+	if _, err := tx.Exec(query, newID, time.Now(), userID, current.ID); err != nil {
+		return nil, fmt.Errorf("failed to rekey task: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO task_key_aliases (user_id, alias, task_id) VALUES ($1, $2, $3)`, userID, current.ID, newID); err != nil {
+		return nil, fmt.Errorf("failed to record task alias: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE task_events SET task_id = $1 WHERE user_id = $2 AND task_id = $3`, newID, userID, current.ID); err != nil {
+		return nil, fmt.Errorf("failed to move task history: %w", err)
+	}
+
+	rekeyed, err := getTask(tx, userID, newID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The key isn't a field history keeps, so the event is written here
+	before, _ := json.Marshal(current.ID)
+	after, _ := json.Marshal(newID)
+	changes := history.Changes{"id": {Before: before, After: after}}
+	if err := recordEvent(tx, userID, newID, history.ActionRekey, changes, rekeyed.Version); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return rekeyed, nil
+}
+
+// lockTaskGraph makes the user's writers to the subtask tree and the
+// dependency graph, and writes that complete or reopen tasks, take turns until
+// the transaction ends. Cycles and open blockers only involve one user's
+// tasks, so other users and readers aren't held up. Writers take it before
+// locking any task rows, so they can't deadlock on it.
+func lockTaskGraph(tx *sqlx.Tx, userID int64) error {
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, userID); err != nil {
+		return fmt.Errorf("failed to lock task graph: %w", err)
+	}
+	return nil
+}
+
+// checkParent makes sure the task with key id can sit under parentID and
+// returns the parent's current key. The parent must exist outside the trash,
+// must not be the task itself or one of its subtasks, and the tree must stay
+// within MaxTaskDepth. id is empty for a task that is being created.
+func checkParent(tx *sqlx.Tx, userID int64, id string, parentID string) (string, error) {
+	// Two moves at once could nest tasks under each other, or past the depth
+	// limit, without either check seeing it
+	if err := lockTaskGraph(tx, userID); err != nil {
+		return "", err
 	}
 
-	return &updatedTask, nil
+	// The parent comes first, then its parent and so on up to the top-level task
+	query := `
+		WITH RECURSIVE ancestors(id, parent_id, depth) AS (
+			SELECT id, parent_id, 1 FROM tasks WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NULL
+			UNION ALL
+			SELECT t.id, t.parent_id, a.depth + 1 FROM tasks t JOIN ancestors a ON t.id = a.parent_id WHERE t.user_id = $1 AND a.depth < $3
+		)
+		SELECT id FROM ancestors ORDER BY depth`
+	var ancestors []string
+	if err := tx.Select(&ancestors, query, userID, parentID, taskTreeWalkLimit); err != nil {
+		return "", fmt.Errorf("failed to get task ancestors: %w", err)
+	}
+	if len(ancestors) == 0 {
+		return "", fmt.Errorf("parent not found: %s", parentID)
+	}
+
+	height := 1
+	if id != "" {
+		if slices.Contains(ancestors, id) {
+			return "", fmt.Errorf("parent cycle: %s under %s", id, parentID)
+		}
+
+		// The task itself may be coming back from the trash; its subtasks
+		// in the trash don't count
+		query = `
+			WITH RECURSIVE subtree(id, depth) AS (
+				SELECT id, 1 FROM tasks WHERE user_id = $1 AND id = $2
+				UNION ALL
+				SELECT t.id, s.depth + 1 FROM tasks t JOIN subtree s ON t.parent_id = s.id
+				WHERE t.user_id = $1 AND t.deleted_at IS NULL AND s.depth < $3
+			)
+			SELECT COALESCE(MAX(depth), 1) FROM subtree`
+		if err := tx.Get(&height, query, userID, id, taskTreeWalkLimit); err != nil {
+			return "", fmt.Errorf("failed to get subtree height: %w", err)
+		}
+	}
+
+	if len(ancestors)+height > MaxTaskDepth {
+		return "", fmt.Errorf("parent too deep: %s", parentID)
+	}
+	return ancestors[0], nil
 }
@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+	"tasker/internal/migrate"
+	"tasker/internal/user"
+	"tasker/migrations"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupDatabase connects to the Postgres database in DATABASE_URL and brings
+// its schema up to date, skipping the test when there isn't one
+func setupDatabase(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	return db
+}
+
+// setupTaskRepository returns a task repository and a user of its own, so
+// tests sharing a database don't see each other's tasks
+func setupTaskRepository(t *testing.T) (*TaskRepository, int64) {
+	t.Helper()
+
+	db := setupDatabase(t)
+	u, err := NewUserRepository(db).CreateUser(user.User{
+		Email: fmt.Sprintf("%s-%d@example.com", t.Name(), time.Now().UnixNano()),
+		Name:  t.Name(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	return NewTaskRepository(db), u.ID
+}
+
+func createTestTask(t *testing.T, r *TaskRepository, userID int64, title string, parentID *string) *task.Task {
+	t.Helper()
+
+	created, err := r.CreateTask(userID, task.Task{
+		Title:          title,
+		Status:         "To Do",
+		StatusCategory: "todo",
+		Priority:       "Medium",
+		ParentID:       parentID,
+	}, task.DefaultKeyPrefix, nil)
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+	return created
+}
+
+func trashByID(t *testing.T, r *TaskRepository, userID int64) map[string]task.Task {
+	t.Helper()
+
+	tasks, err := r.GetTrash(userID)
+	if err != nil {
+		t.Fatalf("failed to get trash: %v", err)
+	}
+
+	byID := make(map[string]task.Task, len(tasks))
+	for _, tt := range tasks {
+		byID[tt.ID] = tt
+	}
+	return byID
+}
+
+func taskIDs(tasks []task.Task) []string {
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+func TestGetTrashedSubtree_OnlyTasksTrashedTogether(t *testing.T) {
+	r, userID := setupTaskRepository(t)
+
+	parent := createTestTask(t, r, userID, "Parent", nil)
+	kept := createTestTask(t, r, userID, "Deleted with the parent", &parent.ID)
+	earlier := createTestTask(t, r, userID, "Deleted on its own", &parent.ID)
+
+	assert.NoError(t, r.DeleteTask(userID, earlier.ID, 0, TaskDeleteReject))
+	assert.NoError(t, r.DeleteTask(userID, parent.ID, 0, TaskDeleteCascade))
+
+	// deleted_at is the NOW() of the delete's transaction
+	bin := trashByID(t, r, userID)
+	if assert.Equal(t, 3, len(bin)) {
+		assert.True(t, bin[parent.ID].DeletedAt.Equal(*bin[kept.ID].DeletedAt))
+		assert.False(t, bin[parent.ID].DeletedAt.Equal(*bin[earlier.ID].DeletedAt))
+	}
+
+	subtree, err := r.GetTrashedSubtree(userID, parent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{parent.ID, kept.ID}, taskIDs(subtree))
+
+	subtree, err = r.GetTrashedSubtree(userID, earlier.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{earlier.ID}, taskIDs(subtree))
+
+	_, err = r.GetTrashedSubtree(userID, createTestTask(t, r, userID, "Not trashed", nil).ID)
+	assert.ErrorContains(t, err, "task not found")
+}
+
+func TestUndoMutation_RestoresCascadeOnce(t *testing.T) {
+	r, userID := setupTaskRepository(t)
+
+	parent := createTestTask(t, r, userID, "Parent", nil)
+	child := createTestTask(t, r, userID, "Child", &parent.ID)
+	assert.NoError(t, r.DeleteTask(userID, parent.ID, 0, TaskDeleteCascade))
+
+	events, err := r.GetUndoableMutation(userID, time.Hour)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, len(events))
+	for _, e := range events {
+		assert.Equal(t, history.ActionDelete, e.Action)
+		assert.Equal(t, events[0].MutationID, e.MutationID)
+	}
+
+	// Children first, so the parent has to be put back before them
+	bin := trashByID(t, r, userID)
+	restored, err := r.UndoMutation(userID, events[0].MutationID, []task.Task{bin[child.ID], bin[parent.ID]})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{parent.ID, child.ID}, taskIDs(restored))
+	assert.Empty(t, trashByID(t, r, userID))
+
+	back, err := r.GetTaskByID(userID, child.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &parent.ID, back.ParentID)
+	}
+
+	_, err = r.UndoMutation(userID, events[0].MutationID, restored)
+	assert.ErrorContains(t, err, "nothing to undo")
+
+	// The undo itself can't be undone, and the creates never could
+	_, err = r.GetUndoableMutation(userID, time.Hour)
+	assert.ErrorContains(t, err, "nothing to undo")
+}
+
+func TestApplyTaskChanges_DryRunLeavesTasksAlone(t *testing.T) {
+	r, userID := setupTaskRepository(t)
+
+	edited := createTestTask(t, r, userID, "Old title", nil)
+	deleted := createTestTask(t, r, userID, "Doomed", nil)
+
+	change := *edited
+	change.Title = "New title"
+	changes := []TaskChange{
+		{ID: edited.ID, Task: change},
+		{ID: deleted.ID, Task: task.Task{Version: deleted.Version}, Delete: true, DeleteMode: TaskDeleteReject},
+	}
+
+	changed, err := r.ApplyTaskChanges(userID, changes, true, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "New title", changed[0].Title)
+	assert.Equal(t, edited.Version+1, changed[0].Version)
+	assert.Nil(t, changed[1])
+
+	current, err := r.GetTaskByID(userID, edited.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Old title", current.Title)
+		assert.Equal(t, edited.Version, current.Version)
+	}
+
+	_, err = r.GetTaskByID(userID, deleted.ID)
+	assert.NoError(t, err)
+
+	events, err := r.GetTaskHistory(userID, edited.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+
+	// Limits are checked with every change made, dry run or not
+	limits := []WIPLimit{{Status: "To Do", Limit: 1}}
+	_, err = r.ApplyTaskChanges(userID, changes[:1], true, limits)
+	var over *WIPLimitError
+	if assert.True(t, errors.As(err, &over)) {
+		assert.Equal(t, 2, over.Count)
+	}
+
+	_, err = r.ApplyTaskChanges(userID, changes, true, limits)
+	assert.NoError(t, err)
+}
+
+func TestMoveTask_RebalancesWhenGapRunsOut(t *testing.T) {
+	r, userID := setupTaskRepository(t)
+
+	// New tasks go on top, so this is the column's order
+	third := createTestTask(t, r, userID, "Third", nil)
+	second := createTestTask(t, r, userID, "Second", nil)
+	first := createTestTask(t, r, userID, "First", nil)
+
+	// Moving the last card under the first over and over halves the same gap
+	// until there is no key left in it
+	order := []*task.Task{first, second, third}
+	rebalanced := false
+	for range 500 {
+		last := order[2]
+		moved, err := r.MoveTask(userID, last.ID, TaskMove{Status: "To Do", Category: "todo", AfterID: first.ID})
+		if !assert.NoError(t, err) {
+			return
+		}
+		order = []*task.Task{first, moved, order[1]}
+
+		top, err := r.GetTaskByID(userID, first.ID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		if top.Rank != first.Rank {
+			rebalanced = true
+			// Spreading the column out again is invisible to clients
+			assert.Equal(t, first.Version, top.Version)
+			break
+		}
+	}
+	if !rebalanced {
+		t.Fatal("column was never rebalanced")
+	}
+
+	ranks := make([]string, len(order))
+	for i, o := range order {
+		current, err := r.GetTaskByID(userID, o.ID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		ranks[i] = current.Rank
+	}
+	assert.Less(t, ranks[0], ranks[1])
+	assert.Less(t, ranks[1], ranks[2])
+}
+
+func TestUpdateTask_ConcurrentParentsCantFormCycle(t *testing.T) {
+	r, userID := setupTaskRepository(t)
+
+	first := createTestTask(t, r, userID, "First", nil)
+	second := createTestTask(t, r, userID, "Second", nil)
+
+	// Each goes under the other at the same time; only one of them can win
+	errs := make(chan error, 2)
+	for _, pair := range [][2]*task.Task{{first, second}, {second, first}} {
+		go func() {
+			child := *pair[0]
+			child.ParentID = &pair[1].ID
+			child.Version = 0
+			_, err := r.UpdateTask(userID, child.ID, child, nil)
+			errs <- err
+		}()
+	}
+
+	failed := 0
+	for range 2 {
+		if err := <-errs; err != nil {
+			assert.ErrorContains(t, err, "parent cycle")
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed)
+}
+
+func TestUpdateTask_RefusesCompletingBlockedTask(t *testing.T) {
+	r, userID := setupTaskRepository(t)
+
+	blocker := createTestTask(t, r, userID, "Blocker", nil)
+	blocked := createTestTask(t, r, userID, "Blocked", nil)
+	if _, err := r.AddDependency(userID, blocked.ID, blocker.ID); err != nil {
+		t.Fatalf("failed to add dependency: %v", err)
+	}
+
+	done := *blocked
+	done.Status, done.StatusCategory, done.Version = "Done", "done", 0
+	_, err := r.UpdateTask(userID, blocked.ID, done, nil)
+	var refused *BlockedError
+	if assert.True(t, errors.As(err, &refused)) {
+		assert.Equal(t, []string{blocker.ID}, taskIDs(refused.Blockers))
+	}
+
+	_, err = r.MoveTask(userID, blocked.ID, TaskMove{Status: "Done", Category: "done"})
+	assert.True(t, errors.As(err, &refused))
+
+	current, err := r.GetTaskByID(userID, blocked.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "To Do", current.Status)
+	}
+
+	// Once the blocker is done nothing stands in the way
+	finished := *blocker
+	finished.Status, finished.StatusCategory, finished.Version = "Done", "done", 0
+	_, err = r.UpdateTask(userID, blocker.ID, finished, nil)
+	assert.NoError(t, err)
+	_, err = r.UpdateTask(userID, blocked.ID, done, nil)
+	assert.NoError(t, err)
+}
+
+func TestCreateTask_NumbersKeysPerUser(t *testing.T) {
+	r, first := setupTaskRepository(t)
+	_, second := setupTaskRepository(t)
+
+	mine := createTestTask(t, r, first, "Mine", nil)
+	theirs := createTestTask(t, r, second, "Theirs", nil)
+	assert.Equal(t, "TASK-001", mine.ID)
+	assert.Equal(t, "TASK-001", theirs.ID)
+
+	// An alias left behind by a rekey only resolves for its owner
+	rekeyed, err := r.RekeyTask(second, theirs.ID, "HOME", theirs.Version)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "HOME-001", rekeyed.ID)
+	}
+	got, err := r.GetTaskByID(first, "TASK-001")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Mine", got.Title)
+	}
+	got, err = r.GetTaskByID(second, "TASK-001")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Theirs", got.Title)
+	}
+
+	events, err := r.GetTaskHistory(second, "HOME-001")
+	if assert.NoError(t, err) {
+		assert.Equal(t, history.ActionRekey, events[0].Action)
+	}
+
+	// A stale version is refused
+	_, err = r.RekeyTask(first, mine.ID, "HOME", mine.Version+1)
+	assert.ErrorContains(t, err, "task version conflict")
+}
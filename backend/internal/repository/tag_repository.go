@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+	"tasker/internal/tag"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const tagColumns = `id, user_id, name, color_hex, created_at, updated_at,
+	(SELECT COUNT(*) FROM task_tags tt JOIN tasks t ON t.user_id = tt.user_id AND t.id = tt.task_id AND t.deleted_at IS NULL
+		WHERE tt.tag_id = tags.id) AS task_count`
+
+// TagRepositoryInterface defines the contract for tag data operations.
+// Every method is scoped to the tags owned by userID.
+type TagRepositoryInterface interface {
+	GetAllTags(userID int64) ([]tag.Tag, error)
+	GetTagByID(userID int64, id int64) (*tag.Tag, error)
+	CreateTag(userID int64, t tag.Tag) (*tag.Tag, error)
+	// UpdateTag renames or recolours a tag; every task using it follows along
+	UpdateTag(userID int64, id int64, t tag.Tag) (*tag.Tag, error)
+	// DeleteTag removes a tag from every task and then deletes it
+	DeleteTag(userID int64, id int64) error
+}
+
+type TagRepository struct {
+	db *sqlx.DB
+}
+
+var Tags TagRepositoryInterface
+
+func NewTagRepository(db *sqlx.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// isUniqueViolation reports whether err is Postgres rejecting a duplicate key
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+func (r *TagRepository) GetAllTags(userID int64) ([]tag.Tag, error) {
+	tags := []tag.Tag{}
+	query := `SELECT ` + tagColumns + ` FROM tags WHERE user_id = $1 ORDER BY LOWER(name)`
+
+	if err := r.db.Select(&tags, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (r *TagRepository) GetTagByID(userID int64, id int64) (*tag.Tag, error) {
+	var t tag.Tag
+	query := `SELECT ` + tagColumns + ` FROM tags WHERE user_id = $1 AND id = $2`
+
+	if err := r.db.Get(&t, query, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tag not found: %d", id)
+		}
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	return &t, nil
+}
+
+func (r *TagRepository) CreateTag(userID int64, t tag.Tag) (*tag.Tag, error) {
+	now := time.Now()
+
+	query := `
+		INSERT INTO tags (user_id, name, color_hex, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + tagColumns
+
+	var created tag.Tag
+	if err := r.db.QueryRowx(query, userID, t.Name, t.ColorHex, now, now).StructScan(&created); err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("tag already exists: %s", t.Name)
+		}
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *TagRepository) UpdateTag(userID int64, id int64, t tag.Tag) (*tag.Tag, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	tagged, err := lockTaggedTasks(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE tags
+		SET name = COALESCE(NULLIF($1, ''), name),
+		    color_hex = COALESCE(NULLIF($2, ''), color_hex),
+		    updated_at = $3
+		WHERE user_id = $4 AND id = $5
+		RETURNING ` + tagColumns
+
+	var updated tag.Tag
+	if err := tx.QueryRowx(query, t.Name, t.ColorHex, time.Now(), userID, id).StructScan(&updated); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tag not found: %d", id)
+		}
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("tag already exists: %s", t.Name)
+		}
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	if err := retagTasks(tx, userID, tagged); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &updated, nil
+}
+
+func (r *TagRepository) DeleteTag(userID int64, id int64) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.Get(&exists, `SELECT true FROM tags WHERE user_id = $1 AND id = $2 FOR UPDATE`, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("tag not found: %d", id)
+		}
+		return fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	tagged, err := lockTaggedTasks(tx, id)
+	if err != nil {
+		return err
+	}
+
+	// task_tags rows go with the tag through ON DELETE CASCADE
+	if _, err := tx.Exec(`DELETE FROM tags WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	if err := retagTasks(tx, userID, tagged); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// lockTaggedTasks locks the tasks carrying the tag and returns them as they
+// are before it changes
+func lockTaggedTasks(tx *sqlx.Tx, tagID int64) ([]task.Task, error) {
+	var tagged []task.Task
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE (user_id, id) IN (SELECT user_id, task_id FROM task_tags WHERE tag_id = $1) ORDER BY id FOR UPDATE`
+	if err := tx.Select(&tagged, query, tagID); err != nil {
+		return nil, fmt.Errorf("failed to get tagged tasks: %w", err)
+	}
+	return tagged, nil
+}
+
+// retagTasks bumps the version of each task whose tags a rename or delete
+// changed, so its ETag changes too, and records the change in its history.
+// A tag that only changed colour leaves the tasks alone.
+func retagTasks(tx *sqlx.Tx, userID int64, tagged []task.Task) error {
+	for _, before := range tagged {
+		after, err := getTask(tx, userID, before.ID)
+		if err != nil {
+			return err
+		}
+		if len(history.Diff(&before, after)) == 0 {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE tasks SET version = version + 1 WHERE user_id = $1 AND id = $2`, userID, before.ID); err != nil {
+			return fmt.Errorf("failed to update tagged task: %w", err)
+		}
+		after.Version++
+		if err := recordDiff(tx, userID, history.ActionUpdate, &before, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTaskTags replaces a task's tags with names, creating any tags the user doesn't
+// have yet. Names match existing tags ignoring case.
+func setTaskTags(tx *sqlx.Tx, userID int64, taskID string, names []string) error {
+	if _, err := tx.Exec(`DELETE FROM task_tags WHERE user_id = $1 AND task_id = $2`, userID, taskID); err != nil {
+		return fmt.Errorf("failed to clear task tags: %w", err)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO tags (user_id, name)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT (user_id, LOWER(name)) DO NOTHING
+	`
+	if _, err := tx.Exec(query, userID, pq.Array(names)); err != nil {
+		return fmt.Errorf("failed to create tags: %w", err)
+	}
+
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+
+	query = `
+		INSERT INTO task_tags (user_id, task_id, tag_id)
+		SELECT $2, $1, id FROM tags WHERE user_id = $2 AND LOWER(name) = ANY($3)
+	`
+	if _, err := tx.Exec(query, taskID, userID, pq.Array(lowered)); err != nil {
+		return fmt.Errorf("failed to tag task: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+	"tasker/internal/workflow"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (r *TaskRepository) ArchiveTask(userID int64, id string, version int64, archived bool, limit *WIPLimit) (*task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var before task.Task
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.Get(&before, query, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("task not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if version != 0 && version != before.Version {
+		return nil, fmt.Errorf("task version conflict: %s", id)
+	}
+	if (before.ArchivedAt != nil) == archived {
+		return &before, nil
+	}
+
+	var after *task.Task
+	if archived {
+		after, err = archiveTask(tx, userID, before)
+	} else if err = checkWIPLimit(tx, userID, limit, before.ID, 1); err == nil {
+		after, err = unarchiveTask(tx, userID, before)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return after, nil
+}
+
+// archiveTask takes before, which the transaction has locked, off the board
+func archiveTask(tx *sqlx.Tx, userID int64, before task.Task) (*task.Task, error) {
+	if before.StatusCategory != workflow.CategoryDone {
+		return nil, fmt.Errorf("task not done: %s", before.ID)
+	}
+
+	query := `UPDATE tasks SET archived_at = $1, updated_at = $1, version = version + 1 WHERE user_id = $2 AND id = $3`
+	if _, err := tx.Exec(query, time.Now(), userID, before.ID); err != nil {
+		return nil, fmt.Errorf("failed to archive task: %w", err)
+	}
+
+	archived, err := getTask(tx, userID, before.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordDiff(tx, userID, history.ActionArchive, &before, archived); err != nil {
+		return nil, err
+	}
+	return archived, nil
+}
+
+// unarchiveTask puts before, which the transaction has locked, back at the top
+// of its column. It counts as done from now, so it isn't archived again on the
+// next run.
+func unarchiveTask(tx *sqlx.Tx, userID int64, before task.Task) (*task.Task, error) {
+	top, err := topOfColumn(tx, userID, before.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE tasks SET archived_at = NULL, rank = $1, updated_at = $2, done_at = $2, version = version + 1 WHERE user_id = $3 AND id = $4`
+	if _, err := tx.Exec(query, top, time.Now(), userID, before.ID); err != nil {
+		return nil, fmt.Errorf("failed to unarchive task: %w", err)
+	}
+
+	unarchived, err := getTask(tx, userID, before.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordDiff(tx, userID, history.ActionUnarchive, &before, unarchived); err != nil {
+		return nil, err
+	}
+	return unarchived, nil
+}
+
+func (r *TaskRepository) ArchiveDoneTasks(age time.Duration) (int64, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Tasks someone is writing right now are left for the next run
+	var due []task.Task
+	query := `
+		SELECT ` + taskColumns + ` FROM tasks
+		WHERE status_category = 'done' AND archived_at IS NULL AND deleted_at IS NULL
+			AND done_at < NOW() - make_interval(secs => $1)
+		ORDER BY id
+		FOR UPDATE SKIP LOCKED`
+	if err := tx.Select(&due, query, age.Seconds()); err != nil {
+		return 0, fmt.Errorf("failed to get done tasks: %w", err)
+	}
+
+	for _, t := range due {
+		if _, err := archiveTask(tx, t.UserID, t); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int64(len(due)), nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"fmt"
+
+	task "tasker/internal/Task"
+)
+
+// TaskChange is one write of ApplyTaskChanges: the task with key ID gets
+// Task's editable fields as with UpdateTask, or with Delete goes to the trash
+// as with DeleteTask in DeleteMode. A non-zero Task.Version must match the
+// stored one either way.
+type TaskChange struct {
+	ID         string
+	Task       task.Task
+	Delete     bool
+	DeleteMode TaskDeleteMode
+}
+
+// TaskChangeError is the failure of the change at Index of ApplyTaskChanges.
+// Err is the error UpdateTask or DeleteTask would have returned for it.
+type TaskChangeError struct {
+	Index int
+	Err   error
+}
+
+func (e *TaskChangeError) Error() string {
+	return fmt.Sprintf("change %d: %v", e.Index, e.Err)
+}
+
+func (e *TaskChangeError) Unwrap() error {
+	return e.Err
+}
+
+func (r *TaskRepository) ApplyTaskChanges(userID int64, changes []TaskChange, dryRun bool, limits []WIPLimit) ([]*task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Deletes lock task rows, so the graph lock updates take goes first
+	if err := lockTaskGraph(tx, userID); err != nil {
+		return nil, err
+	}
+
+	changed := make([]*task.Task, len(changes))
+	for i, change := range changes {
+		if change.Delete {
+			err = deleteTask(tx, userID, change.ID, change.Task.Version, change.DeleteMode)
+		} else {
+			changed[i], err = updateTask(tx, userID, change.ID, change.Task, nil)
+		}
+		if err != nil {
+			return nil, &TaskChangeError{Index: i, Err: err}
+		}
+	}
+
+	// Tasks leaving a column make room in it, so columns are counted last
+	for i := range limits {
+		if err := checkWIPLimit(tx, userID, &limits[i], "", 0); err != nil {
+			return nil, err
+		}
+	}
+
+	// A dry run sees every change made and then leaves it to the rollback
+	if dryRun {
+		return changed, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return changed, nil
+}
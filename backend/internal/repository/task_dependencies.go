@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	task "tasker/internal/Task"
+	"tasker/internal/workflow"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// BlockedError is returned when a write would complete a task while some of
+// the tasks blocking it aren't done
+type BlockedError struct {
+	TaskID   string
+	Blockers []task.Task
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("task blocked: %s", e.TaskID)
+}
+
+// checkBlockers fails with a BlockedError when before, which the transaction
+// has locked, would go into category while it has open blockers. Callers take
+// lockTaskGraph first, so a blocker being reopened meanwhile has either
+// committed or waits for this write.
+func checkBlockers(tx *sqlx.Tx, userID int64, before *task.Task, category string) error {
+	if category != workflow.CategoryDone || before.StatusCategory == workflow.CategoryDone {
+		return nil
+	}
+
+	blockers, err := openBlockers(tx, userID, before.ID)
+	if err != nil {
+		return err
+	}
+	if len(blockers) > 0 {
+		return &BlockedError{TaskID: before.ID, Blockers: blockers}
+	}
+	return nil
+}
+
+// resolveTaskKey returns the current key of the user's task matching id, which may be an old key
+func resolveTaskKey(q sqlx.Queryer, userID int64, id string) (string, error) {
+	var current string
+	query := `SELECT id FROM tasks WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NULL`
+	if err := sqlx.Get(q, &current, query, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("task not found: %s", id)
+		}
+		return "", fmt.Errorf("failed to get task: %w", err)
+	}
+	return current, nil
+}
+
+// bumpTasks marks the user's tasks whose blocked_by or blocks lists changed as modified
+func bumpTasks(tx *sqlx.Tx, userID int64, ids ...string) error {
+	query, args, err := sqlx.In(`UPDATE tasks SET version = version + 1 WHERE user_id = ? AND id IN (?)`, userID, ids)
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to update tasks: %w", err)
+	}
+	return nil
+}
+
+func (r *TaskRepository) AddDependency(userID int64, id string, blockerID string) (*task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Two edges added at once could close a cycle that neither check sees
+	if err := lockTaskGraph(tx, userID); err != nil {
+		return nil, err
+	}
+
+	blocked, err := resolveTaskKey(tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	blocker, err := resolveTaskKey(tx, userID, blockerID)
+	if err != nil {
+		return nil, fmt.Errorf("blocker not found: %s", blockerID)
+	}
+
+	// The new edge closes a cycle if the blocker already waits on the blocked task
+	query := `
+		WITH RECURSIVE downstream(id) AS (
+			SELECT $2::VARCHAR
+			UNION
+			SELECT d.blocked_id FROM task_dependencies d JOIN downstream s ON d.blocker_id = s.id WHERE d.user_id = $1
+		)
+		SELECT EXISTS (SELECT 1 FROM downstream WHERE id = $3)`
+	var cycle bool
+	if err := tx.Get(&cycle, query, userID, blocked, blocker); err != nil {
+		return nil, fmt.Errorf("failed to check dependencies: %w", err)
+	}
+	if cycle {
+		return nil, fmt.Errorf("dependency cycle: %s blocks %s", blocked, blocker)
+	}
+
+	result, err := tx.Exec(`INSERT INTO task_dependencies (user_id, blocker_id, blocked_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, userID, blocker, blocked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add dependency: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n > 0 {
+		if err := bumpTasks(tx, userID, blocked, blocker); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := getTask(tx, userID, blocked)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updated, nil
+}
+
+func (r *TaskRepository) RemoveDependency(userID int64, id string, blockerID string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	blocked, err := resolveTaskKey(tx, userID, id)
+	if err != nil {
+		return err
+	}
+	blocker, err := resolveTaskKey(tx, userID, blockerID)
+	if err != nil {
+		return fmt.Errorf("dependency not found: %s", blockerID)
+	}
+
+	result, err := tx.Exec(`DELETE FROM task_dependencies WHERE user_id = $1 AND blocker_id = $2 AND blocked_id = $3`, userID, blocker, blocked)
+	if err != nil {
+		return fmt.Errorf("failed to remove dependency: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("dependency not found: %s", blockerID)
+	}
+
+	if err := bumpTasks(tx, userID, blocked, blocker); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TaskRepository) GetOpenBlockers(userID int64, id string) ([]task.Task, error) {
+	return openBlockers(r.db, userID, id)
+}
+
+func openBlockers(q sqlx.Queryer, userID int64, id string) ([]task.Task, error) {
+	tasks := []task.Task{}
+	query := `
+		SELECT ` + taskColumns + ` FROM tasks
+		WHERE user_id = $1 AND status_category <> 'done' AND deleted_at IS NULL AND id IN (
+			SELECT blocker_id FROM task_dependencies
+			WHERE user_id = $1 AND blocked_id IN (SELECT id FROM tasks WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + `)
+		)
+		ORDER BY id`
+
+	if err := sqlx.Select(q, &tasks, query, userID, id); err != nil {
+		return nil, fmt.Errorf("failed to get blockers: %w", err)
+	}
+
+	return tasks, nil
+}
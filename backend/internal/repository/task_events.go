@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const taskEventColumns = `id, user_id, task_id, actor_id, action, changes, version, mutation_id, undone, created_at`
+
+// recordEvent appends an event to a task's history. Tasks are only written by
+// their owner, so userID is the actor as well.
+func recordEvent(tx *sqlx.Tx, userID int64, taskID string, action string, changes history.Changes, version int64) error {
+	query := `
+		INSERT INTO task_events (user_id, task_id, actor_id, action, changes, version)
+		VALUES ($1, $2, $1, $3, $4, $5)`
+	if _, err := tx.Exec(query, userID, taskID, action, changes, version); err != nil {
+		return fmt.Errorf("failed to record task event: %w", err)
+	}
+	return nil
+}
+
+// recordDiff records the fields that differ between before and after, where
+// either may be nil for a create or a delete. A write that changed none of the
+// tracked fields leaves no event.
+func recordDiff(tx *sqlx.Tx, userID int64, action string, before, after *task.Task) error {
+	changes := history.Diff(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	current := after
+	if current == nil {
+		current = before
+	}
+	return recordEvent(tx, userID, current.ID, action, changes, current.Version)
+}
+
+func (r *TaskRepository) GetTaskHistory(userID int64, id string) ([]history.Event, error) {
+	// Tasks in the trash are found by their old keys too. Tasks purged from it
+	// have no history left.
+	var current string
+	query := `SELECT id FROM tasks WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2")
+	err := r.db.Get(&current, query, userID, id)
+	exists := err == nil
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+		current = id
+	}
+
+	events := []history.Event{}
+	query = `SELECT ` + taskEventColumns + ` FROM task_events WHERE user_id = $1 AND task_id = $2 ORDER BY id DESC`
+	if err := r.db.Select(&events, query, userID, current); err != nil {
+		return nil, fmt.Errorf("failed to get task history: %w", err)
+	}
+	if len(events) == 0 && !exists {
+		return nil, fmt.Errorf("task not found: %s", id)
+	}
+
+	return events, nil
+}
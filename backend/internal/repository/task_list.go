@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/workflow"
+
+	"github.com/lib/pq"
+)
+
+// ListOptions filters, orders and pages a task listing. Zero values mean "no filter".
+type ListOptions struct {
+	ProjectID *int64
+	// ParentID keeps only the direct subtasks of that task
+	ParentID *string
+	Statuses []string
+	// Categories and ExcludeCategories match on the status's workflow category
+	Categories        []string
+	ExcludeCategories []string
+	Priorities        []string
+	CreatedAfter      *time.Time
+	CreatedBefore     *time.Time
+	UpdatedAfter      *time.Time
+	UpdatedBefore     *time.Time
+	DueAfter          *time.Time
+	DueBefore         *time.Time
+	// Archived keeps only archived tasks; ArchivedAfter and ArchivedBefore imply it
+	Archived       bool
+	ArchivedAfter  *time.Time
+	ArchivedBefore *time.Time
+	// NoDueDate keeps only tasks without a due date
+	NoDueDate bool
+	// Tags keeps tasks carrying any of the tags, or all of them when AllTags is set.
+	// Names match ignoring case.
+	Tags    []string
+	AllTags bool
+	// Query is a web-search style full-text query over title and description
+	Query string
+	// Sort is one of SortableTaskColumns; ties are broken by task id
+	Sort       string
+	Descending bool
+	// Limit caps the page size. Zero returns every match.
+	Limit int
+	// After continues a listing from the cursor returned with the previous page
+	After *TaskCursor
+}
+
+// DefaultTaskSort is the order tasks are listed in when none is requested
+const DefaultTaskSort = "created_at"
+
+// SortableTaskColumns whitelists the columns tasks can be ordered by
+var SortableTaskColumns = []string{"created_at", "updated_at", "due_at", "archived_at", "title", "status", "priority"}
+
+// sortColumn is how a sortable column is ordered in SQL and how cursor values are cast back
+type sortColumn struct {
+	expr string
+	cast string
+}
+
+var taskSortColumns = map[string]sortColumn{
+	"created_at": {expr: "created_at", cast: "timestamp"},
+	"updated_at": {expr: "updated_at", cast: "timestamp"},
+	// Tasks without a due date sort after every dated task
+	"due_at": {expr: "COALESCE(due_at, 'infinity'::timestamptz)", cast: "timestamptz"},
+	// Tasks that aren't archived sort after every archived one
+	"archived_at": {expr: "COALESCE(archived_at, 'infinity'::timestamp)", cast: "timestamp"},
+	"title":       {expr: "title", cast: "text"},
+	// Column names differ between boards, so statuses sort by category
+	"status":   {expr: rankExpr("status_category", workflow.Categories), cast: "int"},
+	"priority": {expr: rankExpr("priority", task.Priorities), cast: "int"},
+}
+
+// rankExpr orders a column by its position in values (board order) rather than alphabetically
+func rankExpr(column string, values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return "COALESCE(array_position(ARRAY[" + strings.Join(quoted, ", ") + "]::text[], " + column + "::text), 0)"
+}
+
+// TaskRank returns value's 1-based position in values, or 0 if it isn't one of them
+func TaskRank(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// TaskCursor marks where a page ended. It is handed to clients as an opaque string
+// and only continues a listing with the same sort.
+type TaskCursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Value      string `json:"v"`
+	ID         string `json:"id"`
+}
+
+// NewTaskCursor returns the cursor that continues opts's listing after t
+func NewTaskCursor(t task.Task, opts ListOptions) TaskCursor {
+	var value string
+	switch opts.Sort {
+	case "updated_at":
+		value = t.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case "due_at":
+		value = "infinity"
+		if t.DueAt != nil {
+			value = t.DueAt.UTC().Format(time.RFC3339Nano)
+		}
+	case "archived_at":
+		value = "infinity"
+		if t.ArchivedAt != nil {
+			value = t.ArchivedAt.UTC().Format(time.RFC3339Nano)
+		}
+	case "title":
+		value = t.Title
+	case "status":
+		value = strconv.Itoa(TaskRank(workflow.Categories, t.StatusCategory))
+	case "priority":
+		value = strconv.Itoa(TaskRank(task.Priorities, t.Priority))
+	default:
+		value = t.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	return TaskCursor{Sort: opts.Sort, Descending: opts.Descending, Value: value, ID: t.ID}
+}
+
+func (c TaskCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeTaskCursor parses a cursor previously returned by Encode
+func DecodeTaskCursor(s string) (*TaskCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var c TaskCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	if _, ok := taskSortColumns[c.Sort]; !ok {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &c, nil
+}
+
+// queryArgs collects positional arguments while a query is being built
+type queryArgs []any
+
+func (a *queryArgs) add(v any) string {
+	*a = append(*a, v)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+func (r *TaskRepository) ListTasks(userID int64, opts ListOptions) ([]task.Task, string, error) {
+	if opts.Sort == "" {
+		opts.Sort = DefaultTaskSort
+	}
+	column, ok := taskSortColumns[opts.Sort]
+	if !ok {
+		return nil, "", fmt.Errorf("invalid sort column: %s", opts.Sort)
+	}
+
+	var args queryArgs
+	where := []string{"user_id = " + args.add(userID), "deleted_at IS NULL"}
+
+	if opts.ParentID != nil {
+		where = append(where, "parent_id = "+args.add(*opts.ParentID))
+	}
+	if opts.ProjectID != nil {
+		where = append(where, "project_id = "+args.add(*opts.ProjectID))
+	}
+	if len(opts.Statuses) > 0 {
+		where = append(where, "status = ANY("+args.add(pq.Array(opts.Statuses))+")")
+	}
+	if len(opts.Categories) > 0 {
+		where = append(where, "status_category = ANY("+args.add(pq.Array(opts.Categories))+")")
+	}
+	if len(opts.ExcludeCategories) > 0 {
+		where = append(where, "status_category <> ALL("+args.add(pq.Array(opts.ExcludeCategories))+")")
+	}
+	if len(opts.Priorities) > 0 {
+		where = append(where, "priority = ANY("+args.add(pq.Array(opts.Priorities))+")")
+	}
+	if opts.CreatedAfter != nil {
+		where = append(where, "created_at >= "+args.add(*opts.CreatedAfter))
+	}
+	if opts.CreatedBefore != nil {
+		where = append(where, "created_at < "+args.add(*opts.CreatedBefore))
+	}
+	if opts.UpdatedAfter != nil {
+		where = append(where, "updated_at >= "+args.add(*opts.UpdatedAfter))
+	}
+	if opts.UpdatedBefore != nil {
+		where = append(where, "updated_at < "+args.add(*opts.UpdatedBefore))
+	}
+	if opts.DueAfter != nil {
+		where = append(where, "due_at >= "+args.add(*opts.DueAfter))
+	}
+	if opts.DueBefore != nil {
+		where = append(where, "due_at < "+args.add(*opts.DueBefore))
+	}
+	if opts.NoDueDate {
+		where = append(where, "due_at IS NULL")
+	}
+	if opts.Archived {
+		where = append(where, "archived_at IS NOT NULL")
+	}
+	if opts.ArchivedAfter != nil {
+		where = append(where, "archived_at >= "+args.add(*opts.ArchivedAfter))
+	}
+	if opts.ArchivedBefore != nil {
+		where = append(where, "archived_at < "+args.add(*opts.ArchivedBefore))
+	}
+	if len(opts.Tags) > 0 {
+		lowered := make([]string, 0, len(opts.Tags))
+		for _, name := range opts.Tags {
+			if name = strings.ToLower(name); !slices.Contains(lowered, name) {
+				lowered = append(lowered, name)
+			}
+		}
+		matching := `SELECT COUNT(*) FROM task_tags tt JOIN tags tg ON tg.id = tt.tag_id
+			WHERE tt.user_id = tasks.user_id AND tt.task_id = tasks.id AND LOWER(tg.name) = ANY(` + args.add(pq.Array(lowered)) + `)`
+		if opts.AllTags {
+			where = append(where, "("+matching+") = "+args.add(len(lowered)))
+		} else {
+			where = append(where, "("+matching+") > 0")
+		}
+	}
+	if opts.Query != "" {
+		where = append(where, "search_vector @@ websearch_to_tsquery('english', "+args.add(opts.Query)+")")
+	}
+
+	direction, comparison := "ASC", ">"
+	if opts.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if opts.After != nil {
+		if opts.After.Sort != opts.Sort || opts.After.Descending != opts.Descending {
+			return nil, "", errors.New("invalid cursor: sort does not match")
+		}
+		// Row comparison keeps the keyset stable when several tasks share a sort value
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s::%s, %s)",
+			column.expr, comparison, args.add(opts.After.Value), column.cast, args.add(opts.After.ID)))
+	}
+
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE ` + strings.Join(where, " AND ") +
+		fmt.Sprintf(" ORDER BY %s %s, id %s", column.expr, direction, direction)
+	if opts.Limit > 0 {
+		// Fetch one extra row to learn whether there is another page
+		query += " LIMIT " + args.add(opts.Limit+1)
+	}
+
+	tasks := []task.Task{}
+	if err := r.db.Select(&tasks, query, args...); err != nil {
+		return nil, "", fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	var next string
+	if opts.Limit > 0 && len(tasks) > opts.Limit {
+		tasks = tasks[:opts.Limit]
+		next = NewTaskCursor(tasks[len(tasks)-1], opts).Encode()
+	}
+
+	return tasks, next, nil
+}
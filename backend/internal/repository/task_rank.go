@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+	"tasker/internal/rank"
+	"tasker/internal/workflow"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// TaskMove is where MoveTask puts a task: a status column and a place in it
+type TaskMove struct {
+	Status   string
+	Category string
+	// AfterID and BeforeID are the cards the task lands between. With neither
+	// the task goes to the bottom of the column.
+	AfterID  string
+	BeforeID string
+	// Version must match the stored one when non-zero
+	Version int64
+	// Limit, when given, is the WIP limit the task's new column has to keep
+	Limit *WIPLimit
+}
+
+// WIPLimit asks a write to keep the column for Status on a board within Limit
+// cards. Board names the board like Workflow.ProjectID does.
+type WIPLimit struct {
+	Board  *int64
+	Status string
+	Limit  int
+}
+
+// WIPLimitError is returned when a write would take a column past its WIP
+// limit. Count leaves out the task a single write puts in the column; after
+// ApplyTaskChanges it is the count with every change made.
+type WIPLimitError struct {
+	WIPLimit
+	Count int
+}
+
+func (e *WIPLimitError) Error() string {
+	return fmt.Sprintf("wip limit: %d of %d tasks in %s", e.Count, e.Limit, e.Status)
+}
+
+// rankedTask is a card of a column in board order
+type rankedTask struct {
+	ID   string `db:"id"`
+	Rank string `db:"rank"`
+}
+
+// lockColumn returns the user's cards in status in rank order, except skipID,
+// and locks them until the transaction ends. Tasks in the trash or the archive
+// aren't cards. Every board's column with that
+// status is included; ranks only have to be ordered within each board, which
+// any order across boards satisfies.
+func lockColumn(tx *sqlx.Tx, userID int64, status string, skipID string) ([]rankedTask, error) {
+	column := []rankedTask{}
+	query := `SELECT id, rank FROM tasks WHERE user_id = $1 AND status = $2 AND id <> $3 AND deleted_at IS NULL AND archived_at IS NULL ORDER BY rank, id FOR UPDATE`
+	if err := tx.Select(&column, query, userID, status, skipID); err != nil {
+		return nil, fmt.Errorf("failed to get column: %w", err)
+	}
+	return column, nil
+}
+
+// checkWIPLimit fails with a WIPLimitError when the column of limit would hold
+// more cards than the limit once adding more join it. The task with key skipID
+// isn't counted. The column is locked first, so writers filling it take turns.
+func checkWIPLimit(tx *sqlx.Tx, userID int64, limit *WIPLimit, skipID string, adding int) error {
+	if limit == nil {
+		return nil
+	}
+	if _, err := lockColumn(tx, userID, limit.Status, skipID); err != nil {
+		return err
+	}
+
+	var count int
+	query := `SELECT COUNT(*) FROM tasks WHERE ` + boardTasks(limit.Board) + ` AND archived_at IS NULL AND status = $3 AND id <> $4`
+	if err := tx.Get(&count, query, userID, limit.Board, limit.Status, skipID); err != nil {
+		return fmt.Errorf("failed to count tasks: %w", err)
+	}
+	if count+adding > limit.Limit {
+		return &WIPLimitError{WIPLimit: *limit, Count: count}
+	}
+	return nil
+}
+
+// placeInColumn returns the rank for a task going to index pos of column. When
+// the gap there is used up the whole column is spread out again first. That
+// leaves versions alone: nothing the client sees on the board moves.
+func placeInColumn(tx *sqlx.Tx, userID int64, column []rankedTask, pos int) (string, error) {
+	var lower, upper string
+	if pos > 0 {
+		lower = column[pos-1].Rank
+	}
+	if pos < len(column) {
+		upper = column[pos].Rank
+	}
+	if key, ok := rank.Between(lower, upper); ok {
+		return key, nil
+	}
+
+	keys := rank.Spread(len(column) + 1)
+	ids := make([]string, len(column))
+	ranks := make([]string, len(column))
+	for i, t := range column {
+		ids[i], ranks[i] = t.ID, keys[i]
+		if i >= pos {
+			ranks[i] = keys[i+1]
+		}
+	}
+
+	query := `
+		UPDATE tasks SET rank = u.rank
+		FROM unnest($2::text[], $3::text[]) AS u(id, rank)
+		WHERE tasks.user_id = $1 AND tasks.id = u.id`
+	if _, err := tx.Exec(query, userID, pq.Array(ids), pq.Array(ranks)); err != nil {
+		return "", fmt.Errorf("failed to rebalance column: %w", err)
+	}
+
+	return keys[pos], nil
+}
+
+// topOfColumn returns a rank above every card the user has in status
+func topOfColumn(tx *sqlx.Tx, userID int64, status string) (string, error) {
+	var first string
+	query := `SELECT rank FROM tasks WHERE user_id = $1 AND status = $2 AND deleted_at IS NULL AND archived_at IS NULL ORDER BY rank, id LIMIT 1 FOR UPDATE`
+	if err := tx.Get(&first, query, userID, status); err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get column: %w", err)
+	}
+	if key, ok := rank.Between("", first); ok {
+		return key, nil
+	}
+
+	column, err := lockColumn(tx, userID, status, "")
+	if err != nil {
+		return "", err
+	}
+	return placeInColumn(tx, userID, column, 0)
+}
+
+// movePosition finds where in column a task placed between afterID and beforeID goes
+func movePosition(column []rankedTask, afterID, beforeID string) (int, error) {
+	at := func(id string) int {
+		return slices.IndexFunc(column, func(t rankedTask) bool { return t.ID == id })
+	}
+
+	pos := len(column)
+	if afterID != "" {
+		i := at(afterID)
+		if i < 0 {
+			return 0, fmt.Errorf("after task not in column: %s", afterID)
+		}
+		pos = i + 1
+	}
+	if beforeID != "" {
+		i := at(beforeID)
+		if i < 0 {
+			return 0, fmt.Errorf("before task not in column: %s", beforeID)
+		}
+		// Cards of other boards may sit between the two, so they needn't be
+		// adjacent here; the task goes right after afterID
+		if afterID == "" {
+			pos = i
+		} else if i < pos {
+			return 0, fmt.Errorf("move neighbours out of order: %s, %s", afterID, beforeID)
+		}
+	}
+	return pos, nil
+}
+
+func (r *TaskRepository) MoveTask(userID int64, id string, move TaskMove) (*task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockTaskGraph(tx, userID); err != nil {
+		return nil, err
+	}
+
+	var current struct {
+		ID      string `db:"id"`
+		Version int64  `db:"version"`
+	}
+	query := `SELECT id, version FROM tasks WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.Get(&current, query, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("task not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if move.Version != 0 && move.Version != current.Version {
+		return nil, fmt.Errorf("task version conflict: %s", id)
+	}
+
+	before, err := getTask(tx, userID, current.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBlockers(tx, userID, before, move.Category); err != nil {
+		return nil, err
+	}
+	if err := checkWIPLimit(tx, userID, move.Limit, current.ID, 1); err != nil {
+		return nil, err
+	}
+
+	// Neighbours may be given by an old key
+	for _, neighbour := range []*string{&move.AfterID, &move.BeforeID} {
+		if *neighbour == "" {
+			continue
+		}
+		if resolved, err := resolveTaskKey(tx, userID, *neighbour); err == nil {
+			*neighbour = resolved
+		}
+	}
+
+	column, err := lockColumn(tx, userID, move.Status, current.ID)
+	if err != nil {
+		return nil, err
+	}
+	pos, err := movePosition(column, move.AfterID, move.BeforeID)
+	if err != nil {
+		return nil, err
+	}
+	key, err := placeInColumn(tx, userID, column, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	// A card put somewhere on the board is no longer archived
+	query = `
+		UPDATE tasks
+		SET status = $1, status_category = $2, rank = $3, updated_at = $4, archived_at = NULL,
+		    ` + doneAtUpdate("$2", "$4") + `, version = version + 1
+		WHERE user_id = $5 AND id = $6`
+	if _, err := tx.Exec(query, move.Status, move.Category, key, time.Now(), userID, current.ID); err != nil {
+		return nil, fmt.Errorf("failed to move task: %w", err)
+	}
+
+	moved, err := getTask(tx, userID, current.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordDiff(tx, userID, history.ActionMove, before, moved); err != nil {
+		return nil, err
+	}
+
+	if before.StatusCategory != workflow.CategoryDone && moved.StatusCategory == workflow.CategoryDone {
+		if _, err := continueSeries(tx, userID, moved); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return moved, nil
+}
@@ -0,0 +1,398 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+	"tasker/internal/recurrence"
+	"tasker/internal/workflow"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const seriesColumns = `id, user_id, rule, after_completion, anchor, next_at, timezone`
+
+// taskSeries is a recurring task's schedule. Anchor is the first occurrence
+// the rule counts from, and Timezone the zone its days are counted in.
+type taskSeries struct {
+	ID              int64      `db:"id"`
+	UserID          int64      `db:"user_id"`
+	Rule            string     `db:"rule"`
+	AfterCompletion bool       `db:"after_completion"`
+	Anchor          time.Time  `db:"anchor"`
+	NextAt          *time.Time `db:"next_at"`
+	Timezone        string     `db:"timezone"`
+}
+
+// location returns the zone the series' rule is worked out in. A zone the
+// server doesn't know falls back to UTC.
+func (s taskSeries) location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func (r *TaskRepository) SetRecurrence(userID int64, id string, version int64, rule *recurrence.Rule, afterCompletion bool, loc *time.Location) (*task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current task.Task
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.Get(&current, query, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("task not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if version != 0 && version != current.Version {
+		return nil, fmt.Errorf("task version conflict: %s", id)
+	}
+
+	// The other occurrences show the series' rule too, so they change with it
+	befores := []task.Task{current}
+	if current.Recurrence != nil {
+		var others []task.Task
+		query := `SELECT ` + taskColumns + ` FROM tasks WHERE series_id = $1 AND id <> $2 ORDER BY id FOR UPDATE`
+		if err := tx.Select(&others, query, current.Recurrence.SeriesID, current.ID); err != nil {
+			return nil, fmt.Errorf("failed to get series: %w", err)
+		}
+		befores = append(befores, others...)
+	}
+
+	switch {
+	case rule != nil:
+		err = scheduleSeries(tx, userID, current, *rule, afterCompletion, loc)
+	case current.Recurrence != nil:
+		err = endSeries(tx, current.Recurrence.SeriesID)
+	default:
+		return &current, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var updated *task.Task
+	for _, before := range befores {
+		after, err := getTask(tx, userID, before.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordDiff(tx, userID, history.ActionRecurrence, &before, after); err != nil {
+			return nil, err
+		}
+		if before.ID == current.ID {
+			updated = after
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updated, nil
+}
+
+// scheduleSeries makes t, which the transaction has locked, recur by rule
+// counted from its due date in loc. A task already in a series changes that
+// series' rule; occurrences created before keep their dates.
+func scheduleSeries(tx *sqlx.Tx, userID int64, t task.Task, rule recurrence.Rule, afterCompletion bool, loc *time.Location) error {
+	from := time.Now().In(loc)
+	if t.DueAt != nil {
+		from = t.DueAt.In(loc)
+	} else if !afterCompletion {
+		return fmt.Errorf("task has no due date: %s", t.ID)
+	}
+
+	// Dates the series already has an occurrence for, skipped ones included,
+	// aren't scheduled again
+	latest := from
+	if t.Recurrence != nil {
+		var scheduled *time.Time
+		query := `SELECT MAX(occurs_at) FROM tasks WHERE series_id = $1 AND id <> $2`
+		if err := tx.Get(&scheduled, query, t.Recurrence.SeriesID, t.ID); err != nil {
+			return fmt.Errorf("failed to get series: %w", err)
+		}
+		if scheduled != nil && scheduled.After(latest) {
+			latest = *scheduled
+		}
+	}
+
+	next, ok := rule.Next(from, latest)
+	if !ok {
+		return fmt.Errorf("rule never recurs: %s", rule)
+	}
+	// The series' columns hold UTC; the zone is kept alongside
+	next = next.UTC()
+	nextAt := &next
+	if afterCompletion {
+		nextAt = nil
+	}
+	var occursAt *time.Time
+	if t.DueAt != nil {
+		due := t.DueAt.UTC()
+		occursAt = &due
+	}
+
+	var seriesID int64
+	if t.Recurrence == nil {
+		query := `INSERT INTO task_series (user_id, rule, after_completion, anchor, next_at, timezone) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+		if err := tx.QueryRowx(query, userID, rule.String(), afterCompletion, from.UTC(), nextAt, loc.String()).Scan(&seriesID); err != nil {
+			return fmt.Errorf("failed to create series: %w", err)
+		}
+	} else {
+		seriesID = t.Recurrence.SeriesID
+		query := `UPDATE task_series SET rule = $1, after_completion = $2, anchor = $3, next_at = $4, timezone = $5, updated_at = NOW() WHERE id = $6`
+		if _, err := tx.Exec(query, rule.String(), afterCompletion, from.UTC(), nextAt, loc.String(), seriesID); err != nil {
+			return fmt.Errorf("failed to update series: %w", err)
+		}
+		// The other occurrences show the new rule too
+		query = `UPDATE tasks SET version = version + 1 WHERE series_id = $1 AND id <> $2`
+		if _, err := tx.Exec(query, seriesID, t.ID); err != nil {
+			return fmt.Errorf("failed to update series: %w", err)
+		}
+	}
+
+	query := `UPDATE tasks SET series_id = $1, occurs_at = $2, version = version + 1 WHERE user_id = $3 AND id = $4`
+	if _, err := tx.Exec(query, seriesID, occursAt, userID, t.ID); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	return nil
+}
+
+// endSeries deletes a series, leaving its occurrences as plain tasks
+func endSeries(tx *sqlx.Tx, seriesID int64) error {
+	query := `UPDATE tasks SET series_id = NULL, occurs_at = NULL, version = version + 1 WHERE series_id = $1`
+	if _, err := tx.Exec(query, seriesID); err != nil {
+		return fmt.Errorf("failed to update series: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM task_series WHERE id = $1`, seriesID); err != nil {
+		return fmt.Errorf("failed to delete series: %w", err)
+	}
+	return nil
+}
+
+// continueSeries returns the occurrence that follows t, which the transaction
+// has just moved to done or is skipping: a later one that is still open, or
+// else a new one. It returns nil when t isn't recurring or the series has no
+// more occurrences.
+func continueSeries(tx *sqlx.Tx, userID int64, t *task.Task) (*task.Task, error) {
+	if t.Recurrence == nil {
+		return nil, nil
+	}
+
+	// A series locked elsewhere is being continued already, by the job
+	// creating its due occurrence or for another of its occurrences
+	var s taskSeries
+	query := `SELECT ` + seriesColumns + ` FROM task_series WHERE id = $1 FOR UPDATE SKIP LOCKED`
+	if err := tx.Get(&s, query, t.Recurrence.SeriesID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get series: %w", err)
+	}
+
+	// Finishing an earlier occurrence late leaves the series as it is
+	var later task.Task
+	query = `
+		SELECT ` + taskColumns + ` FROM tasks
+		WHERE series_id = $1 AND id <> $2 AND deleted_at IS NULL AND status_category <> 'done'
+			AND COALESCE(occurs_at, '-infinity') >= COALESCE($3::timestamp, '-infinity')
+		ORDER BY occurs_at DESC NULLS LAST, id DESC
+		LIMIT 1`
+	err := tx.Get(&later, query, s.ID, t.ID, t.Recurrence.OccursAt)
+	if err == nil {
+		return &later, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get series: %w", err)
+	}
+
+	rule, err := recurrence.Parse(s.Rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse series rule: %w", err)
+	}
+
+	loc := s.location()
+	var at time.Time
+	if s.AfterCompletion {
+		next, ok := rule.After(time.Now().In(loc), t.DueAt)
+		if !ok {
+			return nil, nil
+		}
+		at = next
+	} else {
+		if s.NextAt == nil {
+			return nil, nil
+		}
+		at = *s.NextAt
+		next, ok := rule.Next(s.Anchor.In(loc), at)
+		if err := advanceSeries(tx, s.ID, next, ok); err != nil {
+			return nil, err
+		}
+	}
+
+	return spawnOccurrence(tx, userID, *t, s.ID, at)
+}
+
+// advanceSeries sets when a scheduled series' next occurrence comes due; with
+// ok false it has none
+func advanceSeries(tx *sqlx.Tx, seriesID int64, next time.Time, ok bool) error {
+	var nextAt *time.Time
+	if ok {
+		next = next.UTC()
+		nextAt = &next
+	}
+	if _, err := tx.Exec(`UPDATE task_series SET next_at = $1, updated_at = NOW() WHERE id = $2`, nextAt, seriesID); err != nil {
+		return fmt.Errorf("failed to update series: %w", err)
+	}
+	return nil
+}
+
+// spawnOccurrence creates the occurrence of a series due at, copying from the
+// one before. It starts in the first column of its board with a fresh key
+// under the same prefix, and its start date keeps its lead on the due date.
+func spawnOccurrence(tx *sqlx.Tx, userID int64, from task.Task, seriesID int64, at time.Time) (*task.Task, error) {
+	wf, err := getWorkflow(tx, userID, from.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	status := wf.InitialStatus()
+
+	t := task.Task{
+		Title:          from.Title,
+		Description:    from.Description,
+		Status:         status,
+		StatusCategory: wf.Category(status),
+		Priority:       from.Priority,
+		ProjectID:      from.ProjectID,
+		DueAt:          &at,
+		EstimateHours:  from.EstimateHours,
+		ParentID:       from.ParentID,
+		Tags:           from.Tags,
+		Recurrence:     &task.Recurrence{SeriesID: seriesID, OccursAt: &at},
+	}
+	if from.StartAt != nil && from.DueAt != nil {
+		start := at.Add(-from.DueAt.Sub(*from.StartAt))
+		t.StartAt = &start
+	}
+
+	return insertTask(tx, userID, t, task.KeyPrefix(from.ID))
+}
+
+func (r *TaskRepository) SkipOccurrence(userID int64, id string) (*task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current task.Task
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.Get(&current, query, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("task not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if current.Recurrence == nil {
+		return nil, fmt.Errorf("task not recurring: %s", id)
+	}
+	if current.StatusCategory == workflow.CategoryDone {
+		return nil, fmt.Errorf("task already done: %s", id)
+	}
+
+	next, err := continueSeries(tx, userID, &current)
+	if err != nil {
+		return nil, err
+	}
+
+	// The skipped occurrence stays in the series from the trash, so its date
+	// isn't scheduled again
+	subtree, err := deletableSubtree(tx, userID, current.ID, 0, TaskDeleteCascade)
+	if err != nil {
+		return nil, err
+	}
+	if err := trashTasks(tx, userID, subtree); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return next, nil
+}
+
+func (r *TaskRepository) CreateDueOccurrences() (int64, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Series someone is continuing right now are left for the next run
+	now := time.Now()
+	var due []taskSeries
+	query := `SELECT ` + seriesColumns + ` FROM task_series WHERE next_at <= $1 ORDER BY id FOR UPDATE SKIP LOCKED`
+	if err := tx.Select(&due, query, now); err != nil {
+		return 0, fmt.Errorf("failed to get due series: %w", err)
+	}
+
+	var created int64
+	for _, s := range due {
+		rule, err := recurrence.Parse(s.Rule)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse series rule: %w", err)
+		}
+
+		// Of the occurrences that came due since the last run, only the
+		// latest is created
+		anchor := s.Anchor.In(s.location())
+		at := *s.NextAt
+		next, ok := rule.Next(anchor, at)
+		for ok && !next.After(now) {
+			at = next
+			next, ok = rule.Next(anchor, at)
+		}
+
+		// The latest occurrence is copied; a series whose occurrences have
+		// all been deleted has ended
+		var latest task.Task
+		query := `
+			SELECT ` + taskColumns + ` FROM tasks
+			WHERE series_id = $1 AND deleted_at IS NULL
+			ORDER BY occurs_at DESC NULLS LAST, created_at DESC
+			LIMIT 1`
+		if err := tx.Get(&latest, query, s.ID); err != nil {
+			if err != sql.ErrNoRows {
+				return 0, fmt.Errorf("failed to get series: %w", err)
+			}
+			if _, err := tx.Exec(`DELETE FROM task_series WHERE id = $1`, s.ID); err != nil {
+				return 0, fmt.Errorf("failed to delete series: %w", err)
+			}
+			continue
+		}
+
+		if _, err := spawnOccurrence(tx, s.UserID, latest, s.ID, at); err != nil {
+			return 0, err
+		}
+		if err := advanceSeries(tx, s.ID, next, ok); err != nil {
+			return 0, err
+		}
+		created++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func (r *TaskRepository) RestoreTask(userID int64, t task.Task, action string, limit *WIPLimit) (*task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	restored, err := restoreTask(tx, userID, t, action, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return restored, nil
+}
+
+// restoreTask writes t's fields, rank included, over the task with key t.ID,
+// taking it out of the trash if it is there
+func restoreTask(tx *sqlx.Tx, userID int64, t task.Task, action string, limit *WIPLimit) (*task.Task, error) {
+	t.UpdatedAt = time.Now()
+
+	if err := lockTaskGraph(tx, userID); err != nil {
+		return nil, err
+	}
+
+	var before task.Task
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND id = $2 FOR UPDATE`
+	if err := tx.Get(&before, query, userID, t.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("task not found: %s", t.ID)
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if t.Version != 0 && t.Version != before.Version {
+		return nil, fmt.Errorf("task version conflict: %s", t.ID)
+	}
+	// Undo and the trash put back what was there; a revert is a new change
+	if action == history.ActionRevert {
+		if err := checkBlockers(tx, userID, &before, t.StatusCategory); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkWIPLimit(tx, userID, limit, t.ID, 1); err != nil {
+		return nil, err
+	}
+
+	// The tree may have changed around a task in the trash or an old version
+	if t.ParentID != nil && (before.DeletedAt != nil || before.ParentID == nil || *before.ParentID != *t.ParentID) {
+		parentID, err := checkParent(tx, userID, t.ID, *t.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		t.ParentID = &parentID
+	}
+
+	query = `
+		UPDATE tasks
+		SET title = $1,
+		    description = $2,
+		    status = $3,
+		    status_category = $4,
+		    rank = COALESCE(NULLIF($5, ''), rank),
+		    priority = $6,
+		    project_id = $7,
+		    start_at = $8,
+		    due_at = $9,
+		    estimate_hours = $10,
+		    parent_id = $11,
+		    updated_at = $12,
+		    deleted_at = NULL,
+		    archived_at = CASE WHEN $4 = 'done' THEN archived_at END,
+		    ` + doneAtUpdate("$4", "$12") + `,
+		    version = version + 1
+		WHERE user_id = $13 AND id = $14`
+	_, err := tx.Exec(
+		query,
+		t.Title, t.Description, t.Status, t.StatusCategory, t.Rank, t.Priority, t.ProjectID, t.StartAt, t.DueAt, t.EstimateHours, t.ParentID, t.UpdatedAt, userID, t.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore task: %w", err)
+	}
+
+	if err := setTaskTags(tx, userID, t.ID, t.Tags); err != nil {
+		return nil, err
+	}
+
+	restored, err := getTask(tx, userID, t.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Coming back from the trash reads like a create in the history
+	from := &before
+	if before.DeletedAt != nil {
+		from = nil
+	}
+	if err := recordDiff(tx, userID, action, from, restored); err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+func (r *TaskRepository) GetUndoableMutation(userID int64, window time.Duration) ([]history.Event, error) {
+	query := `
+		SELECT ` + taskEventColumns + ` FROM task_events
+		WHERE user_id = $1 AND mutation_id = (
+			SELECT mutation_id FROM task_events
+			WHERE user_id = $1 AND actor_id = $1 AND NOT undone AND action = ANY($2)
+				AND created_at > NOW() - make_interval(secs => $3)
+			ORDER BY id DESC
+			LIMIT 1
+		)
+		ORDER BY id`
+
+	undoable := []string{history.ActionUpdate, history.ActionMove, history.ActionDelete, history.ActionRevert}
+	var events []history.Event
+	if err := r.db.Select(&events, query, userID, pq.Array(undoable), window.Seconds()); err != nil {
+		return nil, fmt.Errorf("failed to get last change: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+
+	return events, nil
+}
+
+func (r *TaskRepository) UndoMutation(userID int64, mutationID int64, tasks []task.Task) ([]task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Marking the events first means a second undo racing this one finds
+	// nothing left to do
+	query := `UPDATE task_events SET undone = TRUE WHERE user_id = $1 AND mutation_id = $2 AND NOT undone`
+	res, err := tx.Exec(query, userID, mutationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to undo: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+
+	restored := []task.Task{}
+	for _, t := range parentsFirst(tasks) {
+		undone, err := restoreTask(tx, userID, t, history.ActionUndo, nil)
+		if err != nil {
+			return nil, err
+		}
+		restored = append(restored, *undone)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return restored, nil
+}
+
+// parentsFirst orders tasks so each comes after its parent when both are in
+// the list, which a cascade of deleted subtasks needs to be put back
+func parentsFirst(tasks []task.Task) []task.Task {
+	pending := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		pending[t.ID] = true
+	}
+
+	ordered := make([]task.Task, 0, len(tasks))
+	for len(ordered) < len(tasks) {
+		progress := false
+		for _, t := range tasks {
+			if pending[t.ID] && (t.ParentID == nil || !pending[*t.ParentID]) {
+				ordered = append(ordered, t)
+				delete(pending, t.ID)
+				progress = true
+			}
+		}
+		// Parents pointing at each other can't come from real history, but
+		// shouldn't loop forever either
+		if !progress {
+			for _, t := range tasks {
+				if pending[t.ID] {
+					ordered = append(ordered, t)
+					delete(pending, t.ID)
+				}
+			}
+		}
+	}
+	return ordered
+}
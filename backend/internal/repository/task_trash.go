@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+
+	"github.com/lib/pq"
+)
+
+func (r *TaskRepository) GetTrash(userID int64) ([]task.Task, error) {
+	tasks := []task.Task{}
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id`
+	if err := r.db.Select(&tasks, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+	return tasks, nil
+}
+
+func (r *TaskRepository) GetTrashedSubtree(userID int64, id string) ([]task.Task, error) {
+	// A cascade trashes a whole subtree in one transaction, so its subtasks
+	// share the task's deleted_at. Ones trashed on their own before stay put.
+	query := `
+		WITH RECURSIVE target AS (
+			SELECT id, deleted_at FROM tasks
+			WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NOT NULL
+		), subtree(id, depth) AS (
+			SELECT id, 1 FROM target
+			UNION ALL
+			SELECT t.id, s.depth + 1 FROM tasks t JOIN subtree s ON t.parent_id = s.id
+			WHERE t.user_id = $1 AND t.deleted_at = (SELECT deleted_at FROM target) AND s.depth < $3
+		)
+		SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND id IN (SELECT id FROM subtree)
+		ORDER BY (SELECT depth FROM subtree s WHERE s.id = tasks.id), id`
+
+	tasks := []task.Task{}
+	if err := r.db.Select(&tasks, query, userID, id, taskTreeWalkLimit); err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("task not found: %s", id)
+	}
+	return tasks, nil
+}
+
+func (r *TaskRepository) RestoreFromTrash(userID int64, tasks []task.Task) ([]task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+
+	if err := lockTaskGraph(tx, userID); err != nil {
+		return nil, err
+	}
+
+	// Only tasks still in the trash; a restore racing this one leaves nothing
+	var trashed []string
+	query := `SELECT id FROM tasks WHERE user_id = $1 AND id = ANY($2) AND deleted_at IS NOT NULL FOR UPDATE`
+	if err := tx.Select(&trashed, query, userID, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+	if len(trashed) < len(ids) {
+		return nil, fmt.Errorf("task not found: %s", ids[0])
+	}
+
+	restored := []task.Task{}
+	for _, t := range parentsFirst(tasks) {
+		t.Version = 0
+		back, err := restoreTask(tx, userID, t, history.ActionRestore, nil)
+		if err != nil {
+			return nil, err
+		}
+		restored = append(restored, *back)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return restored, nil
+}
+
+func (r *TaskRepository) PurgeTask(userID int64, id string) error {
+	// Subtasks of a task in the trash are in the trash as well
+	query := `
+		WITH RECURSIVE subtree(id, depth) AS (
+			SELECT id, 1 FROM tasks
+			WHERE user_id = $1 AND ` + taskKeyMatch("$1", "$2") + ` AND deleted_at IS NOT NULL
+			UNION ALL
+			SELECT t.id, s.depth + 1 FROM tasks t JOIN subtree s ON t.parent_id = s.id
+			WHERE t.user_id = $1 AND t.deleted_at IS NOT NULL AND s.depth < $3
+		), purged AS (
+			DELETE FROM tasks WHERE user_id = $1 AND id IN (SELECT id FROM subtree) RETURNING id
+		), forgotten AS (
+			DELETE FROM task_events WHERE user_id = $1 AND task_id IN (SELECT id FROM purged)
+		)
+		SELECT COUNT(*) FROM purged`
+
+	var purged int
+	if err := r.db.Get(&purged, query, userID, id, taskTreeWalkLimit); err != nil {
+		return fmt.Errorf("failed to purge task: %w", err)
+	}
+	if purged == 0 {
+		return fmt.Errorf("task not found: %s", id)
+	}
+	return nil
+}
+
+func (r *TaskRepository) PurgeTrash(retention time.Duration) (int64, error) {
+	query := `
+		WITH purged AS (
+			DELETE FROM tasks WHERE deleted_at < NOW() - make_interval(secs => $1) RETURNING user_id, id
+		), forgotten AS (
+			DELETE FROM task_events e USING purged p WHERE e.user_id = p.user_id AND e.task_id = p.id
+		)
+		SELECT COUNT(*) FROM purged`
+
+	var purged int64
+	if err := r.db.Get(&purged, query, retention.Seconds()); err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"tasker/internal/template"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const templateColumns = `id, user_id, name, title, description, checklist, priority, status, created_at, updated_at`
+
+// TemplateRepositoryInterface defines the contract for task template data operations.
+// Every method is scoped to the templates owned by userID.
+type TemplateRepositoryInterface interface {
+	GetAllTemplates(userID int64) ([]template.Template, error)
+	GetTemplateByID(userID int64, id int64) (*template.Template, error)
+	CreateTemplate(userID int64, t template.Template) (*template.Template, error)
+	// UpdateTemplate replaces every field of a template; tasks already made from it stay as they are
+	UpdateTemplate(userID int64, id int64, t template.Template) (*template.Template, error)
+	DeleteTemplate(userID int64, id int64) error
+}
+
+type TemplateRepository struct {
+	db *sqlx.DB
+}
+
+var Templates TemplateRepositoryInterface
+
+func NewTemplateRepository(db *sqlx.DB) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+func (r *TemplateRepository) GetAllTemplates(userID int64) ([]template.Template, error) {
+	templates := []template.Template{}
+	query := `SELECT ` + templateColumns + ` FROM task_templates WHERE user_id = $1 ORDER BY LOWER(name)`
+
+	if err := r.db.Select(&templates, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+func (r *TemplateRepository) GetTemplateByID(userID int64, id int64) (*template.Template, error) {
+	var t template.Template
+	query := `SELECT ` + templateColumns + ` FROM task_templates WHERE user_id = $1 AND id = $2`
+
+	if err := r.db.Get(&t, query, userID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("template not found: %d", id)
+		}
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	return &t, nil
+}
+
+func (r *TemplateRepository) CreateTemplate(userID int64, t template.Template) (*template.Template, error) {
+	now := time.Now()
+
+	query := `
+		INSERT INTO task_templates (user_id, name, title, description, checklist, priority, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + templateColumns
+
+	var created template.Template
+	err := r.db.QueryRowx(query, userID, t.Name, t.Title, t.Description, t.Checklist, t.Priority, t.Status, now, now).StructScan(&created)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("template already exists: %s", t.Name)
+		}
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *TemplateRepository) UpdateTemplate(userID int64, id int64, t template.Template) (*template.Template, error) {
+	query := `
+		UPDATE task_templates
+		SET name = $1, title = $2, description = $3, checklist = $4, priority = $5, status = $6, updated_at = $7
+		WHERE user_id = $8 AND id = $9
+		RETURNING ` + templateColumns
+
+	var updated template.Template
+	err := r.db.QueryRowx(query, t.Name, t.Title, t.Description, t.Checklist, t.Priority, t.Status, time.Now(), userID, id).StructScan(&updated)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("template not found: %d", id)
+		}
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("template already exists: %s", t.Name)
+		}
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+
+	return &updated, nil
+}
+
+func (r *TemplateRepository) DeleteTemplate(userID int64, id int64) error {
+	result, err := r.db.Exec(`DELETE FROM task_templates WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("template not found: %d", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"tasker/internal/user"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const userColumns = `id, email, password_hash, name, timezone, created_at, updated_at`
+
+// UserRepositoryInterface defines the contract for user accounts and their sessions
+type UserRepositoryInterface interface {
+	// CreateUser registers a user. The very first user also takes ownership of
+	// any tasks and projects created before accounts existed.
+	CreateUser(u user.User) (*user.User, error)
+	GetUserByID(id int64) (*user.User, error)
+	GetUserByEmail(email string) (*user.User, error)
+	// UpdateUser saves the user's profile settings (name and time zone)
+	UpdateUser(u user.User) (*user.User, error)
+	RevokeToken(jti string, expiresAt time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+}
+
+type UserRepository struct {
+	db *sqlx.DB
+}
+
+var Users UserRepositoryInterface
+
+func NewUserRepository(db *sqlx.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) CreateUser(u user.User) (*user.User, error) {
+	now := time.Now()
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Serialise registrations so exactly one user can be "first"
+	if _, err := tx.Exec(`LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return nil, fmt.Errorf("failed to lock users: %w", err)
+	}
+
+	var taken bool
+	err = tx.Get(&taken, `SELECT true FROM users WHERE LOWER(email) = LOWER($1)`, u.Email)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+	if taken {
+		return nil, fmt.Errorf("email already registered: %s", u.Email)
+	}
+
+	var userCount int
+	if err := tx.Get(&userCount, `SELECT COUNT(*) FROM users`); err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := `
+		INSERT INTO users (email, password_hash, name, timezone, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + userColumns
+
+	if u.Timezone == "" {
+		u.Timezone = "UTC"
+	}
+
+	var created user.User
+	if err := tx.QueryRowx(query, strings.TrimSpace(u.Email), u.PasswordHash, u.Name, u.Timezone, now, now).StructScan(&created); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if userCount == 0 {
+		if _, err := tx.Exec(`UPDATE projects SET user_id = $1 WHERE user_id IS NULL`, created.ID); err != nil {
+			return nil, fmt.Errorf("failed to claim existing projects: %w", err)
+		}
+		if _, err := tx.Exec(`UPDATE tasks SET user_id = $1 WHERE user_id IS NULL`, created.ID); err != nil {
+			return nil, fmt.Errorf("failed to claim existing tasks: %w", err)
+		}
+		if _, err := tx.Exec(`UPDATE task_key_aliases SET user_id = $1 WHERE user_id IS NULL`, created.ID); err != nil {
+			return nil, fmt.Errorf("failed to claim existing task keys: %w", err)
+		}
+		// Carry on numbering after the keys that came with the tasks
+		query = `
+			INSERT INTO task_key_sequences (user_id, prefix, next_value)
+			SELECT $1, SUBSTRING(key FROM '^(.+)-[0-9]+$'), MAX(CAST(SUBSTRING(key FROM '-([0-9]+)$') AS INTEGER)) + 1
+			FROM (SELECT id AS key FROM tasks WHERE user_id = $1 UNION ALL SELECT alias FROM task_key_aliases WHERE user_id = $1) AS used
+			WHERE key ~ '^.+-[0-9]+$'
+			GROUP BY 2`
+		if _, err := tx.Exec(query, created.ID); err != nil {
+			return nil, fmt.Errorf("failed to claim existing task keys: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *UserRepository) GetUserByID(id int64) (*user.User, error) {
+	var u user.User
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+	if err := r.db.Get(&u, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found: %d", id)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &u, nil
+}
+
+func (r *UserRepository) GetUserByEmail(email string) (*user.User, error) {
+	var u user.User
+	query := `SELECT ` + userColumns + ` FROM users WHERE LOWER(email) = LOWER($1)`
+
+	if err := r.db.Get(&u, query, strings.TrimSpace(email)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found: %s", email)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &u, nil
+}
+
+func (r *UserRepository) UpdateUser(u user.User) (*user.User, error) {
+	query := `
+		UPDATE users SET name = $1, timezone = $2, updated_at = $3
+		WHERE id = $4
+		RETURNING ` + userColumns
+
+	var updated user.User
+	if err := r.db.QueryRowx(query, u.Name, u.Timezone, time.Now(), u.ID).StructScan(&updated); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user not found: %d", u.ID)
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return &updated, nil
+}
+
+func (r *UserRepository) RevokeToken(jti string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	if _, err := r.db.Exec(query, jti, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	// Expired tokens are rejected on their own, so their revocations can go
+	if _, err := r.db.Exec(`DELETE FROM revoked_tokens WHERE expires_at < NOW()`); err != nil {
+		return fmt.Errorf("failed to prune revoked tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) IsTokenRevoked(jti string) (bool, error) {
+	var revoked bool
+	err := r.db.Get(&revoked, `SELECT true FROM revoked_tokens WHERE jti = $1`, jti)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check token: %w", err)
+	}
+	return revoked, nil
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	task "tasker/internal/Task"
+	"tasker/internal/history"
+	"tasker/internal/workflow"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const workflowColumns = `id, user_id, project_id, columns, transitions, created_at, updated_at`
+
+// StatusInUseError is returned when a workflow change would drop statuses that
+// tasks on the board are still in
+type StatusInUseError struct {
+	Statuses []string
+}
+
+func (e *StatusInUseError) Error() string {
+	return "workflow status in use: " + strings.Join(e.Statuses, ", ")
+}
+
+// WorkflowRepositoryInterface defines the contract for board workflows.
+// Every method is scoped to the workflows owned by userID.
+type WorkflowRepositoryInterface interface {
+	// GetWorkflow returns the workflow of a project's board, falling back to the
+	// user's default board and then to workflow.Default(). A nil projectID asks
+	// for the default board.
+	GetWorkflow(userID int64, projectID *int64) (*workflow.Workflow, error)
+	// SaveWorkflow replaces the workflow of the board named by w.ProjectID. Tasks
+	// whose status changes category follow along; dropping a status that tasks
+	// are still in fails with a StatusInUseError.
+	SaveWorkflow(userID int64, w workflow.Workflow) (*workflow.Workflow, error)
+	// CountTasksByStatus counts the tasks on a board by status. projectID names
+	// the board the way Workflow.ProjectID does, nil being the default board.
+	CountTasksByStatus(userID int64, projectID *int64) (map[string]int, error)
+	// GetBoardTasks returns the tasks on a board in rank order
+	GetBoardTasks(userID int64, projectID *int64) ([]task.Task, error)
+}
+
+type WorkflowRepository struct {
+	db *sqlx.DB
+}
+
+var Workflows WorkflowRepositoryInterface
+
+func NewWorkflowRepository(db *sqlx.DB) *WorkflowRepository {
+	return &WorkflowRepository{db: db}
+}
+
+func (r *WorkflowRepository) GetWorkflow(userID int64, projectID *int64) (*workflow.Workflow, error) {
+	return getWorkflow(r.db, userID, projectID)
+}
+
+// getWorkflow reads the workflow of a project's board through q, falling back
+// like GetWorkflow does
+func getWorkflow(q sqlx.Queryer, userID int64, projectID *int64) (*workflow.Workflow, error) {
+	var w workflow.Workflow
+	query := `
+		SELECT ` + workflowColumns + ` FROM workflows
+		WHERE user_id = $1 AND (project_id = $2 OR project_id IS NULL)
+		ORDER BY project_id NULLS LAST
+		LIMIT 1`
+
+	if err := sqlx.Get(q, &w, query, userID, projectID); err != nil {
+		if err == sql.ErrNoRows {
+			def := workflow.Default()
+			return &def, nil
+		}
+		return nil, fmt.Errorf("failed to get workflow: %w", err)
+	}
+
+	return &w, nil
+}
+
+// boardTasks is the condition matching the tasks on the board of projectID ($2).
+// The default board holds tasks without a project and tasks in projects that
+// don't have a workflow of their own. Tasks in the trash are on no board;
+// archived ones still belong to theirs but aren't shown or counted on it.
+func boardTasks(projectID *int64) string {
+	if projectID != nil {
+		return `user_id = $1 AND deleted_at IS NULL AND project_id = $2`
+	}
+	return `user_id = $1 AND deleted_at IS NULL AND $2::INTEGER IS NULL AND (project_id IS NULL OR project_id NOT IN (
+		SELECT project_id FROM workflows WHERE user_id = $1 AND project_id IS NOT NULL))`
+}
+
+func (r *WorkflowRepository) SaveWorkflow(userID int64, w workflow.Workflow) (*workflow.Workflow, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var saved workflow.Workflow
+	query := `
+		INSERT INTO workflows (user_id, project_id, columns, transitions, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (user_id, COALESCE(project_id, 0)) DO UPDATE
+		SET columns = EXCLUDED.columns, transitions = EXCLUDED.transitions, updated_at = EXCLUDED.updated_at
+		RETURNING ` + workflowColumns
+	if err := tx.QueryRowx(query, userID, w.ProjectID, w.Columns, w.Transitions, now).StructScan(&saved); err != nil {
+		return nil, fmt.Errorf("failed to save workflow: %w", err)
+	}
+
+	var stranded []string
+	query = `SELECT DISTINCT status FROM tasks WHERE ` + boardTasks(w.ProjectID) + ` AND status <> ALL($3) ORDER BY status`
+	if err := tx.Select(&stranded, query, userID, w.ProjectID, pq.Array(w.Statuses())); err != nil {
+		return nil, fmt.Errorf("failed to check task statuses: %w", err)
+	}
+	if len(stranded) > 0 {
+		return nil, &StatusInUseError{Statuses: stranded}
+	}
+
+	columns, err := json.Marshal(w.Columns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode columns: %w", err)
+	}
+	var recategorized []struct {
+		ID      string `db:"id"`
+		Version int64  `db:"version"`
+		Before  string `db:"before"`
+		After   string `db:"after"`
+	}
+	query = `
+		SELECT tasks.id, tasks.version, tasks.status_category AS before, c.category AS after
+		FROM tasks, jsonb_to_recordset($3::jsonb) AS c(name TEXT, category TEXT)
+		WHERE ` + boardTasks(w.ProjectID) + ` AND tasks.status = c.name AND tasks.status_category <> c.category
+		ORDER BY tasks.id
+		FOR UPDATE OF tasks`
+	if err := tx.Select(&recategorized, query, userID, w.ProjectID, columns); err != nil {
+		return nil, fmt.Errorf("failed to get task categories: %w", err)
+	}
+
+	query = `
+		UPDATE tasks SET status_category = c.category, done_at = CASE WHEN c.category = 'done' THEN NOW() END, version = version + 1
+		FROM jsonb_to_recordset($3::jsonb) AS c(name TEXT, category TEXT)
+		WHERE ` + boardTasks(w.ProjectID) + ` AND tasks.status = c.name AND tasks.status_category <> c.category`
+	if _, err := tx.Exec(query, userID, w.ProjectID, columns); err != nil {
+		return nil, fmt.Errorf("failed to update task categories: %w", err)
+	}
+
+	// The category isn't a field history keeps, so the event is written here
+	for _, t := range recategorized {
+		before, _ := json.Marshal(t.Before)
+		after, _ := json.Marshal(t.After)
+		changes := history.Changes{"status_category": {Before: before, After: after}}
+		if err := recordEvent(tx, userID, t.ID, history.ActionWorkflow, changes, t.Version+1); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &saved, nil
+}
+
+func (r *WorkflowRepository) CountTasksByStatus(userID int64, projectID *int64) (map[string]int, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}
+	query := `SELECT status, COUNT(*) AS count FROM tasks WHERE ` + boardTasks(projectID) + ` AND archived_at IS NULL GROUP BY status`
+	if err := r.db.Select(&rows, query, userID, projectID); err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *WorkflowRepository) GetBoardTasks(userID int64, projectID *int64) ([]task.Task, error) {
+	tasks := []task.Task{}
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE ` + boardTasks(projectID) + ` AND archived_at IS NULL ORDER BY rank, id`
+	if err := r.db.Select(&tasks, query, userID, projectID); err != nil {
+		return nil, fmt.Errorf("failed to get board tasks: %w", err)
+	}
+	return tasks, nil
+}
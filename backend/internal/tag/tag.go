@@ -0,0 +1,15 @@
+package tag
+
+import "time"
+
+// Tag labels tasks across projects, e.g. "errand" or "deep-work".
+// Names are unique per user, ignoring case.
+type Tag struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int64     `json:"-" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	ColorHex  string    `json:"color_hex" db:"color_hex"`
+	TaskCount int       `json:"task_count" db:"task_count"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,122 @@
+// Package template holds task templates: a title, description and Markdown
+// checklist with {{placeholders}} that are filled in each time a task is made
+// from the template.
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Built-in placeholders, filled in from the time the task is made
+const (
+	// Date is the day as 2006-01-02
+	Date = "date"
+	// Week is the ISO week as 2006-W01
+	Week = "week"
+)
+
+// placeholderPattern matches {{name}}, allowing spaces inside the braces
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// checklistItemPattern matches a Markdown task list item such as "- [ ] Passport"
+var checklistItemPattern = regexp.MustCompile(`^\s*[-*+] \[[ xX]\] \S`)
+
+// checkedBoxPattern matches the ticked box of a checklist item
+var checkedBoxPattern = regexp.MustCompile(`^(\s*[-*+] )\[[xX]\]`)
+
+// Template is the blueprint for a task that is recreated by hand again and
+// again, such as a weekly review. Names are unique per user, ignoring case.
+type Template struct {
+	ID          int64  `json:"id" db:"id"`
+	UserID      int64  `json:"-" db:"user_id"`
+	Name        string `json:"name" db:"name"`
+	Title       string `json:"title" db:"title"`
+	Description string `json:"description" db:"description"`
+	// Checklist is a Markdown task list, one "- [ ] item" per line
+	Checklist string `json:"checklist" db:"checklist"`
+	// Priority and Status are the new task's defaults; empty leaves them to
+	// the usual task defaults
+	Priority  string    `json:"priority" db:"priority"`
+	Status    string    `json:"status" db:"status"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Variables lists the custom placeholders used in the template, in the order
+// they first appear. Built-in placeholders are left out.
+func (t Template) Variables() []string {
+	var names []string
+	for _, text := range []string{t.Title, t.Description, t.Checklist} {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			name := match[1]
+			if name != Date && name != Week && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// ValidateChecklist returns a problem with a checklist, or "" when every
+// non-blank line is a task list item
+func ValidateChecklist(checklist string) string {
+	for i, line := range strings.Split(checklist, "\n") {
+		if strings.TrimSpace(line) != "" && !checklistItemPattern.MatchString(line) {
+			return fmt.Sprintf("line %d is not a checklist item such as \"- [ ] item\"", i+1)
+		}
+	}
+	return ""
+}
+
+// Render fills in the placeholders of text. vars holds the custom values;
+// {{date}} and {{week}} come from now unless vars gives them too. Placeholders
+// without a value are left as they are, so check Missing first.
+func Render(text string, vars map[string]string, now time.Time) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		switch name {
+		case Date:
+			return now.Format("2006-01-02")
+		case Week:
+			year, week := now.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}
+		return placeholder
+	})
+}
+
+// Missing lists the template's custom placeholders that vars has no value for
+func (t Template) Missing(vars map[string]string) []string {
+	var missing []string
+	for _, name := range t.Variables() {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// RenderDescription renders the description the task starts with: the template's
+// description followed by its checklist, with every box unticked
+func (t Template) RenderDescription(vars map[string]string, now time.Time) string {
+	lines := strings.Split(strings.TrimSpace(t.Checklist), "\n")
+	for i, line := range lines {
+		lines[i] = checkedBoxPattern.ReplaceAllString(line, "$1[ ]")
+	}
+
+	parts := []string{}
+	if description := strings.TrimSpace(t.Description); description != "" {
+		parts = append(parts, description)
+	}
+	if checklist := strings.Join(lines, "\n"); checklist != "" {
+		parts = append(parts, checklist)
+	}
+	return Render(strings.Join(parts, "\n\n"), vars, now)
+}
@@ -0,0 +1,63 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		text string
+		vars map[string]string
+		want string
+	}{
+		{"built-ins", "Review {{date}} ({{week}})", nil, "Review 2026-01-01 (2026-W01)"},
+		{"custom", "Pack for {{ destination }}", map[string]string{"destination": "Lisbon"}, "Pack for Lisbon"},
+		{"overridden built-in", "Review {{date}}", map[string]string{"date": "tomorrow"}, "Review tomorrow"},
+		{"missing left alone", "Call {{client}}", nil, "Call {{client}}"},
+		{"not a placeholder", "{{two words}} and {single}", nil, "{{two words}} and {single}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Render(tt.text, tt.vars, now))
+		})
+	}
+}
+
+func TestRender_ISOWeekCrossesYears(t *testing.T) {
+	assert.Equal(t, "2026-W53", Render("{{week}}", nil, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestVariables(t *testing.T) {
+	tmpl := Template{
+		Title:       "Trip to {{destination}} on {{date}}",
+		Description: "Flight {{flight}}",
+		Checklist:   "- [ ] Tickets for {{destination}}\n- [ ] {{ extra }}",
+	}
+
+	assert.Equal(t, []string{"destination", "flight", "extra"}, tmpl.Variables())
+	assert.Equal(t, []string{"flight", "extra"}, tmpl.Missing(map[string]string{"destination": "Rome"}))
+}
+
+func TestValidateChecklist(t *testing.T) {
+	assert.Empty(t, ValidateChecklist(""))
+	assert.Empty(t, ValidateChecklist("- [ ] Passport\n\n* [x] Charger\n  + [X] Nested"))
+	assert.Equal(t, `line 2 is not a checklist item such as "- [ ] item"`, ValidateChecklist("- [ ] Passport\nCharger"))
+	assert.NotEmpty(t, ValidateChecklist("- [ ] "))
+}
+
+func TestRenderDescription(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	tmpl := Template{Description: "Week {{week}}", Checklist: "- [x] Inbox zero\n- [ ] Plan {{focus}}\n"}
+
+	assert.Equal(t, "Week 2026-W10\n\n- [ ] Inbox zero\n- [ ] Plan hiring",
+		tmpl.RenderDescription(map[string]string{"focus": "hiring"}, now))
+	assert.Equal(t, "- [ ] Only", Template{Checklist: "- [ ] Only"}.RenderDescription(nil, now))
+	assert.Equal(t, "", Template{}.RenderDescription(nil, now))
+}
@@ -0,0 +1,14 @@
+package user
+
+import "time"
+
+type User struct {
+	ID           int64  `json:"id" db:"id"`
+	Email        string `json:"email" db:"email"`
+	PasswordHash string `json:"-" db:"password_hash"`
+	Name         string `json:"name" db:"name"`
+	// Timezone is an IANA zone name used for date views such as due=today
+	Timezone  string    `json:"timezone" db:"timezone"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
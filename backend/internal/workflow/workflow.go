@@ -0,0 +1,130 @@
+package workflow
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Column categories. Whatever a column is called, its category tells the rest of
+// the app whether work in it hasn't started, is under way or is finished.
+const (
+	CategoryTodo   = "todo"
+	CategoryActive = "active"
+	CategoryDone   = "done"
+)
+
+// Categories lists the column categories in board order
+var Categories = []string{CategoryTodo, CategoryActive, CategoryDone}
+
+// Column is one status on the board. WIPLimit caps how many tasks may be in
+// it at once; nil means no cap.
+type Column struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	WIPLimit *int   `json:"wip_limit"`
+}
+
+// Columns is stored as a JSON array
+type Columns []Column
+
+// Transitions maps a status to the statuses a task in it may move to.
+// A nil matrix allows every move.
+type Transitions map[string][]string
+
+// Workflow is the ordered set of statuses for a board. ProjectID is nil for
+// the user's default board, which also covers projects without their own.
+type Workflow struct {
+	ID          int64       `json:"id" db:"id"`
+	UserID      int64       `json:"-" db:"user_id"`
+	ProjectID   *int64      `json:"project_id" db:"project_id"`
+	Columns     Columns     `json:"columns" db:"columns"`
+	Transitions Transitions `json:"transitions" db:"transitions"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// Default is the workflow used until a user configures their own
+func Default() Workflow {
+	return Workflow{
+		Columns: Columns{
+			{Name: "TODO", Category: CategoryTodo},
+			{Name: "In Progress", Category: CategoryActive},
+			{Name: "Done", Category: CategoryDone},
+		},
+	}
+}
+
+// Statuses returns the column names in board order
+func (w Workflow) Statuses() []string {
+	statuses := make([]string, len(w.Columns))
+	for i, col := range w.Columns {
+		statuses[i] = col.Name
+	}
+	return statuses
+}
+
+// Column returns the column named status
+func (w Workflow) Column(status string) (Column, bool) {
+	for _, col := range w.Columns {
+		if col.Name == status {
+			return col, true
+		}
+	}
+	return Column{}, false
+}
+
+// Category returns the category of status, or "" when the workflow has no such column
+func (w Workflow) Category(status string) string {
+	col, _ := w.Column(status)
+	return col.Category
+}
+
+// InitialStatus is where new tasks start: the first column
+func (w Workflow) InitialStatus() string {
+	return w.Columns[0].Name
+}
+
+// CanTransition reports whether a task may move from one status to another
+func (w Workflow) CanTransition(from, to string) bool {
+	if w.Transitions == nil || from == to {
+		return true
+	}
+	return slices.Contains(w.Transitions[from], to)
+}
+
+func (c Columns) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *Columns) Scan(src any) error {
+	return scanJSON(src, c)
+}
+
+func (t Transitions) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return json.Marshal(t)
+}
+
+func (t *Transitions) Scan(src any) error {
+	if src == nil {
+		*t = nil
+		return nil
+	}
+	return scanJSON(src, t)
+}
+
+func scanJSON(src any, dest any) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return fmt.Errorf("cannot scan %T as JSON", src)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"tasker/internal/idempotency"
+	"tasker/internal/repository"
+)
+
+// jobInterval is how often the background jobs run
+const jobInterval = time.Hour
+
+// purgeTrash deletes tasks that have been in the trash for longer than
+// retention, every jobInterval for as long as the server runs
+func purgeTrash(retention time.Duration) {
+	for {
+		purged, err := repository.Tasks.PurgeTrash(retention)
+		if err != nil {
+			log.Printf("Failed to purge trash: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d tasks from the trash", purged)
+		}
+		time.Sleep(jobInterval)
+	}
+}
+
+// archiveDoneTasks archives tasks that have been done for longer than age,
+// every jobInterval for as long as the server runs
+func archiveDoneTasks(age time.Duration) {
+	for {
+		archived, err := repository.Tasks.ArchiveDoneTasks(age)
+		if err != nil {
+			log.Printf("Failed to archive done tasks: %v", err)
+		} else if archived > 0 {
+			log.Printf("Archived %d done tasks", archived)
+		}
+		time.Sleep(jobInterval)
+	}
+}
+
+// createDueOccurrences creates the next occurrence of recurring tasks whose
+// schedule has come due, every jobInterval for as long as the server runs
+func createDueOccurrences() {
+	for {
+		created, err := repository.Tasks.CreateDueOccurrences()
+		if err != nil {
+			log.Printf("Failed to create due occurrences: %v", err)
+		} else if created > 0 {
+			log.Printf("Created %d occurrences of recurring tasks", created)
+		}
+		time.Sleep(jobInterval)
+	}
+}
+
+// purgeIdempotencyKeys forgets idempotency keys older than idempotency.TTL,
+// every jobInterval for as long as the server runs
+func purgeIdempotencyKeys() {
+	for {
+		purged, err := repository.IdempotencyKeys.PurgeKeys(idempotency.TTL)
+		if err != nil {
+			log.Printf("Failed to purge idempotency keys: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d idempotency keys", purged)
+		}
+		time.Sleep(jobInterval)
+	}
+}
@@ -2,23 +2,29 @@ package main
 
 import (
 	"context"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	// Embed the zone database so user time zones work in minimal images
+	_ "time/tzdata"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
 
+	"tasker/internal/apitoken"
+	"tasker/internal/auth"
 	"tasker/internal/config"
 	"tasker/internal/database"
 	"tasker/internal/handlers"
+	"tasker/internal/idempotency"
+	"tasker/internal/migrate"
 	"tasker/internal/repository"
+	"tasker/migrations"
 )
 
 func main() {
@@ -48,20 +54,66 @@ func main() {
 	}
 	defer database.Close()
 
-	// Run migrations
-	if err := runMigrations(cfg); err != nil {
-		log.Printf("Warning: failed to run migrations: %v", err)
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	// `server migrate ...` manages the schema and exits without serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(migrator, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	// Run migrations; never serve on top of a half-migrated schema
+	if err := migrator.Up(); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Initialize token signing
+	secret := []byte(cfg.JWTSecret)
+	if len(secret) == 0 {
+		log.Printf("Warning: JWT_SECRET is not set, using a random secret; sessions will not survive a restart")
+		secret = auth.RandomSecret()
+	}
+	auth.Init(secret, time.Duration(cfg.JWTTTLHours)*time.Hour)
+
 	// Initialize repository
 	repository.Tasks = repository.NewTaskRepository(db)
+	repository.Projects = repository.NewProjectRepository(db)
+	repository.Users = repository.NewUserRepository(db)
+	repository.APITokens = repository.NewAPITokenRepository(db)
+	repository.Tags = repository.NewTagRepository(db)
+	repository.Workflows = repository.NewWorkflowRepository(db)
+	repository.Templates = repository.NewTemplateRepository(db)
+	repository.IdempotencyKeys = repository.NewIdempotencyKeyRepository(db)
+
+	// `server token create ...` mints an API token and exits without serving
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		if err := runTokenCommand(os.Args[2:]); err != nil {
+			log.Fatalf("Token command failed: %v", err)
+		}
+		return
+	}
+
+	// Empty the trash of tasks deleted longer ago than the retention period
+	if cfg.TrashRetentionDays > 0 {
+		go purgeTrash(time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour)
+	}
 
-	// Initialize ID generator from existing tasks
-	existingTasks, _ := repository.Tasks.GetAllTasks()
-	if len(existingTasks) > 0 {
-		handlers.InitTaskIDGenerator(existingTasks)
+	// Archive done tasks that have sat on the board for a while
+	if cfg.AutoArchiveDays > 0 {
+		go archiveDoneTasks(time.Duration(cfg.AutoArchiveDays) * 24 * time.Hour)
 	}
 
+	// Create the occurrences of recurring tasks as they come due
+	go createDueOccurrences()
+
+	// Forget idempotency keys once retries with them are no longer replayed
+	go purgeIdempotencyKeys()
+
 	// Setup router
 	r := setupRouter()
 
@@ -69,26 +121,6 @@ func main() {
 	startServer(r)
 }
 
-func runMigrations(cfg *config.Config) error {
-	migrationFiles := []string{
-		"migrations/000001_create_tasks_table.up.sql",
-	}
-
-	for _, file := range migrationFiles {
-		content, err := ioutil.ReadFile(file)
-		if err != nil {
-			return err
-		}
-
-		if _, err := database.DB.Exec(string(content)); err != nil {
-			return err
-		}
-		log.Printf("Migration completed: %s", file)
-	}
-
-	return nil
-}
-
 func healthCheckHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "hello world",
@@ -100,17 +132,84 @@ func setupRouter() *gin.Engine {
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:5174"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match", idempotency.Header},
+		ExposeHeaders:    []string{"Content-Length", "ETag", idempotency.ReplayedHeader},
 		AllowCredentials: true,
 	}))
 
 	r.GET("/", healthCheckHandler)
-	r.GET("/api/task", handlers.GetTaskHandler)
-	r.POST("/api/task", handlers.PostTaskHandler)
-	r.PUT("/api/task/:id", handlers.PutTaskHandler)
-	r.DELETE("/api/task/:id", handlers.DeleteTaskHandler)
+	r.POST("/api/auth/register", handlers.RegisterHandler)
+	r.POST("/api/auth/login", handlers.LoginHandler)
+
+	// Everything below requires a logged-in user or an API token, and writes
+	// can be retried safely with an Idempotency-Key
+	api := r.Group("/api", handlers.RequireAuth(), handlers.Idempotency())
+
+	api.POST("/auth/logout", handlers.RequireSession(), handlers.LogoutHandler)
+	api.GET("/auth/me", handlers.MeHandler)
+	api.PATCH("/auth/me", handlers.RequireSession(), handlers.UpdateMeHandler)
+
+	// API tokens can only be managed from a logged-in session
+	tokens := api.Group("/tokens", handlers.RequireSession())
+	tokens.GET("", handlers.GetAPITokensHandler)
+	tokens.POST("", handlers.PostAPITokenHandler)
+	tokens.DELETE("/:id", handlers.DeleteAPITokenHandler)
+
+	readTasks := handlers.RequireScope(apitoken.ScopeTasksRead)
+	writeTasks := handlers.RequireScope(apitoken.ScopeTasksWrite)
+	api.GET("/task", readTasks, handlers.GetTaskHandler)
+	api.GET("/task/:id", readTasks, handlers.GetTaskByIDHandler)
+	api.POST("/task", writeTasks, handlers.PostTaskHandler)
+	api.POST("/task/bulk", writeTasks, handlers.BulkTaskHandler)
+	api.POST("/task/:id/rekey", writeTasks, handlers.RekeyTaskHandler)
+	api.POST("/task/:id/move", writeTasks, handlers.MoveTaskHandler)
+	api.GET("/task/:id/history", readTasks, handlers.GetTaskHistoryHandler)
+	api.POST("/task/:id/revert", writeTasks, handlers.RevertTaskHandler)
+	api.POST("/task/:id/archive", writeTasks, handlers.ArchiveTaskHandler)
+	api.POST("/task/:id/unarchive", writeTasks, handlers.UnarchiveTaskHandler)
+	api.PUT("/task/:id", writeTasks, handlers.PutTaskHandler)
+	api.PATCH("/task/:id", writeTasks, handlers.PatchTaskHandler)
+	api.DELETE("/task/:id", writeTasks, handlers.DeleteTaskHandler)
+	api.PUT("/task/:id/recurrence", writeTasks, handlers.PutRecurrenceHandler)
+	api.DELETE("/task/:id/recurrence", writeTasks, handlers.DeleteRecurrenceHandler)
+	api.POST("/task/:id/skip", writeTasks, handlers.SkipOccurrenceHandler)
+	api.GET("/task/:id/subtasks", readTasks, handlers.GetSubtasksHandler)
+	api.POST("/task/:id/subtasks", writeTasks, handlers.PostSubtaskHandler)
+	api.POST("/task/:id/dependencies", writeTasks, handlers.PostDependencyHandler)
+	api.DELETE("/task/:id/dependencies/:blocker_id", writeTasks, handlers.DeleteDependencyHandler)
+	api.GET("/graph", readTasks, handlers.GetGraphHandler)
+	api.GET("/workflow", readTasks, handlers.GetWorkflowHandler)
+	api.PUT("/workflow", writeTasks, handlers.PutWorkflowHandler)
+	api.GET("/board", readTasks, handlers.GetBoardHandler)
+	api.POST("/undo", writeTasks, handlers.UndoHandler)
+	api.GET("/trash", readTasks, handlers.GetTrashHandler)
+	api.POST("/trash/:id/restore", writeTasks, handlers.RestoreTrashHandler)
+	api.DELETE("/trash/:id", writeTasks, handlers.DeleteTrashHandler)
+	api.GET("/archive", readTasks, handlers.GetArchiveHandler)
+
+	// Tags are part of tasks, so they share the task scopes
+	api.GET("/tags", readTasks, handlers.GetTagsHandler)
+	api.GET("/tags/:id", readTasks, handlers.GetTagHandler)
+	api.POST("/tags", writeTasks, handlers.PostTagHandler)
+	api.PUT("/tags/:id", writeTasks, handlers.PutTagHandler)
+	api.DELETE("/tags/:id", writeTasks, handlers.DeleteTagHandler)
+
+	// Templates only ever turn into tasks, so they share the task scopes too
+	api.GET("/templates", readTasks, handlers.GetTemplatesHandler)
+	api.GET("/templates/:id", readTasks, handlers.GetTemplateHandler)
+	api.POST("/templates", writeTasks, handlers.PostTemplateHandler)
+	api.PUT("/templates/:id", writeTasks, handlers.PutTemplateHandler)
+	api.DELETE("/templates/:id", writeTasks, handlers.DeleteTemplateHandler)
+	api.POST("/templates/:id/instantiate", writeTasks, handlers.InstantiateTemplateHandler)
+
+	readProjects := handlers.RequireScope(apitoken.ScopeProjectsRead)
+	writeProjects := handlers.RequireScope(apitoken.ScopeProjectsWrite)
+	api.GET("/projects", readProjects, handlers.GetProjectsHandler)
+	api.GET("/projects/:id", readProjects, handlers.GetProjectHandler)
+	api.POST("/projects", writeProjects, handlers.PostProjectHandler)
+	api.PUT("/projects/:id", writeProjects, handlers.PutProjectHandler)
+	api.DELETE("/projects/:id", writeProjects, handlers.DeleteProjectHandler)
 
 	return r
 }
@@ -1,9 +1,23 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"tasker/internal/auth"
+	"tasker/internal/database"
+	"tasker/internal/handlers"
+	"tasker/internal/migrate"
+	"tasker/internal/repository"
+	"tasker/internal/user"
+	"tasker/migrations"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -33,3 +47,182 @@ func TestNotFoundRoute(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
+
+func TestTaskRoutesRequireAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := setupRouter()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/task", nil)
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestConcurrentTaskCreatesGetUniqueIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tasks := handlers.NewMockTaskRepository()
+	repository.Tasks = tasks
+	repository.Projects = handlers.NewMockProjectRepository(tasks)
+	repository.Users = handlers.NewMockUserRepository()
+	repository.Workflows = handlers.NewMockWorkflowRepository(tasks)
+
+	auth.Init([]byte("test-secret"), time.Hour)
+	token, _, err := auth.IssueToken(1)
+	assert.NoError(t, err)
+
+	r := setupRouter()
+
+	const creates = 300
+	ids := make(chan string, creates)
+
+	var wg sync.WaitGroup
+	for i := range creates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			body := fmt.Sprintf(`{"title": "Concurrent task %d"}`, i)
+			req, _ := http.NewRequest("POST", "/api/task", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Errorf("expected 201, got %d: %s", w.Code, w.Body.String())
+				return
+			}
+
+			var created struct {
+				ID string `json:"id"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &created)
+			ids <- created.ID
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool)
+	for id := range ids {
+		assert.False(t, seen[id], "duplicate task id %s", id)
+		seen[id] = true
+	}
+
+	assert.Equal(t, creates, len(seen))
+	for i := 1; i <= creates; i++ {
+		assert.True(t, seen[fmt.Sprintf("TASK-%03d", i)], "missing TASK-%03d", i)
+	}
+}
+
+// setupDatabase points the repositories at the migrated Postgres database in
+// DATABASE_URL, skipping the test when there isn't one
+func setupDatabase(t *testing.T) {
+	t.Helper()
+
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+
+	db, err := database.Connect(url)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	repository.Tasks = repository.NewTaskRepository(db)
+	repository.Projects = repository.NewProjectRepository(db)
+	repository.Users = repository.NewUserRepository(db)
+	repository.APITokens = repository.NewAPITokenRepository(db)
+	repository.Tags = repository.NewTagRepository(db)
+	repository.Workflows = repository.NewWorkflowRepository(db)
+	repository.Templates = repository.NewTemplateRepository(db)
+	repository.IdempotencyKeys = repository.NewIdempotencyKeyRepository(db)
+}
+
+func TestConcurrentTaskCreatesGetUniqueIDs_Postgres(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupDatabase(t)
+
+	// Every user numbers their own keys, so a fresh user gets TASK-001 onwards
+	u, err := repository.Users.CreateUser(user.User{
+		Email: fmt.Sprintf("concurrent-%d@example.com", time.Now().UnixNano()),
+		Name:  "Concurrent",
+	})
+	assert.NoError(t, err)
+
+	auth.Init([]byte("test-secret"), time.Hour)
+	token, _, err := auth.IssueToken(u.ID)
+	assert.NoError(t, err)
+
+	r := setupRouter()
+
+	const creates = 100
+	ids := make(chan string, creates)
+
+	var wg sync.WaitGroup
+	for i := range creates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			body := fmt.Sprintf(`{"title": "Concurrent task %d"}`, i)
+			req, _ := http.NewRequest("POST", "/api/task", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Errorf("expected 201, got %d: %s", w.Code, w.Body.String())
+				return
+			}
+
+			var created struct {
+				ID string `json:"id"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &created)
+			ids <- created.ID
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool)
+	for id := range ids {
+		assert.True(t, strings.HasPrefix(id, "TASK-"), "unexpected task id %s", id)
+		assert.False(t, seen[id], "duplicate task id %s", id)
+		seen[id] = true
+	}
+	assert.Equal(t, creates, len(seen))
+	for i := 1; i <= creates; i++ {
+		assert.True(t, seen[fmt.Sprintf("TASK-%03d", i)], "TASK-%03d was not handed out", i)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/task", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var listed []struct {
+		ID string `json:"id"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Equal(t, creates, len(listed))
+	for _, l := range listed {
+		assert.True(t, seen[l.ID], "listed task %s was not created", l.ID)
+	}
+}
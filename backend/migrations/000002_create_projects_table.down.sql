@@ -0,0 +1,6 @@
+-- Drop project link from tasks
+DROP INDEX IF EXISTS idx_tasks_project_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS project_id;
+
+-- Drop projects table
+DROP TABLE IF EXISTS projects;
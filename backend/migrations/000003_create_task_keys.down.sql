@@ -0,0 +1,6 @@
+-- Drop key aliases
+DROP INDEX IF EXISTS idx_task_key_aliases_task_id;
+DROP TABLE IF EXISTS task_key_aliases;
+
+-- Drop key sequences
+DROP TABLE IF EXISTS task_key_sequences;
@@ -0,0 +1,20 @@
+-- One sequence per task key prefix (TASK-1, HOME-1, WORK-1, ...)
+CREATE TABLE IF NOT EXISTS task_key_sequences (
+    prefix VARCHAR(20) PRIMARY KEY,
+    next_value INTEGER NOT NULL DEFAULT 1
+);
+
+-- Continue the default TASK prefix after the highest existing TASK-NNN id
+INSERT INTO task_key_sequences (prefix, next_value)
+SELECT 'TASK', COALESCE(MAX(CAST(SUBSTRING(id FROM '^TASK-([0-9]+)$') AS INTEGER)), 0) + 1
+FROM tasks
+ON CONFLICT (prefix) DO NOTHING;
+
+-- Old keys of re-keyed tasks keep resolving to the task
+CREATE TABLE IF NOT EXISTS task_key_aliases (
+    alias VARCHAR(50) PRIMARY KEY,
+    task_id VARCHAR(50) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_key_aliases_task_id ON task_key_aliases(task_id);
@@ -0,0 +1,12 @@
+-- Drop ownership columns
+DROP INDEX IF EXISTS idx_projects_user_id;
+DROP INDEX IF EXISTS idx_tasks_user_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS user_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS user_id;
+
+-- Drop revoked tokens
+DROP TABLE IF EXISTS revoked_tokens;
+
+-- Drop users table
+DROP INDEX IF EXISTS idx_users_email;
+DROP TABLE IF EXISTS users;
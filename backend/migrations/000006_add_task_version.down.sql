@@ -0,0 +1,2 @@
+-- Drop task versions
+ALTER TABLE tasks DROP COLUMN IF EXISTS version;
@@ -0,0 +1,2 @@
+-- Optimistic concurrency: every write to a task bumps its version
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
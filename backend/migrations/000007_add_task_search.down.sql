@@ -0,0 +1,5 @@
+-- Drop task search and pagination indexes
+DROP INDEX IF EXISTS idx_tasks_user_updated;
+DROP INDEX IF EXISTS idx_tasks_user_created;
+DROP INDEX IF EXISTS idx_tasks_search_vector;
+ALTER TABLE tasks DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,6 @@
+-- Drop task start and due dates
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+DROP INDEX IF EXISTS idx_tasks_user_due;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_start_before_due;
+ALTER TABLE tasks DROP COLUMN IF EXISTS due_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS start_at;
@@ -0,0 +1,13 @@
+-- Start and due dates. Stored with a time zone so "today" can be worked out
+-- in whichever zone the user lives in.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS start_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_start_before_due;
+ALTER TABLE tasks ADD CONSTRAINT tasks_start_before_due
+    CHECK (start_at IS NULL OR due_at IS NULL OR start_at <= due_at);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_due ON tasks(user_id, due_at);
+
+-- IANA zone used for date views such as due=today
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,3 @@
+-- Drop tags
+DROP TABLE IF EXISTS task_tags;
+DROP TABLE IF EXISTS tags;
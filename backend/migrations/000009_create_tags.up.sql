@@ -0,0 +1,20 @@
+-- Tags cut across projects. Tasks refer to tags by id, so renaming a tag
+-- renames it on every task and deleting it removes it from every task.
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    color_hex VARCHAR(7) NOT NULL DEFAULT '#6B7280',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name ON tags(user_id, LOWER(name));
+
+CREATE TABLE IF NOT EXISTS task_tags (
+    task_id VARCHAR(50) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tags_tag_id ON task_tags(tag_id);
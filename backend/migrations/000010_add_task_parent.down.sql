@@ -0,0 +1,4 @@
+-- Drop subtasks
+DROP INDEX IF EXISTS idx_tasks_parent_id;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_not_own_parent;
+ALTER TABLE tasks DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,10 @@
+-- Subtasks. Deleting a parent is handled by the application (reject or
+-- cascade); the database only promotes children whose parent disappears
+-- some other way, such as a project cascade.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS parent_id VARCHAR(50)
+    REFERENCES tasks(id) ON DELETE SET NULL ON UPDATE CASCADE;
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_not_own_parent;
+ALTER TABLE tasks ADD CONSTRAINT tasks_not_own_parent CHECK (parent_id <> id);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks(parent_id);
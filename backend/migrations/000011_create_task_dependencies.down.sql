@@ -0,0 +1,2 @@
+-- Drop blocking dependencies
+DROP TABLE IF EXISTS task_dependencies;
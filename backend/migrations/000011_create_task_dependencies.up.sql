@@ -0,0 +1,10 @@
+-- Blocking dependencies: blocked_id can't be completed until blocker_id is Done
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    blocker_id VARCHAR(50) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    blocked_id VARCHAR(50) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT task_dependencies_not_self CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocked_id ON task_dependencies(blocked_id);
@@ -0,0 +1,3 @@
+-- Drop task estimates
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_estimate_not_negative;
+ALTER TABLE tasks DROP COLUMN IF EXISTS estimate_hours;
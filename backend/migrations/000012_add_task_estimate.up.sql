@@ -0,0 +1,6 @@
+-- Effort estimate in hours, used to plan dependency chains
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimate_hours NUMERIC(7, 2);
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_estimate_not_negative;
+ALTER TABLE tasks ADD CONSTRAINT tasks_estimate_not_negative
+    CHECK (estimate_hours IS NULL OR estimate_hours >= 0);
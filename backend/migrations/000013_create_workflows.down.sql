@@ -0,0 +1,5 @@
+-- Drop board workflows
+DROP INDEX IF EXISTS idx_tasks_user_status_category;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_status_category;
+ALTER TABLE tasks DROP COLUMN IF EXISTS status_category;
+DROP TABLE IF EXISTS workflows;
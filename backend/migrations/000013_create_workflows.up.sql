@@ -0,0 +1,29 @@
+-- Board workflows: the ordered status columns of a board, each with a category
+-- (todo, active, done), and an optional matrix of allowed moves. A row without
+-- a project is the user's default board.
+CREATE TABLE IF NOT EXISTS workflows (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+    columns JSONB NOT NULL,
+    transitions JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workflows_user_project ON workflows(user_id, COALESCE(project_id, 0));
+
+-- Tasks keep their status's category so "done" works whatever the column is called
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS status_category VARCHAR(20) NOT NULL DEFAULT 'todo';
+
+UPDATE tasks SET status_category = CASE status
+    WHEN 'Done' THEN 'done'
+    WHEN 'In Progress' THEN 'active'
+    ELSE 'todo'
+END;
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_status_category;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_category
+    CHECK (status_category IN ('todo', 'active', 'done'));
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_status_category ON tasks(user_id, status_category);
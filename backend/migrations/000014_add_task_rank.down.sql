@@ -0,0 +1,3 @@
+-- Drop manual card order
+DROP INDEX IF EXISTS idx_tasks_user_status_rank;
+ALTER TABLE tasks DROP COLUMN IF EXISTS rank;
@@ -0,0 +1,16 @@
+-- Manual card order: within a status column tasks sort by rank, a fractional
+-- index key compared byte by byte
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS rank TEXT COLLATE "C";
+
+-- Keep the newest-first order boards showed so far. Trimming zeros keeps keys
+-- from ending in the lowest digit, which the key generator relies on.
+UPDATE tasks t SET rank = rtrim(lpad(r.n::text, 10, '0'), '0')
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY user_id, status ORDER BY created_at DESC, id) AS n
+    FROM tasks
+) r
+WHERE t.id = r.id AND t.rank IS NULL;
+
+ALTER TABLE tasks ALTER COLUMN rank SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_status_rank ON tasks(user_id, status, rank);
@@ -0,0 +1,2 @@
+-- Drop task history
+DROP TABLE IF EXISTS task_events;
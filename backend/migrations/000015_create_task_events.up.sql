@@ -0,0 +1,20 @@
+-- Task history: an append-only log of every create, update, move and delete,
+-- with the changed fields' values before and after. Rows outlive their task,
+-- so task_id is not a foreign key; re-keying moves the history along.
+CREATE TABLE IF NOT EXISTS task_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    task_id VARCHAR(50) NOT NULL,
+    actor_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    version INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete'));
+
+CREATE INDEX IF NOT EXISTS idx_task_events_user_task ON task_events(user_id, task_id, id);
+CREATE INDEX IF NOT EXISTS idx_task_events_user_created ON task_events(user_id, created_at);
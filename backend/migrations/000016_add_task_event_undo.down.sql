@@ -0,0 +1,10 @@
+-- Drop undo; reverts and undos stay in the history as plain updates
+DROP INDEX IF EXISTS idx_task_events_user_mutation;
+
+UPDATE task_events SET action = 'update' WHERE action IN ('revert', 'undo');
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete'));
+
+ALTER TABLE task_events DROP COLUMN IF EXISTS undone;
+ALTER TABLE task_events DROP COLUMN IF EXISTS mutation_id;
@@ -0,0 +1,15 @@
+-- Undo: events written in the same transaction make up one mutation, which
+-- POST /api/undo reverses as a whole. Events from before this migration each
+-- count as a mutation of their own; negative ids keep them apart from txids.
+ALTER TABLE task_events ADD COLUMN IF NOT EXISTS mutation_id BIGINT;
+UPDATE task_events SET mutation_id = -id WHERE mutation_id IS NULL;
+ALTER TABLE task_events ALTER COLUMN mutation_id SET DEFAULT txid_current();
+ALTER TABLE task_events ALTER COLUMN mutation_id SET NOT NULL;
+
+ALTER TABLE task_events ADD COLUMN IF NOT EXISTS undone BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete', 'revert', 'undo'));
+
+CREATE INDEX IF NOT EXISTS idx_task_events_user_mutation ON task_events(user_id, mutation_id);
@@ -0,0 +1,12 @@
+-- Drop the trash; tasks in it are deleted for good along with their history
+DELETE FROM task_events e USING tasks t
+    WHERE e.user_id = t.user_id AND e.task_id = t.id AND t.deleted_at IS NOT NULL;
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+
+UPDATE task_events SET action = 'update' WHERE action = 'restore';
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete', 'revert', 'undo'));
+
+DROP INDEX IF EXISTS idx_tasks_deleted_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
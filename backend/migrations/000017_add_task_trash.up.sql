@@ -0,0 +1,9 @@
+-- Trash: deleting a task sets deleted_at instead of removing the row. Tasks
+-- stay in the trash, history and all, until restored or purged.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted_at ON tasks(deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete', 'revert', 'undo', 'restore'));
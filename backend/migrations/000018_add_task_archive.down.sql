@@ -0,0 +1,8 @@
+-- Drop the archive; archived tasks go back on their boards
+UPDATE task_events SET action = 'update' WHERE action IN ('archive', 'unarchive');
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete', 'revert', 'undo', 'restore'));
+
+DROP INDEX IF EXISTS idx_tasks_user_archived_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,9 @@
+-- Archive: done tasks can be taken off the board, by hand or once they have
+-- sat in a done column for a while, and stay listed everywhere else
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_archived_at ON tasks(user_id, archived_at) WHERE archived_at IS NOT NULL;
+
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete', 'revert', 'undo', 'restore', 'archive', 'unarchive'));
@@ -0,0 +1,21 @@
+-- Recurring tasks: a series holds the rule, and each occurrence is a task of
+-- its own linked to it. occurs_at is the date an occurrence was scheduled for,
+-- which editing its due date leaves alone. next_at is when a scheduled series'
+-- next occurrence comes due; series that count from completion leave it NULL.
+CREATE TABLE IF NOT EXISTS task_series (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rule VARCHAR(200) NOT NULL,
+    after_completion BOOLEAN NOT NULL DEFAULT FALSE,
+    anchor TIMESTAMP NOT NULL,
+    next_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_series_next_at ON task_series(next_at) WHERE next_at IS NOT NULL;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS series_id INTEGER REFERENCES task_series(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS occurs_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_series_id ON tasks(series_id) WHERE series_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Drop task templates; tasks made from them stay
+DROP TABLE IF EXISTS task_templates;
@@ -0,0 +1,2 @@
+-- Drop idempotency keys; retries are no longer recognised
+DROP TABLE IF EXISTS idempotency_keys;
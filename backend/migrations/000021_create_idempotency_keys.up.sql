@@ -0,0 +1,16 @@
+-- Idempotency keys: the first response to a write carrying an Idempotency-Key
+-- header is kept for 24 hours and replayed to retries. status_code is NULL
+-- while that first request is still running. fingerprint is the SHA-256 of
+-- the request, so a key reused for a different request can be refused.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint CHAR(64) NOT NULL,
+    status_code INTEGER,
+    headers JSONB,
+    body BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
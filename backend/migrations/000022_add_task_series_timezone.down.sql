@@ -0,0 +1,2 @@
+-- Series go back to working out their rules in UTC
+ALTER TABLE task_series DROP COLUMN IF EXISTS timezone;
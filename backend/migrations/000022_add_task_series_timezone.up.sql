@@ -0,0 +1,6 @@
+-- Rules are worked out in the time zone a series was set up in, so weekdays,
+-- month days and the time of day follow the user's calendar across DST.
+-- Existing series take their owner's zone.
+ALTER TABLE task_series ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+UPDATE task_series s SET timezone = u.timezone FROM users u WHERE u.id = s.user_id;
@@ -0,0 +1,4 @@
+-- Setting or ending a task's recurrence is recorded in its history
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete', 'revert', 'undo', 'restore', 'archive', 'unarchive', 'recurrence'));
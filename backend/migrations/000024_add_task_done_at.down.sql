@@ -0,0 +1,3 @@
+-- Auto-archive goes back to counting from a task's last change
+DROP INDEX IF EXISTS idx_tasks_done_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS done_at;
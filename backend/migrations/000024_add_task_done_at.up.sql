@@ -0,0 +1,8 @@
+-- When a task last went into a done column. Auto-archive counts from here, so
+-- editing a finished task doesn't keep it on the board. Tasks already done
+-- count from their last change.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS done_at TIMESTAMP;
+
+UPDATE tasks SET done_at = updated_at WHERE status_category = 'done';
+
+CREATE INDEX IF NOT EXISTS idx_tasks_done_at ON tasks(done_at) WHERE status_category = 'done' AND archived_at IS NULL;
@@ -0,0 +1,5 @@
+-- Workflow events go back to being plain updates
+UPDATE task_events SET action = 'update' WHERE action = 'workflow';
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete', 'revert', 'undo', 'restore', 'archive', 'unarchive', 'recurrence'));
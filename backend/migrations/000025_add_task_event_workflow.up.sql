@@ -0,0 +1,5 @@
+-- Workflow changes that put a column in another category are recorded in the
+-- history of the tasks in it
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete', 'revert', 'undo', 'restore', 'archive', 'unarchive', 'recurrence', 'workflow'));
@@ -0,0 +1,49 @@
+-- Rekey events go back to being plain updates
+UPDATE task_events SET action = 'update' WHERE action = 'rekey';
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete', 'revert', 'undo', 'restore', 'archive', 'unarchive', 'recurrence', 'workflow'));
+
+-- Keys go back to being unique across users. This fails once two users have
+-- used the same key.
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_parent_id_fkey;
+ALTER TABLE task_key_aliases DROP CONSTRAINT IF EXISTS task_key_aliases_task_id_fkey;
+ALTER TABLE task_dependencies DROP CONSTRAINT IF EXISTS task_dependencies_blocker_id_fkey;
+ALTER TABLE task_dependencies DROP CONSTRAINT IF EXISTS task_dependencies_blocked_id_fkey;
+ALTER TABLE task_tags DROP CONSTRAINT IF EXISTS task_tags_task_id_fkey;
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_user_id_id_key;
+ALTER TABLE tasks ADD PRIMARY KEY (id);
+
+ALTER TABLE tasks ADD CONSTRAINT tasks_parent_id_fkey FOREIGN KEY (parent_id)
+    REFERENCES tasks(id) ON DELETE SET NULL ON UPDATE CASCADE;
+ALTER TABLE task_key_aliases ADD CONSTRAINT task_key_aliases_task_id_fkey FOREIGN KEY (task_id)
+    REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE task_dependencies ADD CONSTRAINT task_dependencies_blocker_id_fkey FOREIGN KEY (blocker_id)
+    REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE task_dependencies ADD CONSTRAINT task_dependencies_blocked_id_fkey FOREIGN KEY (blocked_id)
+    REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE task_tags ADD CONSTRAINT task_tags_task_id_fkey FOREIGN KEY (task_id)
+    REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE task_key_aliases DROP CONSTRAINT IF EXISTS task_key_aliases_user_id_alias_key;
+ALTER TABLE task_key_aliases ADD PRIMARY KEY (alias);
+ALTER TABLE task_key_aliases DROP COLUMN IF EXISTS user_id;
+
+ALTER TABLE task_dependencies DROP CONSTRAINT IF EXISTS task_dependencies_pkey;
+ALTER TABLE task_dependencies ADD PRIMARY KEY (blocker_id, blocked_id);
+ALTER TABLE task_dependencies DROP COLUMN IF EXISTS user_id;
+
+ALTER TABLE task_tags DROP COLUMN IF EXISTS user_id;
+
+-- One sequence per prefix again, after the highest key anyone has used
+DELETE FROM task_key_sequences;
+ALTER TABLE task_key_sequences DROP CONSTRAINT IF EXISTS task_key_sequences_pkey;
+ALTER TABLE task_key_sequences DROP COLUMN IF EXISTS user_id;
+ALTER TABLE task_key_sequences ADD PRIMARY KEY (prefix);
+
+INSERT INTO task_key_sequences (prefix, next_value)
+SELECT SUBSTRING(key FROM '^(.+)-[0-9]+$'), MAX(CAST(SUBSTRING(key FROM '-([0-9]+)$') AS INTEGER)) + 1
+FROM (SELECT id AS key FROM tasks UNION ALL SELECT alias FROM task_key_aliases) AS used
+WHERE key ~ '^.+-[0-9]+$'
+GROUP BY 1;
@@ -0,0 +1,62 @@
+-- Task keys belong to their owner: every user numbers their tasks from 1 under
+-- each prefix, and keys and aliases only need to be unique per user. Tasks
+-- that haven't been claimed yet have no owner, so (user_id, id) is a unique
+-- constraint rather than the primary key.
+ALTER TABLE task_key_aliases ADD COLUMN IF NOT EXISTS user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+UPDATE task_key_aliases a SET user_id = t.user_id FROM tasks t WHERE t.id = a.task_id;
+
+ALTER TABLE task_dependencies ADD COLUMN IF NOT EXISTS user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+UPDATE task_dependencies d SET user_id = t.user_id FROM tasks t WHERE t.id = d.blocked_id;
+ALTER TABLE task_dependencies ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE task_tags ADD COLUMN IF NOT EXISTS user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+UPDATE task_tags tt SET user_id = t.user_id FROM tasks t WHERE t.id = tt.task_id;
+ALTER TABLE task_tags ALTER COLUMN user_id SET NOT NULL;
+
+-- Everything referring to a task does so by its owner and key
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_parent_id_fkey;
+ALTER TABLE task_key_aliases DROP CONSTRAINT IF EXISTS task_key_aliases_task_id_fkey;
+ALTER TABLE task_dependencies DROP CONSTRAINT IF EXISTS task_dependencies_blocker_id_fkey;
+ALTER TABLE task_dependencies DROP CONSTRAINT IF EXISTS task_dependencies_blocked_id_fkey;
+ALTER TABLE task_tags DROP CONSTRAINT IF EXISTS task_tags_task_id_fkey;
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_pkey;
+ALTER TABLE tasks ALTER COLUMN id SET NOT NULL;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_user_id_id_key;
+ALTER TABLE tasks ADD CONSTRAINT tasks_user_id_id_key UNIQUE (user_id, id);
+
+ALTER TABLE tasks ADD CONSTRAINT tasks_parent_id_fkey FOREIGN KEY (user_id, parent_id)
+    REFERENCES tasks(user_id, id) ON DELETE SET NULL (parent_id) ON UPDATE CASCADE;
+ALTER TABLE task_key_aliases ADD CONSTRAINT task_key_aliases_task_id_fkey FOREIGN KEY (user_id, task_id)
+    REFERENCES tasks(user_id, id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE task_dependencies ADD CONSTRAINT task_dependencies_blocker_id_fkey FOREIGN KEY (user_id, blocker_id)
+    REFERENCES tasks(user_id, id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE task_dependencies ADD CONSTRAINT task_dependencies_blocked_id_fkey FOREIGN KEY (user_id, blocked_id)
+    REFERENCES tasks(user_id, id) ON DELETE CASCADE ON UPDATE CASCADE;
+ALTER TABLE task_tags ADD CONSTRAINT task_tags_task_id_fkey FOREIGN KEY (user_id, task_id)
+    REFERENCES tasks(user_id, id) ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE task_key_aliases DROP CONSTRAINT IF EXISTS task_key_aliases_pkey;
+ALTER TABLE task_key_aliases DROP CONSTRAINT IF EXISTS task_key_aliases_user_id_alias_key;
+ALTER TABLE task_key_aliases ADD CONSTRAINT task_key_aliases_user_id_alias_key UNIQUE (user_id, alias);
+
+ALTER TABLE task_dependencies DROP CONSTRAINT IF EXISTS task_dependencies_pkey;
+ALTER TABLE task_dependencies ADD PRIMARY KEY (user_id, blocker_id, blocked_id);
+
+-- Each user's numbering carries on after the highest key they have used
+-- under each prefix, aliases included
+DELETE FROM task_key_sequences;
+ALTER TABLE task_key_sequences ADD COLUMN IF NOT EXISTS user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE task_key_sequences DROP CONSTRAINT IF EXISTS task_key_sequences_pkey;
+ALTER TABLE task_key_sequences ADD PRIMARY KEY (user_id, prefix);
+
+INSERT INTO task_key_sequences (user_id, prefix, next_value)
+SELECT user_id, SUBSTRING(key FROM '^(.+)-[0-9]+$'), MAX(CAST(SUBSTRING(key FROM '-([0-9]+)$') AS INTEGER)) + 1
+FROM (SELECT user_id, id AS key FROM tasks UNION ALL SELECT user_id, alias FROM task_key_aliases) AS used
+WHERE user_id IS NOT NULL AND key ~ '^.+-[0-9]+$'
+GROUP BY 1, 2;
+
+-- Re-keying a task is recorded in its history
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action
+    CHECK (action IN ('create', 'update', 'move', 'delete', 'revert', 'undo', 'restore', 'archive', 'unarchive', 'recurrence', 'workflow', 'rekey'));
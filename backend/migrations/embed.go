@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migration files into the server binary.
+package migrations
+
+import "embed"
+
+// FS holds every NNNNNN_name.up.sql / NNNNNN_name.down.sql pair in this directory
+//
+//go:embed *.sql
+var FS embed.FS
@@ -1,11 +1,71 @@
-import type { CreateTaskInput, Task } from './types';
+import type { AuthSession, CreateTaskInput, Task } from './types';
 
-const API_BASE_URL = 'http://localhost:8080/api';
+export const API_BASE_URL = 'http://localhost:8080/api';
 
-export async function createTask(taskData: CreateTaskInput): Promise<Task> {
+// AUTH_COOKIE is the httpOnly cookie the backend keeps the session in
+export const AUTH_COOKIE = 'tasker_token';
+
+// Requests from the browser carry the session cookie. Server-side code has no
+// cookie jar, so it passes the session token on as a bearer token instead.
+function jsonHeaders(token?: string): HeadersInit {
+	if (!token) return { 'Content-Type': 'application/json' };
+	return { 'Content-Type': 'application/json', Authorization: `Bearer ${token}` };
+}
+
+export async function register(
+	email: string,
+	password: string,
+	name: string
+): Promise<AuthSession> {
+	const res = await fetch(`${API_BASE_URL}/auth/register`, {
+		method: 'POST',
+		headers: jsonHeaders(),
+		credentials: 'include',
+		body: JSON.stringify({ email, password, name })
+	});
+
+	if (!res.ok) {
+		const err = await res.json().catch(() => ({ error: 'Unknown error' }));
+		const details = err.details ? Object.values(err.details).join(', ') : '';
+		throw new Error(details || err.error || 'Failed to create account');
+	}
+
+	return res.json();
+}
+
+export async function login(email: string, password: string): Promise<AuthSession> {
+	const res = await fetch(`${API_BASE_URL}/auth/login`, {
+		method: 'POST',
+		headers: jsonHeaders(),
+		credentials: 'include',
+		body: JSON.stringify({ email, password })
+	});
+
+	if (!res.ok) {
+		const err = await res.json().catch(() => ({ error: 'Unknown error' }));
+		throw new Error(err.error || 'Failed to sign in');
+	}
+
+	return res.json();
+}
+
+export async function logout(): Promise<void> {
+	const res = await fetch(`${API_BASE_URL}/auth/logout`, {
+		method: 'POST',
+		credentials: 'include'
+	});
+
+	// A session that has already expired is as good as signed out
+	if (!res.ok && res.status !== 401) {
+		throw new Error('Failed to sign out');
+	}
+}
+
+export async function createTask(taskData: CreateTaskInput, token?: string): Promise<Task> {
 	const res = await fetch(`${API_BASE_URL}/task`, {
 		method: 'POST',
-		headers: { 'Content-Type': 'application/json' },
+		headers: jsonHeaders(token),
+		credentials: 'include',
 		body: JSON.stringify(taskData)
 	});
 
@@ -20,7 +80,8 @@ export async function createTask(taskData: CreateTaskInput): Promise<Task> {
 export async function deleteTask(taskId: string): Promise<void> {
 	const res = await fetch(`${API_BASE_URL}/task/${taskId}`, {
 		method: 'DELETE',
-		headers: { 'Content-Type': 'application/json' }
+		headers: jsonHeaders(),
+		credentials: 'include'
 	});
 
 	if (!res.ok) {
@@ -29,15 +90,23 @@ export async function deleteTask(taskId: string): Promise<void> {
 	}
 }
 
-export async function updateTaskStatus(taskId: string, status: string): Promise<Task> {
-	const res = await fetch(`${API_BASE_URL}/task/${taskId}`, {
-		method: 'PUT',
-		headers: { 'Content-Type': 'application/json' },
-		body: JSON.stringify({ status })
+// moveTask puts a card in status between the cards it was dropped after and
+// before, or at the bottom of the column when there are none
+export async function moveTask(
+	taskId: string,
+	status: string,
+	afterId?: string,
+	beforeId?: string
+): Promise<Task> {
+	const res = await fetch(`${API_BASE_URL}/task/${taskId}/move`, {
+		method: 'POST',
+		headers: jsonHeaders(),
+		credentials: 'include',
+		body: JSON.stringify({ status, after_id: afterId, before_id: beforeId })
 	});
 	if (!res.ok) {
 		const err = await res.json().catch(() => ({ message: 'Unknown error' }));
-		throw new Error(err.message || 'Failed to update task');
+		throw new Error(err.message || 'Failed to move task');
 	}
 
 	return res.json();
@@ -45,8 +114,9 @@ export async function updateTaskStatus(taskId: string, status: string): Promise<
 
 export async function updateTask(taskId: string, taskData: Partial<CreateTaskInput>): Promise<Task> {
 	const res = await fetch(`${API_BASE_URL}/task/${taskId}`, {
-		method: 'PUT',
-		headers: { 'Content-Type': 'application/json' },
+		method: 'PATCH',
+		headers: jsonHeaders(),
+		credentials: 'include',
 		body: JSON.stringify(taskData)
 	});
 
@@ -6,9 +6,35 @@ export interface Task {
 	priority: string;
 }
 
+export interface BoardColumn {
+	name: string;
+	category: string;
+	wip_limit?: number;
+	count: number;
+	tasks: Task[];
+}
+
+export interface Board {
+	project_id: number | null;
+	columns: BoardColumn[];
+}
+
 export interface CreateTaskInput {
 	title: string;
 	description?: string;
 	status?: string;
 	priority?: string;
 }
+
+export interface User {
+	id: number;
+	email: string;
+	name: string;
+	timezone: string;
+}
+
+export interface AuthSession {
+	token: string;
+	expires_at: string;
+	user: User;
+}
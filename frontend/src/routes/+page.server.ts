@@ -1,16 +1,27 @@
-import { fail } from '@sveltejs/kit';
-import { createTask } from '$lib/api';
+import { fail, redirect } from '@sveltejs/kit';
+import { API_BASE_URL, AUTH_COOKIE, createTask } from '$lib/api';
+import type { Board } from '$lib/types';
 import type { Actions, PageServerLoad } from './$types';
 
-export const load: PageServerLoad = async ({ fetch }) => {
-	const res = await fetch('http://localhost:8080/api/task');
+// The backend's session cookie is scoped to the host, not the port, so it
+// reaches this server as well and is passed on as a bearer token
+export const load: PageServerLoad = async ({ fetch, cookies }) => {
+	const token = cookies.get(AUTH_COOKIE);
+	if (!token) redirect(303, '/login');
+
+	// The board leaves out archived tasks, which GET /api/task still lists
+	const res = await fetch(`${API_BASE_URL}/board`, {
+		headers: { Authorization: `Bearer ${token}` }
+	});
+	if (res.status === 401) redirect(303, '/login');
 	if (!res.ok) throw new Error(`HTTP error! status: ${res.status}`);
-	const tasks = await res.json();
+	const board: Board = await res.json();
+	const tasks = board.columns.flatMap((column) => column.tasks);
 	return { tasks };
 };
 
 export const actions: Actions = {
-	createTask: async ({ request }) => {
+	createTask: async ({ request, cookies }) => {
 		const formData = await request.formData();
 		const title = formData.get('title');
 		const description = formData.get('description');
@@ -29,12 +40,15 @@ export const actions: Actions = {
 		}
 
 		try {
-			const task = await createTask({
-				title: title.trim(),
-				description: description?.toString() || '',
-				status: status.toString() as 'TODO' | 'In Progress' | 'Done',
-				priority: priority.toString() as 'Low' | 'Medium' | 'High'
-			});
+			const task = await createTask(
+				{
+					title: title.trim(),
+					description: description?.toString() || '',
+					status: status.toString() as 'TODO' | 'In Progress' | 'Done',
+					priority: priority.toString() as 'Low' | 'Medium' | 'High'
+				},
+				cookies.get(AUTH_COOKIE)
+			);
 
 			return { success: true, task };
 		} catch (e) {
@@ -0,0 +1,28 @@
+import { describe, expect, it, vi } from 'vitest';
+import type { Board, Task } from '$lib/types';
+import { load } from './+page.server';
+
+function card(id: string, status: string): Task {
+	return { id, title: id, description: '', status, priority: 'Medium' };
+}
+
+describe('/+page.server.ts', () => {
+	it('loads the cards on the board, leaving archived tasks out', async () => {
+		// TASK-003 is archived, so the board doesn't return it
+		const board: Board = {
+			project_id: null,
+			columns: [
+				{ name: 'TODO', category: 'todo', count: 1, tasks: [card('TASK-001', 'TODO')] },
+				{ name: 'In Progress', category: 'active', count: 0, tasks: [] },
+				{ name: 'Done', category: 'done', count: 1, tasks: [card('TASK-002', 'Done')] }
+			]
+		};
+		const fetch = vi.fn(async () => new Response(JSON.stringify(board)));
+		const cookies = { get: () => 'token' };
+
+		const data = await load({ fetch, cookies } as unknown as Parameters<typeof load>[0]);
+
+		expect(fetch).toHaveBeenCalledWith('http://localhost:8080/api/board', expect.anything());
+		expect((data as { tasks: Task[] }).tasks.map((t) => t.id)).toEqual(['TASK-001', 'TASK-002']);
+	});
+});